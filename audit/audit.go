@@ -98,11 +98,11 @@ func (a *Audit) Shutdown() {
 	}
 }
 
-func (a *Audit) onQueueFull(rec *logr.LogRec, maxQueueSize int) bool {
+func (a *Audit) onQueueFull(rec *logr.LogRec, stats logr.QueueFullStats) bool {
 	if a.OnQueueFull != nil {
-		return a.OnQueueFull("main", maxQueueSize)
+		return a.OnQueueFull("main", stats.MaxQueueSize)
 	}
-	mlog.Error("Audit logging queue full, dropping record.", mlog.Int("queueSize", maxQueueSize))
+	mlog.Error("Audit logging queue full, dropping record.", mlog.Int("queueSize", stats.MaxQueueSize), mlog.Uint64("dropped", stats.Dropped))
 	return true
 }
 
@@ -133,3 +133,5 @@ require (
 	honnef.co/go/tools v0.0.1-2020.1.3 // indirect
 	willnorris.com/go/imageproxy v0.10.0
 )
+
+replace github.com/mattermost/logr => ./logr
@@ -0,0 +1,77 @@
+package logr
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff calculates exponential backoff delays between retry attempts.
+// The zero value is usable and provides defaults.
+type Backoff struct {
+	// Initial is the delay before the first retry. Defaults to DefaultBackoffInitial.
+	Initial time.Duration
+
+	// Max is the maximum delay between retries. Defaults to DefaultBackoffMax.
+	Max time.Duration
+
+	// Multiplier is the factor the delay grows by per attempt. Defaults to 2.
+	Multiplier float64
+
+	// Jitter is the fraction (0.0 - 1.0) of each delay that is randomized to
+	// avoid many clients retrying in lock step. Defaults to no jitter.
+	Jitter float64
+}
+
+// Delay returns the delay to wait before the specified attempt, where the
+// first retry is attempt 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	initial := b.Initial
+	if initial <= 0 {
+		initial = DefaultBackoffInitial
+	}
+	max := b.Max
+	if max <= 0 {
+		max = DefaultBackoffMax
+	}
+	mult := b.Multiplier
+	if mult < 1 {
+		mult = 2
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := float64(initial) * math.Pow(mult, float64(attempt-1))
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if b.Jitter > 0 {
+		jitter := math.Min(b.Jitter, 1.0)
+		delay = delay - (delay * jitter * rand.Float64())
+	}
+	return time.Duration(delay)
+}
+
+// Attempt returns the fields describing a retry attempt with the delay
+// sourced from this Backoff. See `Attempt`.
+func (b Backoff) Attempt(attempt int, maxAttempts int) Fields {
+	return Attempt(attempt, maxAttempts, b.Delay(attempt))
+}
+
+// Attempt returns fields describing a retry attempt so that retries are logged
+// consistently, e.g. `logger.WithFields(logr.Attempt(3, 5, delay)).Warn("retrying")`.
+// The attempt number is recorded under `FieldKeyAttempt`, the maximum number of
+// attempts under `FieldKeyMaxAttempts` (omitted when maxAttempts <= 0, meaning
+// unbounded), and the delay before the next attempt under `FieldKeyBackoff`
+// (omitted when zero).
+func Attempt(attempt int, maxAttempts int, delay time.Duration) Fields {
+	flds := Fields{FieldKeyAttempt: attempt}
+	if maxAttempts > 0 {
+		flds[FieldKeyMaxAttempts] = maxAttempts
+	}
+	if delay > 0 {
+		flds[FieldKeyBackoff] = delay
+	}
+	return flds
+}
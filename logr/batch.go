@@ -0,0 +1,123 @@
+package logr
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RecordBatchWriter is implemented by a RecordWriter that outputs log records
+// in batches, e.g. to ship them over the network in a single request. `Basic`
+// coalesces the records of such a target within the target's own queue
+// goroutine, so a slow or retrying batch holds up only that target. Batches
+// hold up to `Logr.BatchSize` records; a partial batch is written once its
+// oldest record has waited `Logr.BatchInterval`, and whenever the target is
+// flushed or shut down. In `Logr.SyncMode` each record is written as a batch
+// of one. `Write` is not called for log records.
+type RecordBatchWriter interface {
+	// WriteBatch outputs the log records, in order, to the target's
+	// destination. The slice and records are reused once WriteBatch returns.
+	WriteBatch(recs []*LogRec) error
+}
+
+// addToBatch adds a log record to the batch, writing the batch once it
+// reaches `Logr.BatchSize`.
+func (b *Basic) addToBatch(rec *LogRec) {
+	lgr := rec.Logger().Logr()
+	if len(b.batch) == 0 {
+		b.batchDue = time.Now().Add(lgr.batchInterval())
+	}
+	b.batch = append(b.batch, rec)
+	if len(b.batch) >= lgr.batchSize() {
+		b.writeBatch()
+	}
+}
+
+// writeBatch writes any batched log records, counting them or the failure,
+// then releases them.
+func (b *Basic) writeBatch() {
+	recs := b.batch
+	if len(recs) == 0 {
+		return
+	}
+	defer func() {
+		for i := range recs {
+			ReleaseLogRec(recs[i])
+			recs[i] = nil
+		}
+		b.batch = recs[:0]
+	}()
+
+	if err := b.bw.WriteBatch(recs); err != nil {
+		b.writeFailed(recs[0], err)
+		return
+	}
+	if atomic.LoadInt64(&b.consecutiveFailures) != 0 {
+		atomic.StoreInt64(&b.consecutiveFailures, 0)
+	}
+	if b.loggedCounter != nil {
+		b.loggedCounter.Add(float64(len(recs)))
+	}
+}
+
+// startBatching is `start` for a RecordBatchWriter, also writing partial
+// batches once due. Returns once the queue is closed.
+func (b *Basic) startBatching() {
+	timer := time.NewTimer(time.Hour)
+	stopTimer(timer)
+	defer timer.Stop()
+
+	var due <-chan time.Time
+	for {
+		select {
+		case rec, ok := <-b.in:
+			if !ok {
+				return
+			}
+			if rec.flush != nil {
+				b.flush(rec)
+			} else {
+				b.write(rec)
+			}
+		case <-due:
+			due = nil
+			b.writeBatch()
+		}
+
+		// arm the timer when a batch is started, disarm it once written.
+		switch {
+		case len(b.batch) > 0 && due == nil:
+			timer.Reset(time.Until(b.batchDue))
+			due = timer.C
+		case len(b.batch) == 0 && due != nil:
+			stopTimer(timer)
+			due = nil
+		}
+	}
+}
+
+// stopTimer stops a timer, draining a fire that was not received so that a
+// later Reset cannot fire early.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+// batchSize returns the maximum number of log records per batch.
+func (logr *Logr) batchSize() int {
+	if logr.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return logr.BatchSize
+}
+
+// batchInterval returns the maximum time a log record waits in a partial batch.
+func (logr *Logr) batchInterval() time.Duration {
+	if logr.BatchInterval <= 0 {
+		return DefaultBatchInterval
+	}
+	return logr.BatchInterval
+}
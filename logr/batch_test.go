@@ -0,0 +1,188 @@
+package logr_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/test"
+)
+
+// batchTarget records the messages of each batch written, blocking while its
+// gate is closed.
+type batchTarget struct {
+	logr.Basic
+	gate chan struct{}
+	err  error
+
+	mux     sync.Mutex
+	batches [][]string
+}
+
+func newBatchTarget(open bool) *batchTarget {
+	bt := &batchTarget{gate: make(chan struct{})}
+	if open {
+		close(bt.gate)
+	}
+	bt.Basic.Start(bt, bt, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	return bt
+}
+
+func (bt *batchTarget) Write(rec *logr.LogRec) error {
+	return errors.New("Write called for a batch writer")
+}
+
+func (bt *batchTarget) WriteBatch(recs []*logr.LogRec) error {
+	<-bt.gate
+	msgs := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		msgs = append(msgs, rec.Msg())
+	}
+	bt.mux.Lock()
+	defer bt.mux.Unlock()
+	bt.batches = append(bt.batches, msgs)
+	return bt.err
+}
+
+func (bt *batchTarget) sizes() []int {
+	bt.mux.Lock()
+	defer bt.mux.Unlock()
+	sizes := make([]int, 0, len(bt.batches))
+	for _, b := range bt.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func TestBatchSize(t *testing.T) {
+	lgr := &logr.Logr{BatchSize: 3, BatchInterval: time.Hour}
+	bt := newBatchTarget(true)
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 7; i++ {
+		logger.Info("batched")
+	}
+	// flush forces out the partial batch.
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if sizes := bt.sizes(); len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Errorf("expected batches of 3, 3 and 1, got %v", sizes)
+	}
+
+	logger.Info("last")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if sizes := bt.sizes(); len(sizes) != 4 || sizes[3] != 1 {
+		t.Errorf("expected a partial batch written on shutdown, got %v", sizes)
+	}
+}
+
+func TestBatchInterval(t *testing.T) {
+	lgr := &logr.Logr{BatchSize: 100, BatchInterval: 20 * time.Millisecond}
+	bt := newBatchTarget(true)
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+	defer lgr.Shutdown()
+
+	logger := lgr.NewLogger()
+	logger.Info("one")
+	logger.Info("two")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(bt.sizes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("partial batch not written after BatchInterval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sizes := bt.sizes(); len(sizes) != 1 || sizes[0] != 2 {
+		t.Errorf("expected one batch of 2, got %v", sizes)
+	}
+}
+
+func TestBatchSlowTargetDoesNotBlockOthers(t *testing.T) {
+	lgr := &logr.Logr{BatchSize: 1}
+	slow := newBatchTarget(false)
+	buf := &test.Buffer{}
+	fast := test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{Delim: " | "}, buf, 100)
+	_ = lgr.AddTarget(slow)
+	_ = lgr.AddTarget(fast)
+
+	// the batch target is stuck writing; records still reach the other target.
+	logger := lgr.NewLogger()
+	for i := 0; i < 10; i++ {
+		logger.Info("delivered")
+	}
+	if err := lgr.FlushTarget(fast); err != nil {
+		t.Fatal(err)
+	}
+	if len(slow.sizes()) != 0 {
+		t.Error("expected the batch target to be blocked")
+	}
+
+	close(slow.gate)
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if n := len(slow.sizes()); n != 10 {
+		t.Errorf("expected 10 batches once unblocked, got %d", n)
+	}
+}
+
+func TestBatchSyncMode(t *testing.T) {
+	lgr := &logr.Logr{SyncMode: true, BatchInterval: time.Hour}
+	bt := newBatchTarget(true)
+	_ = lgr.AddTarget(bt)
+
+	lgr.NewLogger().Info("immediate")
+	if sizes := bt.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("expected a batch of one written immediately, got %v", sizes)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatchErrorCounted(t *testing.T) {
+	lgr := &logr.Logr{BatchSize: 2}
+	var mux sync.Mutex
+	var reported []error
+	lgr.OnLoggerError = func(err error) {
+		mux.Lock()
+		defer mux.Unlock()
+		reported = append(reported, err)
+	}
+	bt := newBatchTarget(true)
+	bt.err = errors.New("sink unavailable")
+	bt.SetName("batch")
+	_ = lgr.AddTarget(bt)
+	collector := test.NewTestMetricsCollector()
+	if err := lgr.SetMetricsCollector(collector); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := lgr.NewLogger()
+	logger.Info("one")
+	logger.Info("two")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if n := collector.Get("batch").Errors; n != 1 {
+		t.Errorf("expected 1 error counted, got %v", n)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(reported) != 1 {
+		t.Errorf("expected 1 reported error, got %v", reported)
+	}
+}
@@ -0,0 +1,84 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimingCollector is an optional interface a `MetricsCollector` can implement
+// to receive the cumulative time spent delivering log records to each target.
+type TimingCollector interface {
+	// LogTimeCounter returns a Counter that will be increased by the number of
+	// seconds spent delivering log records to the named target.
+	LogTimeCounter(target string) (Counter, error)
+}
+
+// targetTiming accumulates the time spent delivering log records to a target.
+type targetTiming struct {
+	nanos   int64
+	counter Counter
+}
+
+// targetTimings holds a *targetTiming per Target.
+type targetTimings struct {
+	m sync.Map
+}
+
+// logTimed delivers a log record to a target, adding the time taken to the
+// target's running total. Timing is only done when metrics are enabled.
+func (logr *Logr) logTimed(target Target, rec *LogRec) {
+	if logr.metrics == nil {
+		target.Log(rec)
+		return
+	}
+
+	start := time.Now()
+	target.Log(rec)
+	logr.addTargetTime(target, time.Since(start))
+}
+
+// addTargetTime adds the time taken delivering to a target to its running total.
+func (logr *Logr) addTargetTime(target Target, elapsed time.Duration) {
+	v, ok := logr.targetTimings.m.Load(target)
+	if !ok {
+		tt := &targetTiming{}
+		if collector, ok := logr.metrics.(TimingCollector); ok {
+			counter, err := collector.LogTimeCounter(metricsName(target))
+			if err != nil {
+				logr.ReportError(err)
+			}
+			tt.counter = counter
+		}
+		v, _ = logr.targetTimings.m.LoadOrStore(target, tt)
+	}
+	tt := v.(*targetTiming)
+	atomic.AddInt64(&tt.nanos, int64(elapsed))
+	if tt.counter != nil {
+		tt.counter.Add(elapsed.Seconds())
+	}
+}
+
+// BottleneckTarget returns the target with the highest cumulative time spent
+// accepting log records, and that time. Since targets are delivered to
+// sequentially, this is the likely bottleneck when records back up in the
+// Logr queue. Timing is only measured while a `MetricsCollector` is set;
+// returns nil and zero duration when no timings are available.
+func (logr *Logr) BottleneckTarget() (Target, time.Duration) {
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+
+	var bottleneck Target
+	var max int64
+	for _, target := range logr.targets {
+		v, ok := logr.targetTimings.m.Load(target)
+		if !ok {
+			continue
+		}
+		if nanos := atomic.LoadInt64(&v.(*targetTiming).nanos); nanos > max {
+			bottleneck = target
+			max = nanos
+		}
+	}
+	return bottleneck, time.Duration(max)
+}
@@ -0,0 +1,71 @@
+package logr
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// bufferTracker tracks outstanding borrowed buffers when `Logr.DebugBuffers`
+// is true.
+type bufferTracker struct {
+	mux         sync.Mutex
+	outstanding map[*bytes.Buffer]struct{}
+	warnAt      int
+}
+
+// trackBorrow records a borrowed buffer, reporting when the number of
+// outstanding buffers reaches the leak threshold.
+func (logr *Logr) trackBorrow(buf *bytes.Buffer) {
+	t := &logr.bufTracker
+	t.mux.Lock()
+	if t.outstanding == nil {
+		t.outstanding = make(map[*bytes.Buffer]struct{})
+		t.warnAt = logr.bufferLeakThreshold()
+	}
+	t.outstanding[buf] = struct{}{}
+	count := len(t.outstanding)
+	warn := count >= t.warnAt
+	if warn {
+		t.warnAt *= 2
+	}
+	t.mux.Unlock()
+
+	if warn {
+		logr.ReportError(fmt.Errorf("%d borrowed buffers outstanding; a target or formatter may not be calling ReleaseBuffer", count))
+	}
+}
+
+// trackRelease forgets a released buffer. Returns false, after reporting,
+// if the buffer is not outstanding, i.e. it was already released or was not
+// borrowed via `BorrowBuffer`.
+func (logr *Logr) trackRelease(buf *bytes.Buffer) bool {
+	t := &logr.bufTracker
+	t.mux.Lock()
+	_, ok := t.outstanding[buf]
+	delete(t.outstanding, buf)
+	t.mux.Unlock()
+
+	if !ok {
+		logr.ReportError(fmt.Errorf("buffer %p released twice or not borrowed via BorrowBuffer", buf))
+	}
+	return ok
+}
+
+// OutstandingBuffers returns the number of buffers borrowed via `BorrowBuffer`
+// and not yet released. Always returns zero unless `DebugBuffers` is true.
+func (logr *Logr) OutstandingBuffers() int {
+	t := &logr.bufTracker
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return len(t.outstanding)
+}
+
+// bufferLeakThreshold returns the outstanding buffer count that is reported
+// when `DebugBuffers` is true.
+func (logr *Logr) bufferLeakThreshold() int {
+	if logr.BufferLeakThreshold <= 0 {
+		return DefaultBufferLeakThreshold
+	}
+	return logr.BufferLeakThreshold
+}
@@ -0,0 +1,54 @@
+package logr
+
+import "runtime"
+
+// callerCaptureSlack is the number of stack frames captured for the caller,
+// in addition to `CallerSkip`, to allow for frames within logr.
+const callerCaptureSlack = 8
+
+// captureCaller captures the program counters needed to resolve the caller
+// of a logging call when `EnableCaller` is true, otherwise returns nil.
+// Must be called directly by `NewLogRec`.
+func (logr *Logr) captureCaller() []uintptr {
+	if logr == nil || !logr.EnableCaller {
+		return nil
+	}
+	pcs := make([]uintptr, callerCaptureSlack+logr.callerSkip())
+	n := runtime.Callers(3, pcs) // skip Callers, captureCaller and NewLogRec
+	return pcs[:n]
+}
+
+// callerSkip returns the number of frames to skip after leading logr frames.
+func (logr *Logr) callerSkip() int {
+	if logr.CallerSkip < 0 {
+		return 0
+	}
+	return logr.CallerSkip
+}
+
+// resolveCaller returns the first frame outside of logr, after skipping
+// `CallerSkip` frames.
+func (logr *Logr) resolveCaller(pcs []uintptr) (runtime.Frame, bool) {
+	skip := logr.callerSkip()
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if pkg := getPackageName(f.Function); pkg != "" && pkg != logrPkg {
+			if skip == 0 {
+				return f, true
+			}
+			skip--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// Caller returns the source location of the logging call that created this
+// log record, or false if not captured. See `Logr.EnableCaller`.
+func (rec *LogRec) Caller() (runtime.Frame, bool) {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+	return rec.caller, rec.caller.PC != 0
+}
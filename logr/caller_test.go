@@ -0,0 +1,106 @@
+package logr_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+)
+
+// callerTarget records the caller of each record written.
+type callerTarget struct {
+	logr.Basic
+
+	mux     sync.Mutex
+	callers []runtime.Frame
+	found   []bool
+}
+
+func newCallerTarget() *callerTarget {
+	ct := &callerTarget{}
+	ct.Basic.Start(ct, ct, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	return ct
+}
+
+func (ct *callerTarget) Write(rec *logr.LogRec) error {
+	frame, ok := rec.Caller()
+	ct.mux.Lock()
+	defer ct.mux.Unlock()
+	ct.callers = append(ct.callers, frame)
+	ct.found = append(ct.found, ok)
+	return nil
+}
+
+// currentLine returns the line number of its caller.
+func currentLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// logVia logs from a helper, whose caller is skipped via CallerSkip.
+func logVia(logger logr.Logger) {
+	logger.Info("via helper")
+}
+
+func TestEnableCaller(t *testing.T) {
+	lgr := &logr.Logr{EnableCaller: true}
+	ct := newCallerTarget()
+	_ = lgr.AddTarget(ct)
+	logger := lgr.NewLogger()
+
+	var lines []int
+	lines = append(lines, currentLine()+1)
+	logger.Info("direct")
+	lines = append(lines, currentLine()+1)
+	logger.Infof("formatted %d", 1)
+	lines = append(lines, currentLine()+1)
+	logger.WithField("k", "v").Infow("sugared", "n", 1)
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if len(ct.callers) != len(lines) {
+		t.Fatalf("expected %d records, got %d", len(lines), len(ct.callers))
+	}
+	for i, frame := range ct.callers {
+		if !ct.found[i] {
+			t.Errorf("record %d: caller not captured", i)
+			continue
+		}
+		if filepath.Base(frame.File) != "caller_test.go" || frame.Line != lines[i] {
+			t.Errorf("record %d: expected caller_test.go:%d, got %s:%d", i, lines[i], frame.File, frame.Line)
+		}
+	}
+}
+
+func TestEnableCallerSkip(t *testing.T) {
+	lgr := &logr.Logr{EnableCaller: true, CallerSkip: 1}
+	ct := newCallerTarget()
+	_ = lgr.AddTarget(ct)
+
+	line := currentLine() + 1
+	logVia(lgr.NewLogger())
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if frame := ct.callers[0]; frame.Line != line {
+		t.Errorf("expected the helper's caller at line %d, got %s:%d", line, frame.Function, frame.Line)
+	}
+}
+
+func TestCallerDisabled(t *testing.T) {
+	lgr := &logr.Logr{}
+	ct := newCallerTarget()
+	_ = lgr.AddTarget(ct)
+
+	lgr.NewLogger().Info("no caller")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if ct.found[0] {
+		t.Errorf("expected no caller captured, got %v", ct.callers[0])
+	}
+}
@@ -0,0 +1,75 @@
+package logr
+
+import (
+	"fmt"
+	"time"
+)
+
+// clockSkewReportFreq is the maximum frequency that clock skew is reported.
+const clockSkewReportFreq = time.Minute
+
+// SetClock sets the function used to timestamp log records, e.g. a simulated
+// clock for deterministic tests or one corrected for clock skew. The clock
+// also drives record expiry, `Logger.Sample` windows and those of targets
+// such as Dedup and Burst. Passing nil restores the default, `time.Now`.
+// The clock must be safe for concurrent use; it can be changed at any time.
+func (logr *Logr) SetClock(clock func() time.Time) {
+	if clock == nil {
+		clock = time.Now
+	}
+	logr.clock.Store(clock)
+}
+
+// Now returns the current time per the clock set via `SetClock`. A nil Logr
+// returns `time.Now()`, so targets can call `rec.Logger().Logr().Now()` for
+// any record.
+func (logr *Logr) Now() time.Time {
+	if logr == nil {
+		return time.Now()
+	}
+	if clock, ok := logr.clock.Load().(func() time.Time); ok {
+		return clock()
+	}
+	return time.Now()
+}
+
+// checkClock detects log records whose timestamp goes backward relative to
+// the previous record, reporting skew beyond the tolerance via `ReportError`
+// (rate limited) and optionally clamping every backward timestamp so record
+// times are monotonic. The clamped time is kept apart from the original, see
+// `LogRec.Time`. Only called from the `start` goroutine, before the record is
+// prepped.
+func (logr *Logr) checkClock(rec *LogRec) {
+	if !logr.DetectClockSkew {
+		return
+	}
+
+	last := logr.lastRecTime
+	if !rec.time.Before(last) {
+		logr.lastRecTime = rec.time
+		return
+	}
+
+	if rec.time.Before(last.Add(-logr.clockSkewTolerance())) {
+		if logr.skewReporter == nil {
+			logr.skewReporter = &durationSampler{window: int64(clockSkewReportFreq)}
+		}
+		if ok, suppressed := logr.skewReporter.allow(time.Now().UnixNano()); ok {
+			logr.ReportError(fmt.Errorf("log record timestamp went backward by %v (%d more since last report)",
+				last.Sub(rec.time), suppressed))
+		}
+	}
+
+	if logr.ClampClockSkew {
+		rec.clampedTime = last
+	}
+}
+
+// clockSkewTolerance returns the amount a timestamp can go backward before
+// it is considered skewed.
+func (logr *Logr) clockSkewTolerance() time.Duration {
+	if logr.ClockSkewTolerance == 0 {
+		return DefaultClockSkewTolerance
+	}
+	return logr.ClockSkewTolerance
+}
@@ -0,0 +1,85 @@
+package logr_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// timeTarget records the time of each record written.
+type timeTarget struct {
+	logr.Basic
+	mux   sync.Mutex
+	times []time.Time
+}
+
+func (tt *timeTarget) Write(rec *logr.LogRec) error {
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	tt.times = append(tt.times, rec.Time())
+	return nil
+}
+
+// logAt logs one record per offset from base, using a simulated clock.
+func logAt(t *testing.T, lgr *logr.Logr, base time.Time, offsets ...time.Duration) *timeTarget {
+	var now int64
+	lgr.SetClock(func() time.Time {
+		return base.Add(time.Duration(atomic.LoadInt64(&now)))
+	})
+	tt := &timeTarget{}
+	tt.Basic.Start(tt, tt, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	if err := lgr.AddTarget(tt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+	for _, offset := range offsets {
+		atomic.StoreInt64(&now, int64(offset))
+		logger.Info("timed")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	return tt
+}
+
+func TestDetectClockSkew(t *testing.T) {
+	var reported int32
+	lgr := &logr.Logr{DetectClockSkew: true, ClockSkewTolerance: 100 * time.Millisecond}
+	lgr.OnLoggerError = func(err error) {
+		atomic.AddInt32(&reported, 1)
+	}
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// a step back within the tolerance is not reported; one beyond it is.
+	tt := logAt(t, lgr, base, 10*time.Second, 10*time.Second-50*time.Millisecond, 9*time.Second)
+
+	if n := atomic.LoadInt32(&reported); n != 1 {
+		t.Errorf("expected 1 skew reported, got %d", n)
+	}
+	// without clamping record times are unchanged.
+	if len(tt.times) != 3 || !tt.times[2].Equal(base.Add(9*time.Second)) {
+		t.Errorf("expected times unchanged, got %v", tt.times)
+	}
+}
+
+func TestClampClockSkew(t *testing.T) {
+	lgr := &logr.Logr{DetectClockSkew: true, ClampClockSkew: true, ClockSkewTolerance: 100 * time.Millisecond}
+	lgr.OnLoggerError = func(err error) {}
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := logAt(t, lgr, base, 10*time.Second, 10*time.Second-50*time.Millisecond, 9*time.Second, 11*time.Second)
+
+	// every backward step is clamped, even within the tolerance.
+	want := []time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second, 11 * time.Second}
+	if len(tt.times) != len(want) {
+		t.Fatalf("expected %d records, got %d", len(want), len(tt.times))
+	}
+	for i, w := range want {
+		if !tt.times[i].Equal(base.Add(w)) {
+			t.Errorf("record %d: expected %v, got %v", i, base.Add(w), tt.times[i])
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package logr
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Color is an ANSI SGR foreground color code used to colorize output for
+// terminals. See `FormatOptions`.
+type Color int
+
+// ANSI colors.
+const (
+	ColorNone    Color = 0
+	ColorRed     Color = 31
+	ColorGreen   Color = 32
+	ColorYellow  Color = 33
+	ColorBlue    Color = 34
+	ColorMagenta Color = 35
+	ColorCyan    Color = 36
+	ColorWhite   Color = 37
+	ColorGray    Color = 90
+)
+
+// LevelColors maps level IDs to the color used for the level when output is
+// colorized. Levels not in the map are not colorized.
+type LevelColors map[LevelID]Color
+
+// DefaultLevelColors are the colors used for the standard levels when
+// `FormatOptions.LevelColors` is nil. Copy and modify it to customize colors.
+var DefaultLevelColors = LevelColors{
+	Panic.ID: ColorMagenta,
+	Fatal.ID: ColorMagenta,
+	Error.ID: ColorRed,
+	Warn.ID:  ColorYellow,
+	Info.ID:  ColorCyan,
+	Debug.ID: ColorGray,
+	Trace.ID: ColorGray,
+}
+
+// FormatOptions is the target context passed to formatters implementing
+// `FormatterWithOptions`.
+type FormatOptions struct {
+	// Stacktrace is true if the stack trace should be output, as passed to
+	// `Formatter.Format`.
+	Stacktrace bool
+
+	// Color is true if the target wants ANSI colorized output, e.g. a
+	// console writing to a terminal.
+	Color bool
+
+	// LevelColors are the colors per level when Color is true. Defaults to
+	// DefaultLevelColors.
+	LevelColors LevelColors
+}
+
+// LevelColor returns the color for the level, or ColorNone if output is not
+// colorized or the level has no color.
+func (opts FormatOptions) LevelColor(lvl Level) Color {
+	if !opts.Color {
+		return ColorNone
+	}
+	colors := opts.LevelColors
+	if colors == nil {
+		colors = DefaultLevelColors
+	}
+	return colors[lvl.ID]
+}
+
+// FormatterWithOptions is a Formatter that can format using target context,
+// e.g. to emit ANSI colors only for targets writing to a terminal. Targets
+// should call `FormatWithOptions` rather than `Formatter.Format` to support it.
+type FormatterWithOptions interface {
+	// FormatWithOptions converts a log record to bytes, as for `Formatter.Format`.
+	FormatWithOptions(rec *LogRec, opts FormatOptions, buf *bytes.Buffer) (*bytes.Buffer, error)
+}
+
+// FormatWithOptions formats a log record via `FormatterWithOptions` if the
+// formatter implements it, otherwise via `Formatter.Format`, ignoring any
+// options other than `FormatOptions.Stacktrace`.
+func FormatWithOptions(formatter Formatter, rec *LogRec, opts FormatOptions, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	if fo, ok := formatter.(FormatterWithOptions); ok {
+		return fo.FormatWithOptions(rec, opts, buf)
+	}
+	return formatter.Format(rec, opts.Stacktrace, buf)
+}
+
+// WriteColored writes s to buf wrapped in the escape codes for the color, or
+// as is for ColorNone.
+func WriteColored(buf *bytes.Buffer, s string, color Color) {
+	if color == ColorNone {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString("\x1b[")
+	buf.WriteString(strconv.Itoa(int(color)))
+	buf.WriteByte('m')
+	buf.WriteString(s)
+	buf.WriteString("\x1b[0m")
+}
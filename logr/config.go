@@ -0,0 +1,288 @@
+package logr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/wiggin77/cfg"
+	"github.com/wiggin77/merror"
+)
+
+// Config keys recognized by `Logr.Configure`.
+const (
+	// ConfigKeyTargets is a comma separated list of target names, e.g. "console,audit".
+	ConfigKeyTargets = "targets"
+
+	// The remaining keys are per target, prefixed with "targets.<name>.".
+
+	// ConfigKeyType is the target type, e.g. "console" or "file". Required.
+	ConfigKeyType = "type"
+
+	// ConfigKeyLevel is the most verbose level output. Defaults to "info".
+	ConfigKeyLevel = "level"
+
+	// ConfigKeyMinLevel is the most severe level output. Defaults to "panic".
+	ConfigKeyMinLevel = "min_level"
+
+	// ConfigKeyStacktrace is the least severe level output with a stack
+	// trace. Defaults to none.
+	ConfigKeyStacktrace = "stacktrace"
+
+	// ConfigKeyFormat is the formatter type, e.g. "plain" or "json". Defaults to "plain".
+	ConfigKeyFormat = "format"
+
+	// ConfigKeyFormatOptions is a JSON object of formatter specific options.
+	ConfigKeyFormatOptions = "format_options"
+
+	// ConfigKeyOptions is a JSON object of target specific options.
+	ConfigKeyOptions = "options"
+
+	// ConfigKeyMaxQueue is the size of the target queue. Defaults to DefaultMaxQueueSize.
+	ConfigKeyMaxQueue = "maxqueue"
+)
+
+// TargetFactory creates a target from configuration. options is the JSON
+// value of the target's `ConfigKeyOptions` key, or nil if not set.
+type TargetFactory func(options json.RawMessage, filter Filter, formatter Formatter, maxQueue int) (Target, error)
+
+// FormatterFactory creates a formatter from configuration. options is the
+// JSON value of the target's `ConfigKeyFormatOptions` key, or nil if not set.
+type FormatterFactory func(options json.RawMessage) (Formatter, error)
+
+var factories = struct {
+	mux        sync.RWMutex
+	targets    map[string]TargetFactory
+	formatters map[string]FormatterFactory
+}{
+	targets:    make(map[string]TargetFactory),
+	formatters: make(map[string]FormatterFactory),
+}
+
+// RegisterTargetType makes a target type available to `Logr.Configure`.
+// The types provided by the target package are registered when that package
+// is imported. Registering an existing type replaces it.
+func RegisterTargetType(typ string, factory TargetFactory) {
+	factories.mux.Lock()
+	defer factories.mux.Unlock()
+	factories.targets[strings.ToLower(typ)] = factory
+}
+
+// RegisterFormatterType makes a formatter type available to `Logr.Configure`.
+// The types provided by the format package are registered when that package
+// is imported. Registering an existing type replaces it.
+func RegisterFormatterType(typ string, factory FormatterFactory) {
+	factories.mux.Lock()
+	defer factories.mux.Unlock()
+	factories.formatters[strings.ToLower(typ)] = factory
+}
+
+func init() {
+	RegisterFormatterType("default", func(json.RawMessage) (Formatter, error) {
+		return &DefaultFormatter{}, nil
+	})
+}
+
+// configuredTarget is a target added by `Logr.Configure`.
+type configuredTarget struct {
+	def    targetDef
+	target Target
+}
+
+// targetDef is the configuration of one target.
+type targetDef struct {
+	Type          string
+	Level         string
+	MinLevel      string
+	Stacktrace    string
+	Format        string
+	FormatOptions string
+	Options       string
+	MaxQueue      int
+}
+
+// Configure adds the targets defined in config. The `ConfigKeyTargets` key
+// lists target names, and each target is defined by keys prefixed with
+// "targets.<name>.", e.g.
+//
+//	targets = console,audit
+//	targets.console.type = console
+//	targets.console.level = debug
+//	targets.audit.type = file
+//	targets.audit.min_level = warn
+//	targets.audit.format = json
+//	targets.audit.options = {"Filename": "audit.log", "MaxSize": 10}
+//
+// See the ConfigKey constants for all recognized keys. Target and formatter
+// types must be registered via `RegisterTargetType` and `RegisterFormatterType`.
+// Importing the target package registers the "console", "file", "routingfile",
+// "http" and "syslog" types, and importing the nats module registers the
+// "nats" type, whose options are the fields of the corresponding options
+// struct. Importing the format package registers
+// the "plain", "json" and "bunyan" formats. Unknown types are an error.
+//
+// Configure can be called again with a changed config: targets whose
+// definition is unchanged are kept, changed targets are replaced, and
+// targets no longer listed are removed and shut down. Targets added via
+// `AddTarget` are never affected. Errors for individual targets are
+// aggregated; the remaining targets are still applied.
+func (logr *Logr) Configure(config *cfg.Config) error {
+	if config == nil {
+		return errors.New("config cannot be nil")
+	}
+
+	errs := merror.New()
+	defs := make(map[string]targetDef)
+
+	list, _ := config.String(ConfigKeyTargets, "")
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := defs[name]; ok {
+			errs.Append(fmt.Errorf("target %q listed more than once", name))
+			continue
+		}
+		defs[name] = readTargetDef(config, "targets."+name+".")
+	}
+
+	logr.configMux.Lock()
+	defer logr.configMux.Unlock()
+	if logr.configured == nil {
+		logr.configured = make(map[string]configuredTarget)
+	}
+
+	// remove targets no longer configured or whose definition changed.
+	for name, ct := range logr.configured {
+		if def, ok := defs[name]; ok && def == ct.def {
+			continue
+		}
+		if err := logr.removeTarget(ct.target); err != nil {
+			errs.Append(fmt.Errorf("target %q: %w", name, err))
+		}
+		delete(logr.configured, name)
+	}
+
+	for name, def := range defs {
+		if _, ok := logr.configured[name]; ok {
+			continue // unchanged
+		}
+		target, err := newConfiguredTarget(def)
+		if err != nil {
+			errs.Append(fmt.Errorf("target %q: %w", name, err))
+			continue
+		}
+		if namer, ok := target.(interface{ SetName(string) }); ok {
+			namer.SetName(name)
+		}
+		if err = logr.AddTarget(target); err != nil {
+			errs.Append(fmt.Errorf("target %q: %w", name, err))
+			_ = target.Shutdown(context.Background())
+			continue
+		}
+		logr.configured[name] = configuredTarget{def: def, target: target}
+	}
+
+	logr.ResetLevelCache()
+	return errs.ErrorOrNil()
+}
+
+// readTargetDef reads the keys for one target.
+func readTargetDef(config *cfg.Config, prefix string) targetDef {
+	var def targetDef
+	def.Type, _ = config.String(prefix+ConfigKeyType, "")
+	def.Level, _ = config.String(prefix+ConfigKeyLevel, Info.Name)
+	def.MinLevel, _ = config.String(prefix+ConfigKeyMinLevel, Panic.Name)
+	def.Stacktrace, _ = config.String(prefix+ConfigKeyStacktrace, "")
+	def.Format, _ = config.String(prefix+ConfigKeyFormat, "plain")
+	def.FormatOptions, _ = config.String(prefix+ConfigKeyFormatOptions, "")
+	def.Options, _ = config.String(prefix+ConfigKeyOptions, "")
+	def.MaxQueue, _ = config.Int(prefix+ConfigKeyMaxQueue, DefaultMaxQueueSize)
+	return def
+}
+
+// newConfiguredTarget creates a target from its definition.
+func newConfiguredTarget(def targetDef) (Target, error) {
+	if def.Type == "" {
+		return nil, errors.New("missing type")
+	}
+
+	factories.mux.RLock()
+	targetFactory, ok := factories.targets[strings.ToLower(def.Type)]
+	formatterFactory, fok := factories.formatters[strings.ToLower(def.Format)]
+	factories.mux.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown target type %q; is the package providing it imported?", def.Type)
+	}
+	if !fok {
+		return nil, fmt.Errorf("unknown format %q; is the package providing it imported?", def.Format)
+	}
+
+	filter, err := newRangeFilter(def.MinLevel, def.Level, def.Stacktrace)
+	if err != nil {
+		return nil, err
+	}
+
+	formatOptions, err := rawOptions(def.FormatOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ConfigKeyFormatOptions, err)
+	}
+	formatter, err := formatterFactory(formatOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := rawOptions(def.Options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ConfigKeyOptions, err)
+	}
+	return targetFactory(options, filter, formatter, def.MaxQueue)
+}
+
+// rawOptions validates a JSON options value.
+func rawOptions(s string) (json.RawMessage, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !json.Valid([]byte(s)) {
+		return nil, errors.New("not valid JSON")
+	}
+	return json.RawMessage(s), nil
+}
+
+// newRangeFilter creates a filter enabling the levels from the most severe
+// level minName to the most verbose level maxName, with stack traces for
+// levels at least as severe as stackName, if not empty.
+func newRangeFilter(minName string, maxName string, stackName string) (Filter, error) {
+	min, ok := levelByName(minName)
+	if !ok {
+		return nil, fmt.Errorf("unknown level %q", minName)
+	}
+	max, ok := levelByName(maxName)
+	if !ok {
+		return nil, fmt.Errorf("unknown level %q", maxName)
+	}
+	if min.ID > max.ID {
+		return nil, fmt.Errorf("level %q is more severe than %q", maxName, minName)
+	}
+	var stack Level
+	if stackName != "" {
+		if stack, ok = levelByName(stackName); !ok {
+			return nil, fmt.Errorf("unknown level %q", stackName)
+		}
+	}
+
+	filter := &CustomFilter{}
+	for _, lvl := range knownLevels() {
+		if lvl.ID < min.ID || lvl.ID > max.ID {
+			continue
+		}
+		lvl.Stacktrace = stackName != "" && lvl.ID <= stack.ID
+		filter.Add(lvl)
+	}
+	return filter, nil
+}
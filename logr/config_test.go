@@ -0,0 +1,269 @@
+package logr_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+	_ "github.com/mattermost/logr/format"
+	"github.com/wiggin77/cfg"
+)
+
+// configTarget is created by the "configtest" target type, recording its
+// filter and options, and whether it was shut down.
+type configTarget struct {
+	logr.Basic
+	filter  logr.Filter
+	options json.RawMessage
+
+	mux      sync.Mutex
+	shutdown bool
+}
+
+func (ct *configTarget) Write(rec *logr.LogRec) error {
+	return nil
+}
+
+func (ct *configTarget) Shutdown(ctx context.Context) error {
+	ct.mux.Lock()
+	ct.shutdown = true
+	ct.mux.Unlock()
+	return ct.Basic.Shutdown(ctx)
+}
+
+func (ct *configTarget) isShutdown() bool {
+	ct.mux.Lock()
+	defer ct.mux.Unlock()
+	return ct.shutdown
+}
+
+func init() {
+	logr.RegisterTargetType("configtest", func(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+		ct := &configTarget{filter: filter, options: options}
+		ct.Basic.Start(ct, ct, filter, formatter, maxQueue)
+		return ct, nil
+	})
+}
+
+func newConfig(props map[string]string) *cfg.Config {
+	config := &cfg.Config{}
+	config.AppendSource(cfg.NewSrcMapFromMap(props))
+	return config
+}
+
+// configTargets returns the targets of lgr by name.
+func configTargets(lgr *logr.Logr) map[string]*configTarget {
+	targets := make(map[string]*configTarget)
+	for _, t := range lgr.Targets() {
+		if ct, ok := t.(*configTarget); ok {
+			targets[ct.Name()] = ct
+		}
+	}
+	return targets
+}
+
+func TestConfigure(t *testing.T) {
+	lgr := &logr.Logr{}
+	defer lgr.Shutdown()
+
+	err := lgr.Configure(newConfig(map[string]string{
+		"targets":                  "a, b",
+		"targets.a.type":           "configtest",
+		"targets.a.options":        `{"Key": "value"}`,
+		"targets.b.type":           "configtest",
+		"targets.b.level":          "debug",
+		"targets.b.format":         "json",
+		"targets.b.format_options": `{"DisableTimestamp": true}`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targets := configTargets(lgr)
+	if len(targets) != 2 || targets["a"] == nil || targets["b"] == nil {
+		t.Fatalf("expected targets a and b, got %v", targets)
+	}
+	if string(targets["a"].options) != `{"Key": "value"}` {
+		t.Errorf("expected options passed to the factory, got %s", targets["a"].options)
+	}
+	if targets["a"].filter.IsEnabled(logr.Debug) || !targets["b"].filter.IsEnabled(logr.Debug) {
+		t.Error("expected debug enabled for b only")
+	}
+}
+
+func TestConfigureReconfigure(t *testing.T) {
+	lgr := &logr.Logr{}
+	defer lgr.Shutdown()
+
+	err := lgr.Configure(newConfig(map[string]string{
+		"targets":             "keep,change,remove",
+		"targets.keep.type":   "configtest",
+		"targets.change.type": "configtest",
+		"targets.remove.type": "configtest",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := configTargets(lgr)
+
+	err = lgr.Configure(newConfig(map[string]string{
+		"targets":              "keep,change",
+		"targets.keep.type":    "configtest",
+		"targets.change.type":  "configtest",
+		"targets.change.level": "warn",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := configTargets(lgr)
+
+	if len(after) != 2 {
+		t.Fatalf("expected 2 targets, got %v", after)
+	}
+	if after["keep"] != before["keep"] || before["keep"].isShutdown() {
+		t.Error("expected the unchanged target kept running")
+	}
+	if after["change"] == before["change"] || !before["change"].isShutdown() {
+		t.Error("expected the changed target replaced and shut down")
+	}
+	if after["change"].filter.IsEnabled(logr.Info) {
+		t.Error("expected the replacement to use the changed level")
+	}
+	if !before["remove"].isShutdown() {
+		t.Error("expected the removed target shut down")
+	}
+}
+
+func TestConfigureKeepsAddedTargets(t *testing.T) {
+	lgr := &logr.Logr{}
+	defer lgr.Shutdown()
+
+	added := &configTarget{}
+	added.Basic.Start(added, added, &logr.StdFilter{Lvl: logr.Info}, nil, 10)
+	_ = lgr.AddTarget(added)
+
+	_ = lgr.Configure(newConfig(map[string]string{"targets": "a", "targets.a.type": "configtest"}))
+	_ = lgr.Configure(newConfig(map[string]string{}))
+
+	if targets := lgr.Targets(); len(targets) != 1 || targets[0] != added || added.isShutdown() {
+		t.Errorf("expected only the added target left running, got %v", targets)
+	}
+}
+
+func TestConfigureErrors(t *testing.T) {
+	lgr := &logr.Logr{}
+	defer lgr.Shutdown()
+
+	err := lgr.Configure(newConfig(map[string]string{
+		"targets":                    "dup,dup,notype,badtype,badformat,badoptions,good",
+		"targets.dup.type":           "configtest",
+		"targets.badtype.type":       "nosuchtype",
+		"targets.badformat.type":     "configtest",
+		"targets.badformat.format":   "nosuchformat",
+		"targets.badoptions.type":    "configtest",
+		"targets.badoptions.options": "{not json",
+		"targets.good.type":          "configtest",
+	}))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`target "dup" listed more than once`,
+		`target "notype": missing type`,
+		`unknown target type "nosuchtype"`,
+		`unknown format "nosuchformat"`,
+		`target "badoptions": invalid options`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+
+	// valid targets are still applied, a duplicate once.
+	targets := configTargets(lgr)
+	if len(targets) != 2 || targets["dup"] == nil || targets["good"] == nil {
+		t.Errorf("expected targets dup and good, got %v", targets)
+	}
+}
+
+func TestConfigureLevelRange(t *testing.T) {
+	lgr := &logr.Logr{}
+	defer lgr.Shutdown()
+
+	err := lgr.Configure(newConfig(map[string]string{
+		"targets":                  "range",
+		"targets.range.type":       "configtest",
+		"targets.range.level":      "info",
+		"targets.range.min_level":  "error",
+		"targets.range.stacktrace": "error",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := configTargets(lgr)["range"].filter
+
+	tests := []struct {
+		lvl        logr.Level
+		enabled    bool
+		stacktrace bool
+	}{
+		{logr.Panic, false, false},
+		{logr.Fatal, false, false},
+		{logr.Error, true, true},
+		{logr.Warn, true, false},
+		{logr.Info, true, false},
+		{logr.Debug, false, false},
+		{logr.Trace, false, false},
+	}
+	for _, tt := range tests {
+		if got := filter.IsEnabled(tt.lvl); got != tt.enabled {
+			t.Errorf("%s: expected enabled %t, got %t", tt.lvl, tt.enabled, got)
+		}
+		if got := filter.IsStacktraceEnabled(tt.lvl); got != tt.stacktrace {
+			t.Errorf("%s: expected stacktrace %t, got %t", tt.lvl, tt.stacktrace, got)
+		}
+	}
+}
+
+func TestConfigureInvalidLevelRange(t *testing.T) {
+	tests := map[string]struct {
+		props map[string]string
+		want  string
+	}{
+		"inverted": {
+			props: map[string]string{"targets.t.level": "error", "targets.t.min_level": "info"},
+			want:  `level "error" is more severe than "info"`,
+		},
+		"unknown level": {
+			props: map[string]string{"targets.t.level": "loud"},
+			want:  `unknown level "loud"`,
+		},
+		"unknown min level": {
+			props: map[string]string{"targets.t.min_level": "quiet"},
+			want:  `unknown level "quiet"`,
+		},
+		"unknown stacktrace": {
+			props: map[string]string{"targets.t.stacktrace": "deep"},
+			want:  `unknown level "deep"`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			lgr := &logr.Logr{}
+			defer lgr.Shutdown()
+
+			tt.props["targets"] = "t"
+			tt.props["targets.t.type"] = "configtest"
+			err := lgr.Configure(newConfig(tt.props))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error %q, got %v", tt.want, err)
+			}
+			if lgr.HasTargets() {
+				t.Error("expected no target added")
+			}
+		})
+	}
+}
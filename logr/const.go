@@ -0,0 +1,161 @@
+package logr
+
+import "time"
+
+// Defaults.
+const (
+	// DefaultMaxQueueSize is the default maximum queue size for Logr instances.
+	DefaultMaxQueueSize = 1000
+
+	// DefaultMaxStackFrames is the default maximum max number of stack frames collected
+	// when generating stack traces for logging.
+	DefaultMaxStackFrames = 30
+
+	// MaxLevelID is the maximum level ID cached in an array by the default level
+	// cache. Levels with higher IDs are supported but their status is cached in a
+	// map, which is slightly slower. Keep custom level IDs at or below this value.
+	MaxLevelID = 256
+
+	// DefaultEnqueueTimeout is the default amount of time a log record can take to be queued.
+	// This only applies to blocking enqueue which happen after `logr.OnQueueFull` is called
+	// and returns false.
+	DefaultEnqueueTimeout = time.Second * 30
+
+	// DropRateInterval is the interval over which recent drops are counted
+	// for `QueueFullStats.DroppedRecent`.
+	DropRateInterval = time.Second
+
+	// DefaultShutdownTimeout is the default amount of time `logr.Shutdown` can execute before
+	// timing out.
+	DefaultShutdownTimeout = time.Second * 30
+
+	// DefaultFlushTimeout is the default amount of time `logr.Flush` can execute before
+	// timing out.
+	DefaultFlushTimeout = time.Second * 30
+
+	// DefaultMaxPooledBuffer is the maximum size a pooled buffer can be.
+	// Buffers that grow beyond this size are garbage collected.
+	DefaultMaxPooledBuffer = 1024 * 1024
+
+	// DefaultBufferLeakThreshold is the number of outstanding borrowed buffers
+	// that triggers a warning when `Logr.DebugBuffers` is true.
+	DefaultBufferLeakThreshold = 1000
+
+	// DefaultNoTargetBufferSize is the default maximum number of log records buffered
+	// before the first target is added, when `NoTargetPolicy` is NoTargetBuffer.
+	DefaultNoTargetBufferSize = 1000
+
+	// DefaultClockSkewTolerance is the default amount of time a log record's timestamp
+	// can go backward relative to the previous record before it is considered skewed.
+	DefaultClockSkewTolerance = time.Millisecond * 100
+
+	// DefaultBackoffInitial is the default delay before the first retry.
+	DefaultBackoffInitial = time.Millisecond * 100
+
+	// DefaultBackoffMax is the default maximum delay between retries.
+	DefaultBackoffMax = time.Second * 30
+
+	// DefaultMaxErrorChain is the maximum number of errors, from the outermost,
+	// included in the error chain of an `ErrorField`.
+	DefaultMaxErrorChain = 16
+
+	// DefaultMaxGroupFields is the maximum number of fields included in a field
+	// group created by helpers such as `Flags`.
+	DefaultMaxGroupFields = 100
+
+	// DefaultMaxDiffValueLen is the maximum length of the text of a value
+	// recorded via `Diff` before it is truncated.
+	DefaultMaxDiffValueLen = 256
+
+	// DefaultReorderWindow is the default maximum number of log records buffered
+	// for reordering when `Logr.StrictOrdering` is enabled.
+	DefaultReorderWindow = 256
+
+	// DefaultReorderTimeout is the default maximum time a log record is held
+	// waiting for earlier records when `Logr.StrictOrdering` is enabled.
+	DefaultReorderTimeout = time.Millisecond * 50
+
+	// DefaultLoadShedHighWater is the default fraction of the Logr queue
+	// capacity at which load shedding starts. See `Logr.LoadShedLevel`.
+	DefaultLoadShedHighWater = 0.8
+
+	// DefaultLoadShedLowWater is the default fraction of the Logr queue
+	// capacity at which load shedding stops. See `Logr.LoadShedLevel`.
+	DefaultLoadShedLowWater = 0.5
+
+	// DefaultMaxRestarts is the default maximum number of restarts, within
+	// `Logr.RestartWindow`, of the goroutine processing the Logr queue.
+	DefaultMaxRestarts = 5
+
+	// DefaultRestartWindow is the default period over which restarts are
+	// counted for `Logr.MaxRestarts`.
+	DefaultRestartWindow = time.Minute
+
+	// DefaultBatchSize is the default maximum number of log records per batch
+	// for targets whose RecordWriter implements `RecordBatchWriter`.
+	DefaultBatchSize = 100
+
+	// DefaultBatchInterval is the default maximum time a log record waits in a
+	// partial batch for targets whose RecordWriter implements `RecordBatchWriter`.
+	DefaultBatchInterval = time.Millisecond * 200
+)
+
+// Field keys used by built-in helpers.
+const (
+	// FieldKeyPanic is the field key for a recovered panic value.
+	FieldKeyPanic = "panic"
+
+	// FieldKeyError is the field key for an error. See `Err`.
+	FieldKeyError = "error"
+
+	// FieldKeyStack is the field key for a stack trace captured as text.
+	FieldKeyStack = "stack"
+
+	// FieldKeySuppressed is the field key for the number of records suppressed
+	// by sampling since the previous emitted record.
+	FieldKeySuppressed = "suppressed"
+
+	// FieldKeyBadKey is the field key for a value passed to a sugared logging
+	// method, such as `Logger.Infow`, without a valid string key.
+	FieldKeyBadKey = "!BADKEY"
+
+	// FieldKeyBurstDuration is the field key for the time between the first
+	// and last log records of a burst.
+	FieldKeyBurstDuration = "burst_duration"
+
+	// FieldKeyRepeated is the field key for the number of times a log record
+	// was repeated within a deduplication window.
+	FieldKeyRepeated = "repeated"
+
+	// FieldKeySampleRate is the reserved field key for the sampling rate of a
+	// sampled log record, N meaning the record represents 1 in N occurrences.
+	FieldKeySampleRate = "sample_rate"
+
+	// FieldKeySchemaVersion is the reserved field key for the log schema version.
+	// See `Logr.SetSchemaVersion`.
+	FieldKeySchemaVersion = "schema_version"
+
+	// FieldKeyEvent is the reserved field key for a lifecycle event type.
+	// See `Logger.Event`.
+	FieldKeyEvent = "event"
+
+	// FieldKeyAttempt is the field key for a retry attempt number. See `Attempt`.
+	FieldKeyAttempt = "attempt"
+
+	// FieldKeyMaxAttempts is the field key for the maximum number of retry attempts.
+	FieldKeyMaxAttempts = "max_attempts"
+
+	// FieldKeyBackoff is the field key for the delay before the next retry attempt.
+	FieldKeyBackoff = "backoff"
+
+	// FieldKeyFlags is the field key grouping feature-flag evaluations. See `Flags`.
+	FieldKeyFlags = "flags"
+
+	// FieldKeyTruncated is the field key for the number of entries omitted from
+	// a bounded field group.
+	FieldKeyTruncated = "_truncated"
+
+	// FieldKeyDeadlineRemaining is the field key for the time remaining until a
+	// log record's context deadline. See `Logr.ContextDeadlineField`.
+	FieldKeyDeadlineRemaining = "deadline_remaining"
+)
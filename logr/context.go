@@ -0,0 +1,157 @@
+package logr
+
+import (
+	"context"
+	"time"
+)
+
+// contextField is a context key registered via `Logr.RegisterContextField`.
+type contextField struct {
+	key  interface{}
+	name string
+}
+
+// BaggagePrefix is prepended to the key of any baggage entries added as fields.
+const BaggagePrefix = "baggage."
+
+// WithContext creates a new `Logger` that attaches ctx to every log record it
+// creates. Values are extracted from the context, e.g. via `Logr.RegisterContextField`
+// or `Logr.BaggageExtractor`, when the log record is prepped for output. A nil
+// ctx removes any context attached earlier.
+func (logger Logger) WithContext(ctx context.Context) Logger {
+	l := logger
+	l.ctx = ctx
+	return l
+}
+
+// Context returns the context of this log record, or nil if none.
+func (rec *LogRec) Context() context.Context {
+	// no locking needed as this field is not mutated.
+	return rec.ctx
+}
+
+// contextFields returns any fields extracted from the context of a log record
+// created at recTime, or nil if none.
+func (logr *Logr) contextFields(ctx context.Context, recTime time.Time) Fields {
+	if ctx == nil {
+		return nil
+	}
+	var flds Fields
+	flds = logr.addRegisteredFields(ctx, flds)
+	flds = logr.addBaggageFields(ctx, flds)
+	flds = logr.addDeadlineField(ctx, recTime, flds)
+	return flds
+}
+
+// RegisterContextField adds the value stored in a log record's context (see
+// `Logger.WithContext`) under key, e.g. a request-scoped trace ID, as a field
+// named fieldName when the record is prepped for output. Records whose
+// context has no value for key are unaffected. Registering a key again
+// replaces its field name, and an empty fieldName removes the registration.
+// Values are looked up via `context.Context.Value`, so key should be
+// comparable; a nil key is ignored.
+func (logr *Logr) RegisterContextField(key interface{}, fieldName string) {
+	if key == nil {
+		return
+	}
+	logr.ctxFieldsMux.Lock()
+	defer logr.ctxFieldsMux.Unlock()
+
+	old, _ := logr.ctxFields.Load().([]contextField)
+	fields := make([]contextField, 0, len(old)+1)
+	for _, cf := range old {
+		if cf.key != key {
+			fields = append(fields, cf)
+		}
+	}
+	if fieldName != "" {
+		fields = append(fields, contextField{key: key, name: fieldName})
+	}
+	logr.ctxFields.Store(fields)
+}
+
+// addRegisteredFields adds the values of any context keys registered via
+// `RegisterContextField` to flds.
+func (logr *Logr) addRegisteredFields(ctx context.Context, flds Fields) Fields {
+	registered, _ := logr.ctxFields.Load().([]contextField)
+	for _, cf := range registered {
+		v := ctx.Value(cf.key)
+		if v == nil {
+			continue
+		}
+		if flds == nil {
+			flds = make(Fields, len(registered))
+		}
+		flds[cf.name] = v
+	}
+	return flds
+}
+
+// addDeadlineField adds the time remaining until the context deadline, as of
+// recTime, when `ContextDeadlineField` is enabled and the context has a deadline.
+func (logr *Logr) addDeadlineField(ctx context.Context, recTime time.Time, flds Fields) Fields {
+	if !logr.ContextDeadlineField {
+		return flds
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return flds
+	}
+	if flds == nil {
+		flds = make(Fields, 1)
+	}
+	flds[FieldKeyDeadlineRemaining] = deadline.Sub(recTime)
+	return flds
+}
+
+// addBaggageFields adds any baggage entries from the context to flds, subject
+// to the `BaggageKeys` allowlist.
+func (logr *Logr) addBaggageFields(ctx context.Context, flds Fields) Fields {
+	if logr.BaggageExtractor == nil {
+		return flds
+	}
+	baggage := logr.BaggageExtractor(ctx)
+	if len(baggage) == 0 {
+		return flds
+	}
+
+	add := func(key, val string) {
+		if flds == nil {
+			flds = make(Fields, len(baggage))
+		}
+		flds[BaggagePrefix+key] = val
+	}
+
+	if len(logr.BaggageKeys) == 0 {
+		for k, v := range baggage {
+			add(k, v)
+		}
+		return flds
+	}
+	for _, k := range logr.BaggageKeys {
+		if v, ok := baggage[k]; ok {
+			add(k, v)
+		}
+	}
+	return flds
+}
+
+// mergeFields returns a new Fields containing a and b, with b taking
+// precedence on collisions. If either is empty the other is returned
+// without copying.
+func mergeFields(a Fields, b Fields) Fields {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	merged := make(Fields, len(a)+len(b))
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,83 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventCollector is an optional interface a `MetricsCollector` can implement
+// to receive counts of log records created via `Logger.CountOnly`.
+type EventCollector interface {
+	// EventCounter returns a Counter that will be incremented for each
+	// count-only log record with the specified name.
+	EventCounter(name string) (Counter, error)
+}
+
+// eventCounts tracks counts of count-only log records by name.
+type eventCounts struct {
+	m sync.Map // name -> *eventCount
+}
+
+type eventCount struct {
+	n       uint64
+	counter Counter
+}
+
+// CountOnly creates a new `Logger` whose log records are counted but never
+// output to any target. The record message is the name of the count, e.g.
+// `logger.CountOnly().Info("cache_miss")`. Counts are available via
+// `Logr.EventCounts` and pushed to the `MetricsCollector` when it implements
+// `EventCollector`. Count-only records bypass target level filters since they
+// produce no output, but are still prepped so fields are resolved.
+func (logger Logger) CountOnly() Logger {
+	l := logger
+	l.countOnly = true
+	return l
+}
+
+// levelStatus returns whether log records at the specified level should be
+// created by this Logger.
+func (logger Logger) levelStatus(lvl Level) LevelStatus {
+	if logger.countOnly {
+		return LevelStatus{Enabled: true}
+	}
+	status := logger.logr.IsLevelEnabled(lvl)
+	if len(logger.tees) > 0 {
+		status = logger.teeLevelStatus(lvl, status)
+	}
+	return status
+}
+
+// countRecord counts a count-only log record.
+func (logr *Logr) countRecord(rec *LogRec) {
+	logr.stats.inc(statCounted)
+	name := rec.Msg()
+	v, ok := logr.eventCounts.m.Load(name)
+	if !ok {
+		ec := &eventCount{}
+		if collector, ok := logr.metrics.(EventCollector); ok {
+			counter, err := collector.EventCounter(name)
+			if err != nil {
+				logr.ReportError(err)
+			}
+			ec.counter = counter
+		}
+		v, _ = logr.eventCounts.m.LoadOrStore(name, ec)
+	}
+	ec := v.(*eventCount)
+	atomic.AddUint64(&ec.n, 1)
+	if ec.counter != nil {
+		ec.counter.Inc()
+	}
+}
+
+// EventCounts returns a snapshot of the counts of log records created via
+// `Logger.CountOnly`, keyed by name.
+func (logr *Logr) EventCounts() map[string]uint64 {
+	counts := make(map[string]uint64)
+	logr.eventCounts.m.Range(func(k, v interface{}) bool {
+		counts[k.(string)] = atomic.LoadUint64(&v.(*eventCount).n)
+		return true
+	})
+	return counts
+}
@@ -0,0 +1,224 @@
+package logr
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The default Logr used by the package-level logging functions.
+var (
+	defaultMux     sync.RWMutex
+	defaultLogr    *Logr
+	defaultLogger  Logger
+	defaultCreated bool // true if defaultLogr was created lazily rather than via SetDefault
+)
+
+// Default returns the default Logr used by the package-level logging
+// functions such as `Infof`. Unless replaced via `SetDefault`, it is created
+// on first use with a single target writing Info and above to stderr via
+// `DefaultFormatter`, including stack traces for Panic. It uses `SyncMode`,
+// so records are written before the logging call returns and are not lost
+// when the program exits without shutting it down.
+func Default() *Logr {
+	return defaultLog().Logr()
+}
+
+// SetDefault replaces the default Logr, typically once at startup before
+// logging. If the previous default was created by `Default` it is shut down,
+// after writing any log records queued; a previous default provided via
+// SetDefault is left running. A nil Logr restores the lazily created default.
+func SetDefault(lgr *Logr) {
+	defaultMux.Lock()
+	prev, created := defaultLogr, defaultCreated
+	defaultLogr, defaultCreated = lgr, false
+	if lgr != nil {
+		defaultLogger = lgr.NewLogger()
+	}
+	defaultMux.Unlock()
+
+	if prev != nil && created && prev != lgr {
+		if err := prev.Shutdown(); err != nil {
+			fmt.Fprintln(os.Stderr, "logr default shutdown --", err)
+		}
+	}
+}
+
+// defaultLog returns a Logger for the default Logr, creating it if needed.
+func defaultLog() Logger {
+	defaultMux.RLock()
+	if defaultLogr != nil {
+		logger := defaultLogger
+		defaultMux.RUnlock()
+		return logger
+	}
+	defaultMux.RUnlock()
+
+	defaultMux.Lock()
+	defer defaultMux.Unlock()
+	if defaultLogr == nil {
+		lgr := &Logr{SyncMode: true}
+		t := &stderrTarget{}
+		t.Basic.Start(t, t, &StdFilter{Lvl: Info, Stacktrace: Panic}, nil, DefaultMaxQueueSize)
+		t.SetName("stderr")
+		if err := lgr.AddTarget(t); err != nil {
+			fmt.Fprintln(os.Stderr, "logr default --", err)
+		}
+		defaultLogr, defaultLogger, defaultCreated = lgr, lgr.NewLogger(), true
+	}
+	return defaultLogger
+}
+
+// stderrTarget is the target of the lazily created default Logr.
+type stderrTarget struct {
+	Basic
+}
+
+// Write formats the log record and outputs it to stderr.
+func (t *stderrTarget) Write(rec *LogRec) error {
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := t.Formatter().Format(rec, t.IncludeStacktrace(rec), buf)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stderr.Write(buf.Bytes())
+	return err
+}
+
+// The package-level functions below log via the default Logr, see `Default`.
+// Since `Info`, `Error`, etc. name the standard levels, each level is logged
+// via its `ln`, formatted `f` and structured `w` variants, e.g. `Infoln`,
+// `Infof` and `Infow`, plus `Print` and friends in the manner of the standard
+// library `log` package.
+
+// Log logs via the default Logr, see `Logger.Log`.
+func Log(lvl Level, args ...interface{}) {
+	defaultLog().Log(lvl, args...)
+}
+
+// Logf logs via the default Logr, see `Logger.Logf`.
+func Logf(lvl Level, format string, args ...interface{}) {
+	defaultLog().Logf(lvl, format, args...)
+}
+
+// Logw logs via the default Logr, see `Logger.Logw`.
+func Logw(lvl Level, msg string, keysAndValues ...interface{}) {
+	defaultLog().Logw(lvl, msg, keysAndValues...)
+}
+
+// Logln logs via the default Logr, see `Logger.Logln`.
+func Logln(lvl Level, args ...interface{}) {
+	defaultLog().Logln(lvl, args...)
+}
+
+// Print logs at Info level via the default Logr, see `Logger.Print`.
+func Print(args ...interface{}) {
+	defaultLog().Print(args...)
+}
+
+// Printf logs at Info level via the default Logr, see `Logger.Printf`.
+func Printf(format string, args ...interface{}) {
+	defaultLog().Printf(format, args...)
+}
+
+// Println logs at Info level via the default Logr, see `Logger.Println`.
+func Println(args ...interface{}) {
+	defaultLog().Println(args...)
+}
+
+// Tracef logs at Trace level via the default Logr.
+func Tracef(format string, args ...interface{}) {
+	defaultLog().Tracef(format, args...)
+}
+
+// Debugf logs at Debug level via the default Logr.
+func Debugf(format string, args ...interface{}) {
+	defaultLog().Debugf(format, args...)
+}
+
+// Infof logs at Info level via the default Logr.
+func Infof(format string, args ...interface{}) {
+	defaultLog().Infof(format, args...)
+}
+
+// Warnf logs at Warn level via the default Logr.
+func Warnf(format string, args ...interface{}) {
+	defaultLog().Warnf(format, args...)
+}
+
+// Errorf logs at Error level via the default Logr.
+func Errorf(format string, args ...interface{}) {
+	defaultLog().Errorf(format, args...)
+}
+
+// Fatalf logs at Fatal level via the default Logr, then exits, see `Logr.OnExit`.
+func Fatalf(format string, args ...interface{}) {
+	defaultLog().Fatalf(format, args...)
+}
+
+// Panicf logs at Panic level via the default Logr, then panics, see `Logr.OnPanic`.
+func Panicf(format string, args ...interface{}) {
+	defaultLog().Panicf(format, args...)
+}
+
+// Traceln logs at Trace level via the default Logr.
+func Traceln(args ...interface{}) {
+	defaultLog().Traceln(args...)
+}
+
+// Debugln logs at Debug level via the default Logr.
+func Debugln(args ...interface{}) {
+	defaultLog().Debugln(args...)
+}
+
+// Infoln logs at Info level via the default Logr.
+func Infoln(args ...interface{}) {
+	defaultLog().Infoln(args...)
+}
+
+// Warnln logs at Warn level via the default Logr.
+func Warnln(args ...interface{}) {
+	defaultLog().Warnln(args...)
+}
+
+// Errorln logs at Error level via the default Logr.
+func Errorln(args ...interface{}) {
+	defaultLog().Errorln(args...)
+}
+
+// Fatalln logs at Fatal level via the default Logr, then exits, see `Logr.OnExit`.
+func Fatalln(args ...interface{}) {
+	defaultLog().Fatalln(args...)
+}
+
+// Panicln logs at Panic level via the default Logr, then panics, see `Logr.OnPanic`.
+func Panicln(args ...interface{}) {
+	defaultLog().Panicln(args...)
+}
+
+// Tracew logs at Trace level via the default Logr, see `Logger.Logw`.
+func Tracew(msg string, keysAndValues ...interface{}) {
+	defaultLog().Tracew(msg, keysAndValues...)
+}
+
+// Debugw logs at Debug level via the default Logr, see `Logger.Logw`.
+func Debugw(msg string, keysAndValues ...interface{}) {
+	defaultLog().Debugw(msg, keysAndValues...)
+}
+
+// Infow logs at Info level via the default Logr, see `Logger.Logw`.
+func Infow(msg string, keysAndValues ...interface{}) {
+	defaultLog().Infow(msg, keysAndValues...)
+}
+
+// Warnw logs at Warn level via the default Logr, see `Logger.Logw`.
+func Warnw(msg string, keysAndValues ...interface{}) {
+	defaultLog().Warnw(msg, keysAndValues...)
+}
+
+// Errorw logs at Error level via the default Logr, see `Logger.Logw`.
+func Errorw(msg string, keysAndValues ...interface{}) {
+	defaultLog().Errorw(msg, keysAndValues...)
+}
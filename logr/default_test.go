@@ -0,0 +1,75 @@
+package logr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/test"
+)
+
+func TestDefaultWritesSynchronously(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	logr.SetDefault(nil)
+	defer logr.SetDefault(nil)
+	if !logr.Default().SyncMode {
+		t.Error("default Logr should use SyncMode")
+	}
+
+	// no Flush or Shutdown: the record must be written before Printf returns.
+	logr.Printf("written %s", "immediately")
+	w.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "written immediately") {
+		t.Errorf("record not written synchronously, got %q", data)
+	}
+}
+
+func TestDefaultLevelFunctions(t *testing.T) {
+	lgr := &logr.Logr{SyncMode: true, OnPanic: func(interface{}) {}, OnExit: func(int) {}}
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Trace}
+	_ = lgr.AddTarget(test.NewSlowTarget(filter, &format.Plain{Delim: " | "}, buf, 100))
+	logr.SetDefault(lgr)
+	defer logr.SetDefault(nil)
+
+	logr.Traceln("trace", "ln")
+	logr.Debugln("debug", "ln")
+	logr.Infoln("info", "ln")
+	logr.Warnln("warn", "ln")
+	logr.Errorln("error", "ln")
+	logr.Fatalln("fatal", "ln")
+	logr.Panicln("panic", "ln")
+	logr.Logln(logr.Info, "log", "ln")
+	logr.Tracew("trace w", "key", "t")
+	logr.Debugw("debug w", "key", "d")
+	logr.Infow("info w", "key", "i")
+	logr.Warnw("warn w", "key", "w")
+	logr.Errorw("error w", "key", "e")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"trace ln", "debug ln", "info ln", "warn ln", "error ln",
+		"fatal ln", "panic ln", "log ln", "trace w", "key=t", "debug w", "key=d",
+		"info w", "key=i", "warn w", "key=w", "error w", "key=e"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got %q", want, out)
+		}
+	}
+}
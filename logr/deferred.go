@@ -0,0 +1,36 @@
+package logr
+
+// deferredValue is a field value resolved when a log record is prepped,
+// on the Logr goroutine rather than the logging goroutine.
+type deferredValue interface {
+	// resolve returns the field value for a record at the level, or false
+	// if the field should be omitted.
+	resolve(lvl Level, lgr *Logr) (interface{}, bool)
+}
+
+// resolveDeferredFields resolves any deferred field values. The original
+// fields are returned, without copying, when no deferred values are present.
+func resolveDeferredFields(flds Fields, lvl Level, lgr *Logr) Fields {
+	var found bool
+	for _, v := range flds {
+		if _, ok := v.(deferredValue); ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return flds
+	}
+
+	resolved := make(Fields, len(flds))
+	for k, v := range flds {
+		if dv, ok := v.(deferredValue); ok {
+			var include bool
+			if v, include = dv.resolve(lvl, lgr); !include {
+				continue
+			}
+		}
+		resolved[k] = v
+	}
+	return resolved
+}
@@ -0,0 +1,60 @@
+package logr
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Change describes a value that changed from Old to New. A nil Old indicates
+// the value was created and a nil New indicates the value was deleted.
+// See `Diff`.
+type Change struct {
+	Old interface{}
+	New interface{}
+}
+
+// String returns the change in compact `old→new` form.
+func (c Change) String() string {
+	return fmt.Sprintf("%s→%s", changeValueString(c.Old), changeValueString(c.New))
+}
+
+func changeValueString(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	return fmt.Sprint(v)
+}
+
+// Diff returns a field recording that the value for key changed from old to new,
+// e.g. `logger.WithFields(logr.Diff("email", prev, cur)).Info("user updated")`, so
+// that change auditing is logged consistently. Structured formatters output
+// `{"key":{"old":...,"new":...}}` while text formatters output `key=old→new`.
+// Use nil for old when a value is created and nil for new when it is deleted.
+// Values whose text exceeds `DefaultMaxDiffValueLen` are truncated.
+func Diff(key string, old interface{}, new interface{}) Fields {
+	return Fields{key: Change{Old: truncateDiffValue(old), New: truncateDiffValue(new)}}
+}
+
+// truncateDiffValue replaces a value whose text representation is longer than
+// DefaultMaxDiffValueLen with its truncated text.
+func truncateDiffValue(v interface{}) interface{} {
+	var s string
+	switch t := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case string:
+		s = t
+	case error:
+		s = t.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if len(s) <= DefaultMaxDiffValueLen {
+		return v
+	}
+	n := DefaultMaxDiffValueLen
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,114 @@
+package logr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Dump writes the log records currently in the Logr queue, not yet delivered
+// to targets, to w, oldest first, one per line, e.g. to a crash file from a
+// fatal error handler so records logged just before the crash are not lost.
+// The queue is not consumed: the records stay queued in order and are
+// delivered as usual.
+//
+// Dump is best-effort and may interleave with normal draining: records
+// being delivered while it runs may or may not be included, and the goroutine
+// delivering records waits while they are rendered. Dump does not wait for
+// the queue to drain, so it also works while the queue is wedged; records of
+// senders blocked on a full queue are included. Messages and fields are
+// rendered in a simple format without calling formatters or resolving
+// deferred fields; redaction, see `AddRedactor`, is applied. Returns the
+// first error writing to w, or nil if no target has been added or this Logr
+// is shut down.
+func (logr *Logr) Dump(w io.Writer) error {
+	logr.inMux.RLock()
+	closed := logr.in == nil || logr.inClosed
+	logr.inMux.RUnlock()
+	if closed {
+		return nil
+	}
+
+	// holding queuedMux keeps the read loop from taking, and so releasing,
+	// any of the records while they are rendered.
+	logr.queuedMux.Lock()
+	recs := make([]*LogRec, 0, len(logr.queued))
+	for rec := range logr.queued {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].seq < recs[j].seq
+	})
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		logr.dumpRec(rec, &buf)
+	}
+	logr.queuedMux.Unlock()
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// track records a log record about to be sent to the Logr queue, so that
+// `Dump` can find it without consuming the queue. Flush records are skipped.
+func (logr *Logr) track(rec *LogRec) {
+	if rec.flush != nil {
+		return
+	}
+	logr.queuedMux.Lock()
+	defer logr.queuedMux.Unlock()
+	if logr.queued == nil {
+		logr.queued = make(map[*LogRec]struct{})
+	}
+	logr.queued[rec] = struct{}{}
+}
+
+// untrack forgets a log record taken from the Logr queue, or not sent to it
+// after all.
+func (logr *Logr) untrack(rec *LogRec) {
+	if rec.flush != nil {
+		return
+	}
+	logr.queuedMux.Lock()
+	defer logr.queuedMux.Unlock()
+	delete(logr.queued, rec)
+}
+
+// dumpRec writes a queued log record as a line of text. Queued records are
+// not prepped yet, so the message is resolved here without modifying rec.
+func (logr *Logr) dumpRec(rec *LogRec, buf *bytes.Buffer) {
+	rec.mux.RLock()
+	var msg string
+	switch {
+	case rec.template != "":
+		msg = fmt.Sprintf(rec.template, rec.args...)
+	case rec.newline:
+		msg = fmt.Sprintln(rec.args...)
+	default:
+		msg = fmt.Sprint(rec.args...)
+	}
+	tm, lvl := rec.time, rec.level
+	rec.mux.RUnlock()
+
+	if prefix := rec.logger.prefix; prefix != "" {
+		msg = prefix + " " + msg
+	}
+
+	fields := make(Fields, len(rec.logger.fields))
+	for k, v := range rec.logger.fields {
+		if _, ok := v.(deferredValue); ok {
+			v = "(deferred)"
+		}
+		fields[k] = v
+	}
+	fields = logr.redactFields(fields)
+
+	fmt.Fprintf(buf, "%s %s %s", tm.Format(time.RFC3339Nano), lvl.Name, bytes.TrimRight([]byte(msg), "\n"))
+	if len(fields) > 0 {
+		buf.WriteByte(' ')
+		WriteFields(buf, fields, " ")
+	}
+	buf.WriteByte('\n')
+}
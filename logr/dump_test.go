@@ -0,0 +1,162 @@
+package logr_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+)
+
+// blockingTarget blocks every write until released, recording the message
+// of each record written.
+type blockingTarget struct {
+	logr.Basic
+	release chan struct{}
+
+	mux  sync.Mutex
+	msgs []string
+}
+
+func newBlockingTarget() *blockingTarget {
+	bt := &blockingTarget{release: make(chan struct{})}
+	bt.Basic.Start(bt, bt, &logr.StdFilter{Lvl: logr.Info}, &format.Plain{Delim: " | "}, 1)
+	return bt
+}
+
+func (bt *blockingTarget) Write(rec *logr.LogRec) error {
+	<-bt.release
+	bt.mux.Lock()
+	defer bt.mux.Unlock()
+	bt.msgs = append(bt.msgs, rec.Msg())
+	return nil
+}
+
+func TestDumpWedgedQueue(t *testing.T) {
+	lgr := &logr.Logr{MaxQueueSize: 10, EnqueueTimeout: time.Second * 5}
+	bt := newBlockingTarget()
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+
+	// fill the target queue and the Logr queue, then block more senders.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logger.Info(fmt.Sprintf("record %d", i))
+		}(i)
+		time.Sleep(time.Millisecond * 2)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	buf := &bytes.Buffer{}
+	done := make(chan error, 1)
+	go func() { done <- lgr.Dump(buf) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dump blocked on a wedged queue")
+	}
+
+	if n := strings.Count(buf.String(), "record "); n == 0 {
+		t.Errorf("no queued records dumped: %q", buf.String())
+	}
+
+	close(bt.release)
+	wg.Wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if dropped := lgr.Stats().Dropped; dropped != 0 {
+		t.Errorf("expected no records dropped, got %d", dropped)
+	}
+	if n := len(bt.msgs); n != 20 {
+		t.Errorf("expected 20 records written, got %d", n)
+	}
+}
+
+func TestDumpKeepsRecordsQueued(t *testing.T) {
+	lgr := &logr.Logr{}
+	bt := newBlockingTarget()
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info(fmt.Sprintf("record %d", i))
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	buf := &bytes.Buffer{}
+	if err := lgr.Dump(buf); err != nil {
+		t.Error(err)
+	}
+	dumped := strings.Count(buf.String(), "record ")
+
+	buf.Reset()
+	if err := lgr.Dump(buf); err != nil {
+		t.Error(err)
+	}
+	if again := strings.Count(buf.String(), "record "); again != dumped {
+		t.Errorf("dump changed the queue: %d then %d records", dumped, again)
+	}
+
+	close(bt.release)
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDumpPreservesOrder(t *testing.T) {
+	lgr := &logr.Logr{MaxQueueSize: 10}
+	bt := newBlockingTarget()
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+	var want []string
+	for i := 0; i < 8; i++ {
+		msg := fmt.Sprintf("record %d", i)
+		want = append(want, msg)
+		logger.Info(msg)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	buf := &bytes.Buffer{}
+	if err := lgr.Dump(buf); err != nil {
+		t.Error(err)
+	}
+	// records still in the Logr queue are dumped oldest first.
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) == 0 || !strings.Contains(lines[len(lines)-1], "record 7") {
+		t.Errorf("expected the newest record dumped last, got %q", buf.String())
+	}
+	for i := 1; i < len(lines); i++ {
+		prev := lines[i-1][strings.Index(lines[i-1], "record "):]
+		if cur := lines[i][strings.Index(lines[i], "record "):]; prev >= cur {
+			t.Errorf("dumped out of order: %q", buf.String())
+		}
+	}
+
+	close(bt.release)
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if fmt.Sprint(bt.msgs) != fmt.Sprint(want) {
+		t.Errorf("expected records written in order %v, got %v", want, bt.msgs)
+	}
+	if dropped := lgr.Stats().Dropped; dropped != 0 {
+		t.Errorf("expected no records dropped, got %d", dropped)
+	}
+}
@@ -0,0 +1,95 @@
+package logr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Keys of the nested fields created by `ErrorField`.
+const (
+	ErrorKeyMsg    = "msg"
+	ErrorKeyType   = "type"
+	ErrorKeyFields = "fields"
+	ErrorKeyCause  = "cause"
+	ErrorKeyCauses = "causes"
+)
+
+// errorChainValue is an error converted to nested fields when the log record
+// is prepped.
+type errorChainValue struct {
+	err error
+}
+
+// ErrorField creates a field for an error under `FieldKeyError` whose value is
+// the error's chain of causes as nested fields, e.g. rendered by the JSON
+// formatter as `"error":{"msg":"...","type":"...","cause":{...}}`. Each error
+// in the chain contributes its message, its type and any fields returned by a
+// `Fields() logr.Fields` or `Fields() map[string]interface{}` method. Causes
+// are found via `errors.Unwrap`; errors wrapping several errors, e.g. via
+// `errors.Join`, list them under "causes" keyed by index. The chain is limited
+// to DefaultMaxErrorChain errors deep.
+//
+// The chain is walked when the log record is processed by the Logr, so the
+// error must not be modified after logging. A nil error creates an empty
+// field which adds nothing.
+func ErrorField(err error) Field {
+	if err == nil {
+		return Field{}
+	}
+	return Field{Key: FieldKeyError, Value: errorChainValue{err: err}}
+}
+
+// Error returns the message of the error, so formatters not aware of
+// deferred values still render something useful.
+func (ev errorChainValue) Error() string {
+	return ev.err.Error()
+}
+
+// resolve converts the error chain to fields.
+func (ev errorChainValue) resolve(_ Level, _ *Logr) (interface{}, bool) {
+	return errorChainFields(ev.err, 0), true
+}
+
+// errorChainFields returns the fields describing err and its causes.
+func errorChainFields(err error, depth int) Fields {
+	flds := Fields{
+		ErrorKeyMsg:  err.Error(),
+		ErrorKeyType: fmt.Sprintf("%T", err),
+	}
+	if ef := errorFields(err); len(ef) > 0 {
+		flds[ErrorKeyFields] = ef
+	}
+	if depth+1 >= DefaultMaxErrorChain {
+		return flds
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		causes := make(Fields)
+		for i, cause := range u.Unwrap() {
+			if cause != nil {
+				causes[strconv.Itoa(i)] = errorChainFields(cause, depth+1)
+			}
+		}
+		if len(causes) > 0 {
+			flds[ErrorKeyCauses] = causes
+		}
+	default:
+		if cause := errors.Unwrap(err); cause != nil {
+			flds[ErrorKeyCause] = errorChainFields(cause, depth+1)
+		}
+	}
+	return flds
+}
+
+// errorFields returns the fields attached to an error via a Fields method, if any.
+func errorFields(err error) Fields {
+	switch e := err.(type) {
+	case interface{ Fields() Fields }:
+		return e.Fields()
+	case interface{ Fields() map[string]interface{} }:
+		return Fields(e.Fields())
+	}
+	return nil
+}
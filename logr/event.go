@@ -0,0 +1,57 @@
+package logr
+
+// Conventional lifecycle event types for `Logger.Event`, so that operational
+// dashboards can detect these events consistently across services.
+const (
+	EventStartup           = "startup"
+	EventShutdown          = "shutdown"
+	EventConfigReload      = "config_reload"
+	EventConnectionOpened  = "connection_opened"
+	EventConnectionClosed  = "connection_closed"
+	EventKeyRotation       = "key_rotation"
+	EventLeadershipChanged = "leadership_changed"
+)
+
+// Event logs an operational lifecycle event at Info level, e.g.
+// `logger.Event(logr.EventConfigReload, logr.Fields{"source": path})`. The
+// event type is recorded under the reserved `FieldKeyEvent` key, which
+// formatters output ahead of other fields, and is also used as the message.
+// Any fields are added to the record as with `WithFields`. Event types other
+// than the provided constants may be used; prefer short snake_case names.
+func (logger Logger) Event(eventType string, fields ...Fields) {
+	l := logger
+	for _, f := range fields {
+		l = l.WithFields(f)
+	}
+	l.event = eventType
+	l.Log(Info, eventType)
+}
+
+// reservedFields returns the reserved fields for log records created by
+// this Logger, or nil if none.
+func (logger Logger) reservedFields() Fields {
+	var reserved Fields
+	if v := logger.logr.SchemaVersion(); v != "" {
+		reserved = Fields{FieldKeySchemaVersion: v}
+	}
+	if logger.event != "" {
+		if reserved == nil {
+			reserved = make(Fields, 1)
+		}
+		reserved[FieldKeyEvent] = logger.event
+	}
+	if logger.sampleRate > 1 {
+		if reserved == nil {
+			reserved = make(Fields, 1)
+		}
+		reserved[FieldKeySampleRate] = logger.sampleRate
+	}
+	return reserved
+}
+
+// Event returns the lifecycle event type of this log record, or empty
+// string if the record was not created via `Logger.Event`.
+func (rec *LogRec) Event() string {
+	v, _ := rec.reserved[FieldKeyEvent].(string)
+	return v
+}
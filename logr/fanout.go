@@ -0,0 +1,72 @@
+package logr
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// fanoutConcurrent delivers a log record to each enabled target in its own
+// goroutine, returning once every target's `Log` has returned. Returns true if
+// any target was enabled. Must be called with `tmux` read locked. Waiting for
+// every target keeps records in order per target and guarantees the record is
+// not reused while a target's `Log` is running.
+func (logr *Logr) fanoutConcurrent(rec *LogRec) bool {
+	enabled := make([]Target, 0, len(logr.targets))
+	for _, target := range logr.targets {
+		if e, _ := target.IsLevelEnabled(rec.Level()); e && logr.allowTarget(target) {
+			retainFor(target, rec)
+			enabled = append(enabled, target)
+		}
+	}
+
+	switch len(enabled) {
+	case 0:
+		return false
+	case 1:
+		return logr.logSafe(enabled[0], rec)
+	}
+
+	var logged int32
+	var wg sync.WaitGroup
+	wg.Add(len(enabled) - 1)
+	for _, target := range enabled[1:] {
+		go func(target Target) {
+			defer wg.Done()
+			if logr.logSafe(target, rec) {
+				atomic.StoreInt32(&logged, 1)
+			}
+		}(target)
+	}
+	// deliver to the first target from this goroutine.
+	if logr.logSafe(enabled[0], rec) {
+		atomic.StoreInt32(&logged, 1)
+	}
+	wg.Wait()
+	return atomic.LoadInt32(&logged) == 1
+}
+
+// logSafe delivers a log record to a target, recovering from a panic in the
+// target's `Log` so that the record is still delivered to the other targets.
+// Returns false if the target panicked.
+func (logr *Logr) logSafe(target Target, rec *LogRec) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			logr.targetPanicked(target, rec, r)
+		}
+	}()
+	logr.logTimed(target, rec)
+	return true
+}
+
+// targetPanicked counts and reports a panic recovered from a target's `Log`,
+// along with the target and record, and passes it to `OnTargetPanic`.
+func (logr *Logr) targetPanicked(target Target, rec *LogRec, r interface{}) {
+	logr.stats.inc(statTargetPanics)
+	// the record may be the cause, so avoid formatting it.
+	logr.ReportError(fmt.Errorf("fanout failed for target %s, record [%s %q]: %v",
+		target, rec.Level().Name, rec.Msg(), r))
+	if logr.OnTargetPanic != nil {
+		logr.OnTargetPanic(target, rec, r)
+	}
+}
@@ -0,0 +1,135 @@
+package logr
+
+import (
+	"sort"
+	"time"
+)
+
+// Field is a typed key/value pair attached to log records via `Logger.With`
+// or passed to the sugared logging methods such as `Logger.Infow`. Values
+// keep their type so formatters can render them as structured data, e.g.
+// JSON numbers and booleans.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a string field.
+func String(key string, val string) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Int creates an integer field.
+func Int(key string, val int) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Bool creates a boolean field.
+func Bool(key string, val bool) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Err creates a field for an error under `FieldKeyError`. A nil error
+// creates an empty field which adds nothing.
+func Err(err error) Field {
+	if err == nil {
+		return Field{}
+	}
+	return Field{Key: FieldKeyError, Value: err}
+}
+
+// Time creates a time field.
+func Time(key string, val time.Time) Field {
+	return Field{Key: key, Value: val}
+}
+
+// Duration creates a duration field.
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Value: val}
+}
+
+// With creates a new `Logger` with any existing fields plus the typed
+// fields, replacing existing fields with the same key. Fields with an
+// empty key are skipped. Fields passed to a logging call, e.g. via
+// `Logger.Infow`, take precedence over these on key collisions.
+func (logger Logger) With(fields ...Field) Logger {
+	flds := make(Fields, len(fields))
+	for _, f := range fields {
+		if f.Key != "" {
+			flds[f.Key] = f.Value
+		}
+	}
+	if len(flds) == 0 {
+		return logger
+	}
+	return logger.WithFields(flds)
+}
+
+// FieldSlice returns this log record's fields as typed fields sorted by key.
+func (rec *LogRec) FieldSlice() []Field {
+	fields := rec.Fields()
+	slice := make([]Field, 0, len(fields))
+	for k, v := range fields {
+		slice = append(slice, Field{Key: k, Value: v})
+	}
+	sort.Slice(slice, func(i, j int) bool { return slice[i].Key < slice[j].Key })
+	return slice
+}
+
+// FieldString returns the string field with the key, if present.
+func (rec *LogRec) FieldString(key string) (string, bool) {
+	v, ok := rec.Fields()[key].(string)
+	return v, ok
+}
+
+// FieldInt returns the integer field with the key, if present. Any signed
+// or unsigned integer type is converted.
+func (rec *LogRec) FieldInt(key string) (int64, bool) {
+	switch v := rec.Fields()[key].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case uint:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// FieldBool returns the boolean field with the key, if present.
+func (rec *LogRec) FieldBool(key string) (bool, bool) {
+	v, ok := rec.Fields()[key].(bool)
+	return v, ok
+}
+
+// FieldErr returns the error field under `FieldKeyError`, if present.
+func (rec *LogRec) FieldErr() (error, bool) {
+	v, ok := rec.Fields()[FieldKeyError].(error)
+	return v, ok
+}
+
+// FieldTime returns the time field with the key, if present.
+func (rec *LogRec) FieldTime(key string) (time.Time, bool) {
+	v, ok := rec.Fields()[key].(time.Time)
+	return v, ok
+}
+
+// FieldDuration returns the duration field with the key, if present.
+func (rec *LogRec) FieldDuration(key string) (time.Duration, bool) {
+	v, ok := rec.Fields()[key].(time.Duration)
+	return v, ok
+}
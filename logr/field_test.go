@@ -0,0 +1,124 @@
+package logr_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+)
+
+// typedTarget records the typed field accessors of each record written.
+type typedTarget struct {
+	logr.Basic
+
+	mux   sync.Mutex
+	recs  []typedFields
+	slice [][]logr.Field
+}
+
+type typedFields struct {
+	str    string
+	num    int64
+	ok     bool
+	err    error
+	when   time.Time
+	took   time.Duration
+	found  [6]bool
+	fields logr.Fields
+}
+
+func newTypedTarget() *typedTarget {
+	tt := &typedTarget{}
+	tt.Basic.Start(tt, tt, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	return tt
+}
+
+func (tt *typedTarget) Write(rec *logr.LogRec) error {
+	var tf typedFields
+	tf.str, tf.found[0] = rec.FieldString("user")
+	tf.num, tf.found[1] = rec.FieldInt("count")
+	tf.ok, tf.found[2] = rec.FieldBool("ok")
+	tf.err, tf.found[3] = rec.FieldErr()
+	tf.when, tf.found[4] = rec.FieldTime("when")
+	tf.took, tf.found[5] = rec.FieldDuration("took")
+	tf.fields = rec.Fields()
+
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	tt.recs = append(tt.recs, tf)
+	tt.slice = append(tt.slice, rec.FieldSlice())
+	return nil
+}
+
+func TestWithTypedFields(t *testing.T) {
+	lgr := &logr.Logr{}
+	tt := newTypedTarget()
+	_ = lgr.AddTarget(tt)
+
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	errFailed := errors.New("failed")
+	logger := lgr.NewLogger().WithField("user", "old").With(
+		logr.String("user", "sam"), // replaces the existing field
+		logr.Int("count", 3),
+		logr.Bool("ok", true),
+		logr.Err(errFailed),
+		logr.Time("when", when),
+		logr.Duration("took", time.Second),
+		logr.Err(nil),           // adds nothing
+		logr.Field{Value: "no"}, // skipped
+	)
+	logger.Info("typed")
+	// fields passed to the logging call take precedence.
+	logger.Infow("sugared", "count", int64(4))
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if len(tt.recs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(tt.recs))
+	}
+	got := tt.recs[0]
+	for i, found := range got.found {
+		if !found {
+			t.Errorf("typed field %d not found in %v", i, got.fields)
+		}
+	}
+	if got.str != "sam" || got.num != 3 || !got.ok || got.err != errFailed || !got.when.Equal(when) || got.took != time.Second {
+		t.Errorf("unexpected typed fields %+v", got)
+	}
+	if len(got.fields) != 6 {
+		t.Errorf("expected 6 fields, got %v", got.fields)
+	}
+	if s := tt.slice[0]; len(s) != 6 || s[0].Key != "count" || s[5].Key != "when" {
+		t.Errorf("expected fields sorted by key, got %v", s)
+	}
+	if num := tt.recs[1].num; num != 4 {
+		t.Errorf("expected the sugared field to take precedence, got %d", num)
+	}
+	if _, ok := tt.recs[1].fields["user"]; !ok {
+		t.Error("expected With fields kept for sugared calls")
+	}
+}
+
+func TestWithTypedFieldsJSON(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &bytes.Buffer{}
+	_ = lgr.AddTarget(target.NewWriterTarget(&logr.StdFilter{Lvl: logr.Info}, &format.JSON{DisableTimestamp: true}, buf, 100))
+
+	lgr.NewLogger().With(logr.Int("count", 3), logr.Bool("ok", true), logr.String("user", "sam")).Info("typed")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// values keep their type when rendered.
+	for _, want := range []string{`"count":3`, `"ok":true`, `"user":"sam"`} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("expected %s in %s", want, buf.String())
+		}
+	}
+}
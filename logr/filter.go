@@ -0,0 +1,48 @@
+package logr
+
+// LevelID is the unique id of each level.
+type LevelID uint
+
+// Level provides a mechanism to enable/disable specific log lines.
+type Level struct {
+	ID         LevelID
+	Name       string
+	Stacktrace bool
+}
+
+// String returns the name of this level.
+func (level Level) String() string {
+	return level.Name
+}
+
+// Filter allows targets to determine which Level(s) are active
+// for logging and which Level(s) require a stack trace to be output.
+// A default implementation using "panic, fatal..." is provided, and
+// a more flexible alternative implementation is also provided that
+// allows any number of custom levels.
+type Filter interface {
+	IsEnabled(Level) bool
+	IsStacktraceEnabled(Level) bool
+}
+
+// SetFilter sets a function called for every log record, after its message
+// and fields are resolved and before it is delivered to any target, e.g. to
+// silence a noisy but benign message regardless of level. Returning false
+// drops the record, counting it in `Stats.Filtered`. The filter is called
+// from the goroutine delivering log records, so it must be fast, must not
+// block or log to this Logr, and must not modify rec. Passing nil removes
+// the filter. It can be changed at any time.
+func (logr *Logr) SetFilter(filter func(rec *LogRec) bool) {
+	logr.recFilter.Store(filter)
+}
+
+// filterRecord returns false, counting the record as filtered, if the filter
+// set via `SetFilter` drops the record.
+func (logr *Logr) filterRecord(rec *LogRec) bool {
+	filter, _ := logr.recFilter.Load().(func(rec *LogRec) bool)
+	if filter == nil || filter(rec) {
+		return true
+	}
+	logr.stats.inc(statFiltered)
+	return false
+}
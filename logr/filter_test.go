@@ -0,0 +1,45 @@
+package logr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+)
+
+func TestSetFilter(t *testing.T) {
+	lgr := &logr.Logr{}
+	ft := newFieldsTarget()
+	_ = lgr.AddTarget(ft)
+	logger := lgr.NewLogger()
+
+	// the filter sees resolved messages and fields.
+	lgr.SetFilter(func(rec *logr.LogRec) bool {
+		return !strings.HasPrefix(rec.Msg(), "noisy") && rec.Fields()["drop"] != true
+	})
+	logger.Info("noisy ", 1)
+	logger.Info("kept")
+	logger.WithField("drop", true).Error("dropped regardless of level")
+	logger.WithField("drop", false).Info("kept with field")
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if n := lgr.Stats().Filtered; n != 2 {
+		t.Errorf("expected 2 records filtered, got %d", n)
+	}
+
+	// a nil filter removes it.
+	lgr.SetFilter(nil)
+	logger.Info("noisy again")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if got := fmt.Sprint(ft.msgs); got != "[kept kept with field noisy again]" {
+		t.Errorf("expected [kept kept with field noisy again], got %s", got)
+	}
+	if n := lgr.Stats().Filtered; n != 2 {
+		t.Errorf("expected no more records filtered, got %d", n)
+	}
+}
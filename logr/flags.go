@@ -0,0 +1,38 @@
+package logr
+
+import (
+	"sort"
+)
+
+// Flags returns fields describing feature-flag evaluations, grouped under the
+// `FieldKeyFlags` key, e.g. `logger.WithFields(logr.Flags(evaluated)).Debug("flags")`.
+// At most `DefaultMaxGroupFields` flags are included, chosen in key order; when
+// truncated, the number of omitted flags is recorded under `FieldKeyTruncated`
+// within the group.
+func Flags(flags map[string]interface{}) Fields {
+	return Fields{FieldKeyFlags: boundedGroup(flags, DefaultMaxGroupFields)}
+}
+
+// boundedGroup converts a map to a Fields group containing at most max entries.
+func boundedGroup(m map[string]interface{}, max int) Fields {
+	if len(m) <= max {
+		group := make(Fields, len(m))
+		for k, v := range m {
+			group[k] = v
+		}
+		return group
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	group := make(Fields, max+1)
+	for _, k := range keys[:max] {
+		group[k] = m[k]
+	}
+	group[FieldKeyTruncated] = len(keys) - max
+	return group
+}
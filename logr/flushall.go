@@ -0,0 +1,78 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// flushAllPollFreq is how often `FlushAll` checks for in-flight enqueues to finish.
+const flushAllPollFreq = time.Millisecond
+
+// enqueueBarrier tracks log records being enqueued so `FlushAll` can wait for
+// enqueues in flight when it starts, without waiting for those started after.
+// Enqueues are counted per epoch; `FlushAll` starts a new epoch then waits for
+// the count of the previous epoch to reach zero.
+type enqueueBarrier struct {
+	mux      sync.Mutex // serializes epoch changes
+	epoch    uint32     // atomic
+	inflight [2]int64   // atomic; enqueues in flight per epoch parity
+}
+
+// enter counts an enqueue in flight, returning the index to pass to `leave`.
+func (b *enqueueBarrier) enter() int {
+	for {
+		e := atomic.LoadUint32(&b.epoch)
+		idx := int(e & 1)
+		atomic.AddInt64(&b.inflight[idx], 1)
+		if atomic.LoadUint32(&b.epoch) == e {
+			return idx
+		}
+		// the epoch changed before this enqueue was counted; count it in the new epoch.
+		atomic.AddInt64(&b.inflight[idx], -1)
+	}
+}
+
+// leave marks an enqueue counted by `enter` as finished.
+func (b *enqueueBarrier) leave(idx int) {
+	atomic.AddInt64(&b.inflight[idx], -1)
+}
+
+// wait starts a new epoch and blocks until all enqueues counted in the
+// previous epoch have finished or the deadline passes. Returns false on timeout.
+func (b *enqueueBarrier) wait(deadline time.Time) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	prev := int(atomic.AddUint32(&b.epoch, 1)-1) & 1
+	for atomic.LoadInt64(&b.inflight[prev]) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(flushAllPollFreq)
+	}
+	return true
+}
+
+// FlushAll is like `Flush` but first waits for log records whose enqueue is in
+// flight, e.g. blocked waiting for space in a full Logr queue, to be queued.
+//
+// Ordering guarantee: every log record a logging API (`Info`, `Log`, etc.)
+// had begun adding to the Logr queue before FlushAll was called is written to
+// the enabled targets before FlushAll returns, unless the record was dropped,
+// e.g. by `OnQueueFull`, or its enqueue timed out per `EnqueueTimeout`. Log
+// records added after FlushAll is called may or may not be written. By
+// contrast `Flush` only guarantees records whose logging call returned before
+// it was called.
+//
+// `FlushTimeout` bounds the wait for in-flight enqueues and, separately, the
+// flush that follows.
+func (logr *Logr) FlushAll() error {
+	if !logr.HasTargets() {
+		return nil
+	}
+	if !logr.enqueues.wait(time.Now().Add(logr.flushTimeout())) {
+		return newTimeoutError("logr FlushAll timeout waiting for in-flight enqueues")
+	}
+	return logr.Flush()
+}
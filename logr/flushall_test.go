@@ -0,0 +1,110 @@
+package logr_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// gateTarget blocks writes until its gate is opened, recording the message
+// of each record written.
+type gateTarget struct {
+	logr.Basic
+	gate chan struct{}
+
+	mux     sync.Mutex
+	written map[string]bool
+}
+
+func newGateTarget(maxQueue int) *gateTarget {
+	gt := &gateTarget{gate: make(chan struct{}), written: make(map[string]bool)}
+	gt.Basic.Start(gt, gt, &logr.StdFilter{Lvl: logr.Info}, nil, maxQueue)
+	return gt
+}
+
+func (gt *gateTarget) Write(rec *logr.LogRec) error {
+	<-gt.gate
+	gt.mux.Lock()
+	defer gt.mux.Unlock()
+	gt.written[rec.Msg()] = true
+	return nil
+}
+
+func TestFlushAllInFlight(t *testing.T) {
+	for round := 0; round < 5; round++ {
+		flushAllRound(t)
+	}
+}
+
+// flushAllRound blocks many loggers on a full queue, then checks that
+// FlushAll writes every record whose logging call began before FlushAll was
+// called while the loggers keep logging.
+func flushAllRound(t *testing.T) {
+	lgr := &logr.Logr{MaxQueueSize: 5, EnqueueTimeout: time.Minute, FlushTimeout: time.Minute}
+	gt := newGateTarget(5)
+	if err := lgr.AddTarget(gt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+
+	const goroutines = 20
+	const records = 50
+	begun := make([]int64, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < records; i++ {
+				atomic.StoreInt64(&begun[g], int64(i+1))
+				logger.Info(fmt.Sprintf("%d-%d", g, i))
+			}
+		}(g)
+	}
+
+	// wait for every logger to block on the full queues.
+	snapshot := func() []int64 {
+		s := make([]int64, goroutines)
+		for g := range s {
+			s[g] = atomic.LoadInt64(&begun[g])
+		}
+		return s
+	}
+	inFlight := snapshot()
+	for {
+		time.Sleep(20 * time.Millisecond)
+		next := snapshot()
+		if fmt.Sprint(next) == fmt.Sprint(inFlight) {
+			break
+		}
+		inFlight = next
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- lgr.FlushAll()
+	}()
+	close(gt.gate)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	gt.mux.Lock()
+	for g, n := range inFlight {
+		for i := 0; i < int(n); i++ {
+			if msg := fmt.Sprintf("%d-%d", g, i); !gt.written[msg] {
+				t.Errorf("record %s began before FlushAll but was not written", msg)
+			}
+		}
+	}
+	gt.mux.Unlock()
+
+	wg.Wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,82 @@
+package logr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/test"
+)
+
+func TestFlushGroupMembers(t *testing.T) {
+	group := &logr.FlushGroup{}
+	for _, name := range []string{"b", "a", "c"} {
+		if err := group.Add(name, &logr.Logr{}); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := group.Add("a", &logr.Logr{}); err == nil {
+		t.Error("expected an error adding a name twice")
+	}
+	group.Remove("b")
+	if names := fmt.Sprint(group.Names()); names != "[a c]" {
+		t.Errorf("expected [a c], got %s", names)
+	}
+}
+
+func TestFlushGroupFlushAll(t *testing.T) {
+	group := &logr.FlushGroup{}
+	formatter := &format.Plain{DisableTimestamp: true, Delim: " | "}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+
+	bufs := make(map[string]*test.Buffer)
+	for _, name := range []string{"one", "two"} {
+		lgr := &logr.Logr{}
+		bufs[name] = &test.Buffer{}
+		_ = lgr.AddTarget(test.NewSlowTarget(filter, formatter, bufs[name], 100))
+		_ = group.Add(name, lgr)
+		lgr.NewLogger().Info("record for ", name)
+	}
+	// a Logr wedged by a blocked target times out alone.
+	stuck := &logr.Logr{}
+	bt := newBlockingTarget()
+	_ = stuck.AddTarget(bt)
+	_ = group.Add("stuck", stuck)
+	stuck.NewLogger().Info("stuck")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := group.FlushAll(ctx)
+
+	var ge *logr.GroupError
+	if !errors.As(err, &ge) {
+		t.Fatalf("expected a GroupError, got %v", err)
+	}
+	if len(ge.Errors) != 1 || !logr.IsTimeoutError(ge.Errors["stuck"]) {
+		t.Errorf("expected only a timeout for stuck, got %v", ge.Errors)
+	}
+	if !strings.HasPrefix(err.Error(), "stuck: ") {
+		t.Errorf("expected the error named, got %q", err)
+	}
+	for name, buf := range bufs {
+		if !strings.Contains(buf.String(), "record for "+name) {
+			t.Errorf("expected %s flushed, got %q", name, buf.String())
+		}
+	}
+
+	close(bt.release)
+	if err := group.ShutdownAll(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if len(bt.msgs) != 1 {
+		t.Errorf("expected the stuck record written on shutdown, got %v", bt.msgs)
+	}
+	if names := fmt.Sprint(group.Names()); names != "[one stuck two]" {
+		t.Errorf("expected the Logr instances kept after ShutdownAll, got %s", names)
+	}
+}
@@ -0,0 +1,137 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/francoispqt/gojay"
+	"github.com/mattermost/logr"
+)
+
+const (
+	// BunyanTimestampFormat is the ISO 8601 UTC timestamp format used by Bunyan.
+	BunyanTimestampFormat = "2006-01-02T15:04:05.000Z"
+
+	// bunyanVersion is the Bunyan log record format version.
+	bunyanVersion = 0
+)
+
+// bunyanKeys are the keys reserved by the Bunyan log record format.
+var bunyanKeys = map[string]struct{}{
+	"v": {}, "level": {}, "name": {}, "hostname": {}, "pid": {}, "time": {}, "msg": {}, "src": {}, "stacktrace": {},
+}
+
+// Bunyan formats log records as JSON compatible with the Node.js Bunyan
+// library, so that Bunyan tooling such as the `bunyan` CLI can view them.
+// Levels are mapped to Bunyan numeric levels via `logr.BunyanLevel` and
+// fields are output at the top level; fields colliding with Bunyan keys
+// are prefixed with an underscore.
+type Bunyan struct {
+	// Name is the application name output under the `name` key. Defaults to
+	// the executable name.
+	Name string
+
+	// Hostname overrides the host name output under the `hostname` key. Defaults
+	// to `os.Hostname`.
+	Hostname string
+
+	// DisableStacktrace disables output of stack trace.
+	DisableStacktrace bool
+
+	once sync.Once
+	pid  int
+}
+
+// Format converts a log record to bytes in Bunyan JSON format.
+func (b *Bunyan) Format(rec *logr.LogRec, stacktrace bool, buf *bytes.Buffer) (*bytes.Buffer, error) {
+	b.once.Do(b.applyDefaults)
+
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	enc := gojay.BorrowEncoder(buf)
+	defer func() {
+		enc.Release()
+	}()
+
+	brec := bunyanLogRec{
+		LogRec:     rec,
+		Bunyan:     b,
+		stacktrace: stacktrace,
+	}
+
+	err := enc.EncodeObject(brec)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf, nil
+}
+
+func (b *Bunyan) applyDefaults() {
+	if b.Name == "" {
+		b.Name = filepath.Base(os.Args[0])
+	}
+	if b.Hostname == "" {
+		b.Hostname, _ = os.Hostname()
+	}
+	b.pid = os.Getpid()
+}
+
+// bunyanLogRec decorates a LogRec adding Bunyan JSON encoding.
+type bunyanLogRec struct {
+	*logr.LogRec
+	*Bunyan
+	stacktrace bool
+}
+
+// MarshalJSONObject encodes the LogRec as Bunyan JSON.
+func (rec bunyanLogRec) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.AddIntKey("v", bunyanVersion)
+	enc.AddIntKey("level", logr.BunyanLevel(rec.Level()))
+	enc.AddStringKey("name", rec.Name)
+	enc.AddStringKey("hostname", rec.Hostname)
+	enc.AddIntKey("pid", rec.pid)
+	time := rec.Time().UTC()
+	enc.AddTimeKey("time", &time, BunyanTimestampFormat)
+	enc.AddStringKey("msg", rec.Msg())
+
+	reserved := rec.ReservedFields()
+	flds := logr.ProtectFields(rec.Fields(), reserved)
+	for _, cf := range sortFields(reserved) {
+		encodeField(enc, bunyanKey(cf.Key, flds), cf.Val)
+	}
+	for _, cf := range sortFields(flds) {
+		encodeField(enc, bunyanKey(cf.Key, flds), cf.Val)
+	}
+
+	if rec.stacktrace && !rec.DisableStacktrace {
+		frames := rec.StackFrames()
+		if len(frames) > 0 {
+			enc.AddArrayKey("stacktrace", stackFrames(frames))
+		}
+	}
+}
+
+// IsNil returns true if the LogRec pointer is nil.
+func (rec bunyanLogRec) IsNil() bool {
+	return rec.LogRec == nil
+}
+
+// bunyanKey prefixes keys colliding with Bunyan keys with underscores until
+// the key does not collide with Bunyan keys or other fields.
+func bunyanKey(key string, flds logr.Fields) string {
+	if _, ok := bunyanKeys[key]; !ok {
+		return key
+	}
+	for {
+		key = "_" + key
+		_, isBunyan := bunyanKeys[key]
+		_, isField := flds[key]
+		if !isBunyan && !isField {
+			return key
+		}
+	}
+}
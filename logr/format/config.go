@@ -0,0 +1,33 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/mattermost/logr"
+)
+
+func init() {
+	logr.RegisterFormatterType("plain", func(options json.RawMessage) (logr.Formatter, error) {
+		f := &Plain{}
+		return f, decodeOptions(options, f)
+	})
+	logr.RegisterFormatterType("json", func(options json.RawMessage) (logr.Formatter, error) {
+		f := &JSON{}
+		return f, decodeOptions(options, f)
+	})
+	logr.RegisterFormatterType("bunyan", func(options json.RawMessage) (logr.Formatter, error) {
+		f := &Bunyan{}
+		return f, decodeOptions(options, f)
+	})
+}
+
+// decodeOptions decodes JSON formatter options into v, rejecting unknown options.
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(options))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,11 @@
+module github.com/mattermost/logr
+
+go 1.12
+
+require (
+	github.com/francoispqt/gojay v1.2.13
+	github.com/stretchr/testify v1.2.2
+	github.com/wiggin77/cfg v1.0.2
+	github.com/wiggin77/merror v1.0.2
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0
+)
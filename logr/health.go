@@ -0,0 +1,82 @@
+package logr
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// TargetHealth is a snapshot of the health of a target.
+type TargetHealth struct {
+	// Known is false if the target does not report its health, in which case
+	// the remaining fields are zero.
+	Known bool
+
+	// Up is true if the target's most recent write succeeded, or it has not
+	// written yet.
+	Up bool
+
+	// LastError is the most recent write error, or nil if none.
+	LastError error
+
+	// LastErrorTime is when LastError occurred, or zero time if none.
+	LastErrorTime time.Time
+
+	// ConsecutiveFailures is the number of writes that have failed since the
+	// last successful write.
+	ConsecutiveFailures int
+}
+
+// String returns "up", "down" or "unknown".
+func (h TargetHealth) String() string {
+	switch {
+	case !h.Known:
+		return "unknown"
+	case h.Up:
+		return "up"
+	default:
+		return "down"
+	}
+}
+
+// TargetWithHealth is a target that reports its health, e.g. for dashboards
+// or to fail over to another target. Targets built on `Basic` report health
+// from the results of writing log records.
+type TargetWithHealth interface {
+	Health() TargetHealth
+}
+
+// HealthOf returns the health of a target, or a TargetHealth with Known false
+// if the target does not implement `TargetWithHealth`.
+func HealthOf(target Target) TargetHealth {
+	if th, ok := target.(TargetWithHealth); ok {
+		return th.Health()
+	}
+	return TargetHealth{}
+}
+
+// Health returns the health of each target. Targets that do not implement
+// `TargetWithHealth` are included with Known false.
+func (logr *Logr) Health() map[Target]TargetHealth {
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+
+	health := make(map[Target]TargetHealth, len(logr.targets))
+	for _, t := range logr.targets {
+		health[t] = HealthOf(t)
+	}
+	return health
+}
+
+// Health returns the health of this target from the results of writing log
+// records.
+func (b *Basic) Health() TargetHealth {
+	err, when := b.LastError()
+	failures := atomic.LoadInt64(&b.consecutiveFailures)
+	return TargetHealth{
+		Known:               true,
+		Up:                  failures == 0,
+		LastError:           err,
+		LastErrorTime:       when,
+		ConsecutiveFailures: int(failures),
+	}
+}
@@ -0,0 +1,149 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthSwitch decides when delivery should fail over from primary targets
+// to a standby, and back, by periodically checking the health of the primary
+// targets. It switches only after consecutive checks agree, so a target
+// alternating between failed and successful writes does not flap. It is used
+// by `Supervisor` and `target.Failover`.
+//
+// By default a target is healthy while its most recent write succeeded, see
+// `HealthOf`; targets that do not report their health are always healthy.
+// While failed over the primary targets receive no records, so a target's
+// health cannot change. `Probe` therefore returns true once per check
+// interval, and the caller delivers that log record to the primary as well as
+// the standby so the primary's health reflects whether its sink has recovered.
+// When `SupervisorOptions.IsHealthy` is supplied no probe records are needed.
+type HealthSwitch struct {
+	opts    SupervisorOptions
+	targets func() []Target
+
+	failed   int32 // atomic; 1 while failed over
+	probeDue int32 // atomic; 1 when a probe record is due
+
+	unhealthyChecks int
+	healthyChecks   int
+
+	stopOnce sync.Once
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// NewHealthSwitch creates a HealthSwitch that checks the health of the primary
+// targets returned by targets, until stopped.
+func NewHealthSwitch(targets func() []Target, opts SupervisorOptions) *HealthSwitch {
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = DefaultSupervisorCheckInterval
+	}
+	if opts.FailAfter <= 0 {
+		opts.FailAfter = DefaultSupervisorFailAfter
+	}
+	if opts.RecoverAfter <= 0 {
+		opts.RecoverAfter = DefaultSupervisorRecoverAfter
+	}
+	hs := &HealthSwitch{
+		opts:    opts,
+		targets: targets,
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go hs.start()
+	return hs
+}
+
+// IsFailedOver returns true while log records should be delivered to the standby.
+func (hs *HealthSwitch) IsFailedOver() bool {
+	return atomic.LoadInt32(&hs.failed) == 1
+}
+
+// Probe returns true, at most once per check interval while failed over, if
+// a log record should also be delivered to the primary targets to check
+// whether they have recovered.
+func (hs *HealthSwitch) Probe() bool {
+	if hs.opts.IsHealthy != nil || !hs.IsFailedOver() {
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&hs.probeDue, 1, 0)
+}
+
+// Stop stops checking health. The failover state no longer changes.
+func (hs *HealthSwitch) Stop() {
+	hs.stopOnce.Do(func() {
+		close(hs.quit)
+		<-hs.done
+	})
+}
+
+// start checks health until stopped.
+func (hs *HealthSwitch) start() {
+	defer close(hs.done)
+	ticker := time.NewTicker(hs.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hs.quit:
+			return
+		case <-ticker.C:
+			hs.check()
+		}
+	}
+}
+
+// check updates the failover state from the health of the primary targets,
+// switching only after enough consecutive checks agree.
+func (hs *HealthSwitch) check() {
+	if hs.healthy() {
+		hs.healthyChecks++
+		hs.unhealthyChecks = 0
+	} else {
+		hs.unhealthyChecks++
+		hs.healthyChecks = 0
+	}
+
+	switch {
+	case !hs.IsFailedOver() && hs.unhealthyChecks >= hs.opts.FailAfter:
+		hs.setFailedOver(true)
+	case hs.IsFailedOver() && hs.healthyChecks >= hs.opts.RecoverAfter:
+		hs.setFailedOver(false)
+	}
+	if hs.IsFailedOver() {
+		atomic.StoreInt32(&hs.probeDue, 1)
+	}
+}
+
+func (hs *HealthSwitch) setFailedOver(failed bool) {
+	var v int32
+	if failed {
+		v = 1
+	}
+	atomic.StoreInt32(&hs.failed, v)
+	if hs.opts.OnSwitch != nil {
+		hs.opts.OnSwitch(failed)
+	}
+}
+
+// healthy returns true if at least one primary target is healthy. No
+// primary targets is unhealthy.
+func (hs *HealthSwitch) healthy() bool {
+	for _, t := range hs.targets() {
+		if hs.isHealthy(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHealthy reports target health via `SupervisorOptions.IsHealthy` or, by
+// default, via `HealthOf`.
+func (hs *HealthSwitch) isHealthy(t Target) bool {
+	if hs.opts.IsHealthy != nil {
+		return hs.opts.IsHealthy(t)
+	}
+	h := HealthOf(t)
+	return !h.Known || h.Up
+}
@@ -0,0 +1,116 @@
+package logr
+
+import (
+	"sync"
+)
+
+// LevelStatus represents whether a level is enabled and
+// requires a stack trace.
+type LevelStatus struct {
+	// Enabled is true if at least one target outputs the level.
+	Enabled bool
+
+	// Stacktrace is true if at least one target, or `Logr.SetStacktraceLevels`,
+	// requests stack traces for the level. The stack is then captured when the
+	// log record is created and resolved to frames, available via
+	// `LogRec.StackFrames`, when the record is prepped. Frames are trimmed per
+	// `Logr.MaxStackDepth`, `Logr.StackExcludePrefixes` and `Logr.StackFrameFilter`.
+	Stacktrace bool
+
+	empty bool
+}
+
+type levelCache interface {
+	setup()
+	get(id LevelID) (LevelStatus, bool)
+	put(id LevelID, status LevelStatus) error
+	clear()
+}
+
+// syncMapLevelCache uses sync.Map which may better handle large concurrency
+// scenarios.
+type syncMapLevelCache struct {
+	m sync.Map
+}
+
+func (c *syncMapLevelCache) setup() {
+	c.clear()
+}
+
+func (c *syncMapLevelCache) get(id LevelID) (LevelStatus, bool) {
+	s, ok := c.m.Load(id)
+	if !ok {
+		return LevelStatus{}, false
+	}
+	status := s.(LevelStatus)
+	return status, !status.empty
+}
+
+func (c *syncMapLevelCache) put(id LevelID, status LevelStatus) error {
+	c.m.Store(id, status)
+	return nil
+}
+
+func (c *syncMapLevelCache) clear() {
+	c.m.Range(func(id, _ interface{}) bool {
+		if id.(LevelID) > MaxLevelID {
+			c.m.Delete(id)
+		}
+		return true
+	})
+	var i LevelID
+	for i = 0; i <= MaxLevelID; i++ {
+		c.m.Store(i, LevelStatus{empty: true})
+	}
+}
+
+// arrayLevelCache using array and a mutex. Level IDs up to MaxLevelID are
+// cached in the array; any higher IDs, e.g. registered via `RegisterLevel`,
+// fall back to a map, which is slightly slower.
+type arrayLevelCache struct {
+	arr      [MaxLevelID + 1]LevelStatus
+	overflow map[LevelID]LevelStatus
+	mux      sync.RWMutex
+}
+
+func (c *arrayLevelCache) setup() {
+	c.clear()
+}
+
+//var dummy = LevelStatus{}
+
+func (c *arrayLevelCache) get(id LevelID) (LevelStatus, bool) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	if id > MaxLevelID {
+		status, ok := c.overflow[id]
+		return status, ok
+	}
+	status := c.arr[id]
+	return status, !status.empty
+}
+
+func (c *arrayLevelCache) put(id LevelID, status LevelStatus) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if id > MaxLevelID {
+		if c.overflow == nil {
+			c.overflow = make(map[LevelID]LevelStatus)
+		}
+		c.overflow[id] = status
+		return nil
+	}
+	c.arr[id] = status
+	return nil
+}
+
+func (c *arrayLevelCache) clear() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for i := range c.arr {
+		c.arr[i] = LevelStatus{empty: true}
+	}
+	c.overflow = nil
+}
@@ -0,0 +1,124 @@
+package logr_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+var (
+	LoginLevel  = logr.Level{ID: 100, Name: "login ", Stacktrace: false}
+	LogoutLevel = logr.Level{ID: 101, Name: "logout", Stacktrace: false}
+	LargeLevel  = logr.Level{ID: logr.MaxLevelID + 1, Name: "large", Stacktrace: false}
+)
+
+func TestCustomLevel(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+
+	// create a custom filter with custom levels.
+	filter := &logr.CustomFilter{}
+	filter.Add(LoginLevel, LogoutLevel)
+
+	formatter := &format.Plain{Delim: " | "}
+	tgr := target.NewWriterTarget(filter, formatter, buf, 1000)
+	err := lgr.AddTarget(tgr)
+	if err != nil {
+		t.Error(err)
+	}
+
+	logger := lgr.NewLogger().WithFields(logr.Fields{"user": "Bob", "role": "admin"})
+
+	logger.Log(LoginLevel, "this item will get logged")
+	logger.Log(logr.Error, "XXX - won't be logged as Error was not added to custom filter.")
+	logger.Debug("XXX - won't be logged")
+	logger.Log(LogoutLevel, "will get logged")
+
+	err = lgr.Shutdown()
+	if err != nil {
+		t.Error(err)
+	}
+
+	output := buf.String()
+	fmt.Println(output)
+
+	if !strings.Contains(output, "will get logged") {
+		t.Error("missing levels")
+	}
+
+	if strings.Contains(output, "XXX") {
+		t.Error("wrong level(s) output")
+	}
+
+}
+
+func TestLevelIDAboveMax(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	var count int32
+
+	lgr.OnLoggerError = func(err error) {
+		atomic.AddInt32(&count, 1)
+	}
+
+	// create a custom filter with a level ID above MaxLevelID.
+	filter := &logr.CustomFilter{}
+	filter.Add(LargeLevel)
+
+	formatter := &format.Plain{Delim: " | "}
+	tgr := target.NewWriterTarget(filter, formatter, buf, 1000)
+	err := lgr.AddTarget(tgr)
+	if err != nil {
+		t.Error(err)
+	}
+
+	logger := lgr.NewLogger().WithFields(logr.Fields{"user": "Bob", "role": "admin"})
+
+	logger.Log(LargeLevel, "this item will get logged")
+
+	err = lgr.Shutdown()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if atomic.LoadInt32(&count) != 0 {
+		t.Error("OnLoggerError should not be called")
+	}
+	if !strings.Contains(buf.String(), "this item will get logged") {
+		t.Error("missing level above MaxLevelID")
+	}
+}
+
+func TestCustomFilterAddConflict(t *testing.T) {
+	filter := &logr.CustomFilter{}
+
+	// an unknown level is registered.
+	audit := logr.Level{ID: 300, Name: "audit-custom"}
+	filter.Add(audit)
+	if _, err := logr.RegisterLevel("audit-custom", 300); err != nil {
+		t.Errorf("expected the level registered by Add, got %v", err)
+	}
+
+	// levels conflicting with known ones are enabled but not registered.
+	renamed := logr.Level{ID: logr.Error.ID, Name: "oops"}
+	shadow := logr.Level{ID: 301, Name: "ERROR"}
+	filter.Add(renamed, shadow)
+	if !filter.IsEnabled(logr.Error) || !filter.IsEnabled(shadow) {
+		t.Error("expected conflicting levels enabled by the filter")
+	}
+	if _, err := logr.RegisterLevel("error", logr.Error.ID); err != nil {
+		t.Errorf("expected the error level unchanged, got %v", err)
+	}
+	if _, err := logr.RegisterLevel("oops", 302); err != nil {
+		t.Errorf("expected the name oops unregistered, got %v", err)
+	}
+	if _, err := logr.RegisterLevel("shadow-custom", 301); err != nil {
+		t.Errorf("expected the id 301 unregistered, got %v", err)
+	}
+}
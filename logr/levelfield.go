@@ -0,0 +1,32 @@
+package logr
+
+// levelValue is a field value only included in log records at or more
+// verbose than a minimum level.
+type levelValue struct {
+	lvl Level
+	val interface{}
+}
+
+// ForLevel returns a field that is only included in log records whose level is
+// at or more verbose than lvl, e.g. `logr.ForLevel(logr.Debug, "sql", query)` is
+// included in Debug and Trace records but omitted from Info records. This lets one
+// logging helper adapt its verbosity to the record level without branching.
+// Verbosity follows the standard level IDs, where higher IDs are more verbose.
+func ForLevel(lvl Level, key string, value interface{}) Fields {
+	return Fields{key: levelValue{lvl: lvl, val: value}}
+}
+
+// DebugOnly returns a field that is only included in Debug (and Trace) log records.
+// See `ForLevel`.
+func DebugOnly(key string, value interface{}) Fields {
+	return ForLevel(Debug, key, value)
+}
+
+// resolve returns the value if the log record level is at or more verbose
+// than the field's minimum level.
+func (lv levelValue) resolve(lvl Level, _ *Logr) (interface{}, bool) {
+	if lvl.ID < lv.lvl.ID {
+		return nil, false
+	}
+	return lv.val, true
+}
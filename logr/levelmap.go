@@ -0,0 +1,140 @@
+package logr
+
+import (
+	"sync"
+)
+
+// Level mapping schemes with built-in mappings.
+const (
+	// LevelSchemeSyslog maps to syslog severities (RFC 5424), e.g. 3 for error.
+	LevelSchemeSyslog = "syslog"
+
+	// LevelSchemeOTel maps to OpenTelemetry SeverityNumber, e.g. 17 for error.
+	LevelSchemeOTel = "otel"
+
+	// LevelSchemeGCP maps to Google Cloud Logging severity names, e.g. "ERROR".
+	LevelSchemeGCP = "gcp"
+
+	// LevelSchemeBunyan maps to Bunyan numeric levels, e.g. 50 for error.
+	LevelSchemeBunyan = "bunyan"
+)
+
+// levelMapping maps level IDs to values for one scheme.
+type levelMapping struct {
+	values map[LevelID]interface{}
+	def    interface{}
+}
+
+// levelMappings is the central registry used by formatters and targets that
+// need to translate Levels into another system's severities.
+var levelMappings = struct {
+	mux     sync.RWMutex
+	schemes map[string]*levelMapping
+}{
+	schemes: map[string]*levelMapping{
+		LevelSchemeSyslog: {
+			values: map[LevelID]interface{}{
+				Panic.ID: 2, Fatal.ID: 2, Error.ID: 3, Warn.ID: 4, Info.ID: 6, Debug.ID: 7, Trace.ID: 7,
+			},
+			def: 6,
+		},
+		LevelSchemeOTel: {
+			values: map[LevelID]interface{}{
+				Panic.ID: 24, Fatal.ID: 21, Error.ID: 17, Warn.ID: 13, Info.ID: 9, Debug.ID: 5, Trace.ID: 1,
+			},
+			def: 9,
+		},
+		LevelSchemeGCP: {
+			values: map[LevelID]interface{}{
+				Panic.ID: "EMERGENCY", Fatal.ID: "CRITICAL", Error.ID: "ERROR", Warn.ID: "WARNING",
+				Info.ID: "INFO", Debug.ID: "DEBUG", Trace.ID: "DEBUG",
+			},
+			def: "DEFAULT",
+		},
+		LevelSchemeBunyan: {
+			values: map[LevelID]interface{}{
+				Panic.ID: 60, Fatal.ID: 60, Error.ID: 50, Warn.ID: 40, Info.ID: 30, Debug.ID: 20, Trace.ID: 10,
+			},
+			def: 30,
+		},
+	},
+}
+
+// RegisterLevelMapping sets the value a level maps to within a scheme, overriding
+// any built-in mapping. New schemes are created as needed. This is typically used
+// to map custom levels, or to change how standard levels map.
+func RegisterLevelMapping(scheme string, lvl Level, value interface{}) {
+	levelMappings.mux.Lock()
+	defer levelMappings.mux.Unlock()
+	lm := levelMappings.schemes[scheme]
+	if lm == nil {
+		lm = &levelMapping{values: make(map[LevelID]interface{})}
+		levelMappings.schemes[scheme] = lm
+	}
+	lm.values[lvl.ID] = value
+}
+
+// SetLevelMappingDefault sets the value returned for levels without a mapping
+// within a scheme. New schemes are created as needed.
+func SetLevelMappingDefault(scheme string, value interface{}) {
+	levelMappings.mux.Lock()
+	defer levelMappings.mux.Unlock()
+	lm := levelMappings.schemes[scheme]
+	if lm == nil {
+		lm = &levelMapping{values: make(map[LevelID]interface{})}
+		levelMappings.schemes[scheme] = lm
+	}
+	lm.def = value
+}
+
+// MapLevel returns the value a level maps to within a scheme, such as
+// `LevelSchemeSyslog`. Levels without a mapping get the scheme's default.
+// Returns false if the scheme is unknown or has no mapping and no default.
+func MapLevel(lvl Level, scheme string) (interface{}, bool) {
+	levelMappings.mux.RLock()
+	defer levelMappings.mux.RUnlock()
+	lm := levelMappings.schemes[scheme]
+	if lm == nil {
+		return nil, false
+	}
+	if v, ok := lm.values[lvl.ID]; ok {
+		return v, true
+	}
+	return lm.def, lm.def != nil
+}
+
+// SyslogSeverity returns the syslog severity for a level.
+func SyslogSeverity(lvl Level) int {
+	v, _ := MapLevel(lvl, LevelSchemeSyslog)
+	if sev, ok := v.(int); ok {
+		return sev
+	}
+	return 6 // informational
+}
+
+// OTelSeverity returns the OpenTelemetry SeverityNumber for a level.
+func OTelSeverity(lvl Level) int {
+	v, _ := MapLevel(lvl, LevelSchemeOTel)
+	if sev, ok := v.(int); ok {
+		return sev
+	}
+	return 9 // INFO
+}
+
+// GCPSeverity returns the Google Cloud Logging severity name for a level.
+func GCPSeverity(lvl Level) string {
+	v, _ := MapLevel(lvl, LevelSchemeGCP)
+	if sev, ok := v.(string); ok {
+		return sev
+	}
+	return "DEFAULT"
+}
+
+// BunyanLevel returns the Bunyan numeric level for a level.
+func BunyanLevel(lvl Level) int {
+	v, _ := MapLevel(lvl, LevelSchemeBunyan)
+	if sev, ok := v.(int); ok {
+		return sev
+	}
+	return 30 // info
+}
@@ -0,0 +1,87 @@
+package logr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// levelRegistry tracks all levels known to this package, including the
+// standard levels and any custom levels added to a `CustomFilter`.
+var levelRegistry = struct {
+	mux    sync.RWMutex
+	levels map[LevelID]Level
+}{
+	levels: make(map[LevelID]Level),
+}
+
+func init() {
+	registerLevels(Panic, Fatal, Error, Warn, Info, Debug, Trace)
+}
+
+// RegisterLevel defines a custom level, e.g. "audit" or "security", making it
+// known to `Logr.Configure` and level-name lookups. Registering the same name
+// and ID again returns the existing level. An error is returned if the ID or
+// name, ignoring case, is already used by a different level. Any ID may be
+// used, however IDs above MaxLevelID are slightly slower to check. To output
+// a custom level, enable it via a `CustomFilter`.
+func RegisterLevel(name string, id LevelID) (Level, error) {
+	if name == "" {
+		return Level{}, errors.New("level name cannot be empty")
+	}
+
+	levelRegistry.mux.Lock()
+	defer levelRegistry.mux.Unlock()
+
+	if existing, ok := levelRegistry.levels[id]; ok {
+		if existing.Name == name {
+			return existing, nil
+		}
+		return Level{}, fmt.Errorf("level id %d already used by level %q", id, existing.Name)
+	}
+	for _, lvl := range levelRegistry.levels {
+		if strings.EqualFold(lvl.Name, name) {
+			return Level{}, fmt.Errorf("level name %q already used by level id %d", name, lvl.ID)
+		}
+	}
+
+	lvl := Level{ID: id, Name: name}
+	levelRegistry.levels[id] = lvl
+	return lvl, nil
+}
+
+// registerLevels adds one or more levels to the registry of known levels.
+func registerLevels(levels ...Level) {
+	levelRegistry.mux.Lock()
+	defer levelRegistry.mux.Unlock()
+	for _, lvl := range levels {
+		levelRegistry.levels[lvl.ID] = lvl
+	}
+}
+
+// knownLevels returns all registered levels sorted by ID.
+func knownLevels() []Level {
+	levelRegistry.mux.RLock()
+	defer levelRegistry.mux.RUnlock()
+
+	levels := make([]Level, 0, len(levelRegistry.levels))
+	for _, lvl := range levelRegistry.levels {
+		levels = append(levels, lvl)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].ID < levels[j].ID })
+	return levels
+}
+
+// levelByName returns the registered level with the name, ignoring case.
+func levelByName(name string) (Level, bool) {
+	levelRegistry.mux.RLock()
+	defer levelRegistry.mux.RUnlock()
+	for _, lvl := range levelRegistry.levels {
+		if strings.EqualFold(lvl.Name, name) {
+			return lvl, true
+		}
+	}
+	return Level{}, false
+}
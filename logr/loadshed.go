@@ -0,0 +1,88 @@
+package logr
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// loadShedMarks returns the high and low water marks, as queue lengths, for a
+// queue of the capacity. The high mark is at least one and the low mark is
+// always below it, so that shedding can both start and stop.
+func (logr *Logr) loadShedMarks(capacity int) (high int, low int) {
+	hw, lw := logr.LoadShedHighWater, logr.LoadShedLowWater
+	if hw <= 0 || hw > 1 {
+		hw = DefaultLoadShedHighWater
+	}
+	if lw <= 0 || lw >= hw {
+		lw = DefaultLoadShedLowWater
+		if lw >= hw {
+			lw = hw / 2
+		}
+	}
+
+	high = int(math.Ceil(float64(capacity) * hw))
+	if high < 1 {
+		high = 1
+	}
+	low = int(math.Floor(float64(capacity) * lw))
+	if low >= high {
+		low = high - 1
+	}
+	return high, low
+}
+
+// updateLoadShed starts or stops load shedding given the current queue
+// length, with hysteresis: shedding starts when the queue reaches the high
+// water mark and stops once it drains to the low water mark. Returns true
+// while shedding.
+func (logr *Logr) updateLoadShed(queueLen int, capacity int) bool {
+	high, low := logr.loadShedMarks(capacity)
+	if atomic.LoadInt32(&logr.loadShedding) == 1 {
+		if queueLen <= low && atomic.CompareAndSwapInt32(&logr.loadShedding, 1, 0) {
+			logr.notifyLoadShed(false)
+		}
+	} else if queueLen >= high && atomic.CompareAndSwapInt32(&logr.loadShedding, 0, 1) {
+		logr.notifyLoadShed(true)
+	}
+	return atomic.LoadInt32(&logr.loadShedding) == 1
+}
+
+// notifyLoadShed calls `OnLoadShed`, if any, when shedding starts or stops.
+func (logr *Logr) notifyLoadShed(shedding bool) {
+	if logr.OnLoadShed != nil {
+		logr.OnLoadShed(shedding)
+	}
+}
+
+// loadShed returns true, counting the record as dropped, if the log record
+// should be dropped because the Logr queue is overloaded and the record is
+// less severe than `LoadShedLevel`. Otherwise only the shedding state is
+// updated. Must be called with inMux read locked.
+func (logr *Logr) loadShed(rec *LogRec) bool {
+	if logr.LoadShedLevel.Name == "" || logr.in == nil {
+		return false
+	}
+	if !logr.updateLoadShed(len(logr.in), cap(logr.in)) || rec.level.ID <= logr.LoadShedLevel.ID {
+		return false
+	}
+	logr.stats.inc(statLoadShed)
+	return true
+}
+
+// checkLoadShed stops load shedding once the queue has drained to the low
+// water mark. Called by the queue goroutine so shedding stops even if no
+// more records are logged.
+func (logr *Logr) checkLoadShed() {
+	if atomic.LoadInt32(&logr.loadShedding) == 0 {
+		return
+	}
+	if in := logr.readQueue(); in != nil {
+		logr.updateLoadShed(len(in), cap(in))
+	}
+}
+
+// IsLoadShedding returns true while log records less severe than
+// `LoadShedLevel` are being dropped because the Logr queue is overloaded.
+func (logr *Logr) IsLoadShedding() bool {
+	return atomic.LoadInt32(&logr.loadShedding) == 1
+}
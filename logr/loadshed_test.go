@@ -0,0 +1,83 @@
+package logr_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+func TestLoadShed(t *testing.T) {
+	var mux sync.Mutex
+	var transitions []bool
+	lgr := &logr.Logr{
+		MaxQueueSize:      10,
+		EnqueueTimeout:    5 * time.Second,
+		LoadShedLevel:     logr.Warn,
+		LoadShedHighWater: 0.5,
+		LoadShedLowWater:  0.2,
+		OnLoadShed: func(shedding bool) {
+			mux.Lock()
+			defer mux.Unlock()
+			transitions = append(transitions, shedding)
+		},
+	}
+	bt := newBlockingTarget()
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+
+	// with the target blocked the queue reaches the high water mark, after
+	// which info records are shed rather than blocking.
+	for i := 0; i < 20; i++ {
+		logger.Info("shed")
+		time.Sleep(time.Millisecond)
+	}
+	if !lgr.IsLoadShedding() {
+		t.Fatal("expected load shedding once the queue reached the high water mark")
+	}
+	shed := lgr.Stats().LoadShed
+	if shed == 0 {
+		t.Error("expected records counted as shed")
+	}
+
+	// records at least as severe as LoadShedLevel are kept.
+	logger.Warn("kept")
+	logger.Error("kept")
+	if n := lgr.Stats().LoadShed; n != shed {
+		t.Errorf("expected warn and error not shed, shed count went from %d to %d", shed, n)
+	}
+
+	// shedding stops once the queue drains to the low water mark.
+	close(bt.release)
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if lgr.IsLoadShedding() {
+		t.Error("expected load shedding stopped once drained")
+	}
+	logger.Info("after")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	var kept, after int
+	for _, msg := range bt.msgs {
+		switch msg {
+		case "kept":
+			kept++
+		case "after":
+			after++
+		}
+	}
+	if kept != 2 || after != 1 {
+		t.Errorf("expected 2 kept and 1 after written, got %d and %d", kept, after)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(transitions) != 2 || !transitions[0] || transitions[1] {
+		t.Errorf("expected OnLoadShed(true) then OnLoadShed(false), got %v", transitions)
+	}
+}
@@ -0,0 +1,326 @@
+package logr
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Fields type, used to pass to `WithFields`.
+type Fields map[string]interface{}
+
+// Logger provides context for logging via fields.
+type Logger struct {
+	logr   *Logr
+	fields Fields
+	ttl    time.Duration
+
+	sampler    *durationSampler
+	sampleRate uint64
+	ctx        context.Context
+	countOnly  bool
+	tees       []Target
+	event      string
+	prefix     string
+}
+
+// Logr returns the `Logr` instance that created this `Logger`.
+func (logger Logger) Logr() *Logr {
+	return logger.logr
+}
+
+// WithField creates a new `Logger` with any existing fields
+// plus the new one.
+func (logger Logger) WithField(key string, value interface{}) Logger {
+	return logger.WithFields(Fields{key: value})
+}
+
+// WithFields creates a new `Logger` with any existing fields
+// plus the new ones.
+func (logger Logger) WithFields(fields Fields) Logger {
+	l := logger
+	// if parent has no fields then avoid creating a new map.
+	oldLen := len(logger.fields)
+	if oldLen == 0 {
+		l.fields = fields
+		return l
+	}
+
+	l.fields = make(Fields, len(fields)+oldLen)
+	for k, v := range logger.fields {
+		l.fields[k] = v
+	}
+	for k, v := range fields {
+		l.fields[k] = v
+	}
+	return l
+}
+
+// WithMap creates a new `Logger` with any existing fields plus the
+// entries of m, for fields from dynamic sources such as configuration or
+// external events. As with `WithFields`, entries replace existing fields
+// with the same key. The map is copied so later changes to m do not affect
+// the logger, and nested `map[string]interface{}` values are converted to
+// `Fields` so formatters output them as nested, key sorted groups.
+func (logger Logger) WithMap(m map[string]interface{}) Logger {
+	if len(m) == 0 {
+		return logger
+	}
+	return logger.WithFields(mapToFields(m))
+}
+
+// mapToFields copies a map into Fields, converting nested maps.
+func mapToFields(m map[string]interface{}) Fields {
+	flds := make(Fields, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			v = mapToFields(nested)
+		}
+		flds[k] = v
+	}
+	return flds
+}
+
+// WithTTL creates a new `Logger` whose log records expire if not
+// processed within ttl of being created. Expired records are dropped
+// rather than delivered to targets. Panic, Fatal and Error records
+// never expire. A zero ttl means use `Logr.RecordTTL`.
+func (logger Logger) WithTTL(ttl time.Duration) Logger {
+	l := logger
+	l.ttl = ttl
+	return l
+}
+
+// recordTTL returns the TTL for records created by this Logger.
+func (logger Logger) recordTTL() time.Duration {
+	if logger.ttl != 0 {
+		return logger.ttl
+	}
+	return logger.logr.RecordTTL
+}
+
+// Log checks that the level matches one or more targets, and
+// if so, generates a log record that is added to the Logr queue.
+// Arguments are handled in the manner of fmt.Print.
+func (logger Logger) Log(lvl Level, args ...interface{}) {
+	status := logger.levelStatus(lvl)
+	if status.Enabled {
+		logger, ok := logger.sample()
+		if !ok {
+			return
+		}
+		rec := NewLogRec(lvl, logger, "", args, status.Stacktrace)
+		logger.logr.enqueue(rec)
+	}
+}
+
+// TryLog is like `Log` but never blocks the caller: if the Logr queue is full
+// the log record is dropped, counted via `Logr.DroppedCount`, and false is
+// returned. `OnQueueFull` is not called. Returns true if the record was
+// queued or did not need to be, e.g. because the level is not enabled.
+func (logger Logger) TryLog(lvl Level, args ...interface{}) bool {
+	status := logger.levelStatus(lvl)
+	if !status.Enabled {
+		return true
+	}
+	logger, ok := logger.sample()
+	if !ok {
+		return true
+	}
+	rec := NewLogRec(lvl, logger, "", args, status.Stacktrace)
+	return logger.logr.tryEnqueue(rec)
+}
+
+// Trace is a convenience method equivalent to `Log(TraceLevel, args...)`.
+func (logger Logger) Trace(args ...interface{}) {
+	logger.Log(Trace, args...)
+}
+
+// Debug is a convenience method equivalent to `Log(DebugLevel, args...)`.
+func (logger Logger) Debug(args ...interface{}) {
+	logger.Log(Debug, args...)
+}
+
+// Print ensures compatibility with std lib logger.
+func (logger Logger) Print(args ...interface{}) {
+	logger.Info(args...)
+}
+
+// Info is a convenience method equivalent to `Log(InfoLevel, args...)`.
+func (logger Logger) Info(args ...interface{}) {
+	logger.Log(Info, args...)
+}
+
+// Warn is a convenience method equivalent to `Log(WarnLevel, args...)`.
+func (logger Logger) Warn(args ...interface{}) {
+	logger.Log(Warn, args...)
+}
+
+// Error is a convenience method equivalent to `Log(ErrorLevel, args...)`.
+func (logger Logger) Error(args ...interface{}) {
+	logger.Log(Error, args...)
+}
+
+// Fatal is a convenience method equivalent to `Log(FatalLevel, args...)`
+// followed by a call to os.Exit(1).
+func (logger Logger) Fatal(args ...interface{}) {
+	logger.Log(Fatal, args...)
+	logger.logr.exit(1)
+}
+
+// Panic is a convenience method equivalent to `Log(PanicLevel, args...)`
+// followed by a call to panic(). See `Logr.OnPanic`.
+func (logger Logger) Panic(args ...interface{}) {
+	logger.logPanic(fmt.Sprint(args...))
+}
+
+// RecoverAndLog recovers from a panic, if any, and logs the panic value
+// plus the stack captured at the point of recovery as structured fields.
+// It must be called directly via `defer logger.RecoverAndLog(lvl)`.
+// If lvl is `Panic` then, after logging, the panic is propagated via
+// `OnPanic` or, when `OnPanic` is nil, the Logr is shut down and the
+// panic is re-raised.
+func (logger Logger) RecoverAndLog(lvl Level) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	// capture the stack here, before any further unwinding.
+	stack := debug.Stack()
+
+	logger.WithFields(Fields{
+		FieldKeyPanic: r,
+		FieldKeyStack: string(stack),
+	}).Log(lvl, "recovered from panic")
+
+	if lvl.ID == Panic.ID {
+		logger.logr.panic(r)
+	}
+}
+
+//
+// Printf style
+//
+
+// Logf checks that the level matches one or more targets, and
+// if so, generates a log record that is added to the main
+// queue (channel). Arguments are handled in the manner of fmt.Printf.
+func (logger Logger) Logf(lvl Level, format string, args ...interface{}) {
+	status := logger.levelStatus(lvl)
+	if status.Enabled {
+		logger, ok := logger.sample()
+		if !ok {
+			return
+		}
+		rec := NewLogRec(lvl, logger, format, args, status.Stacktrace)
+		logger.logr.enqueue(rec)
+	}
+}
+
+// Tracef is a convenience method equivalent to `Logf(TraceLevel, args...)`.
+func (logger Logger) Tracef(format string, args ...interface{}) {
+	logger.Logf(Trace, format, args...)
+}
+
+// Debugf is a convenience method equivalent to `Logf(DebugLevel, args...)`.
+func (logger Logger) Debugf(format string, args ...interface{}) {
+	logger.Logf(Debug, format, args...)
+}
+
+// Infof is a convenience method equivalent to `Logf(InfoLevel, args...)`.
+func (logger Logger) Infof(format string, args ...interface{}) {
+	logger.Logf(Info, format, args...)
+}
+
+// Printf ensures compatibility with std lib logger.
+func (logger Logger) Printf(format string, args ...interface{}) {
+	logger.Infof(format, args...)
+}
+
+// Warnf is a convenience method equivalent to `Logf(WarnLevel, args...)`.
+func (logger Logger) Warnf(format string, args ...interface{}) {
+	logger.Logf(Warn, format, args...)
+}
+
+// Errorf is a convenience method equivalent to `Logf(ErrorLevel, args...)`.
+func (logger Logger) Errorf(format string, args ...interface{}) {
+	logger.Logf(Error, format, args...)
+}
+
+// Fatalf is a convenience method equivalent to `Logf(FatalLevel, args...)`
+// followed by a call to os.Exit(1).
+func (logger Logger) Fatalf(format string, args ...interface{}) {
+	logger.Logf(Fatal, format, args...)
+	logger.logr.exit(1)
+}
+
+// Panicf is a convenience method equivalent to `Logf(PanicLevel, args...)`
+// followed by a call to panic(). See `Logr.OnPanic`.
+func (logger Logger) Panicf(format string, args ...interface{}) {
+	logger.logPanic(fmt.Sprintf(format, args...))
+}
+
+//
+// Println style
+//
+
+// Logln checks that the level matches one or more targets, and
+// if so, generates a log record that is added to the main
+// queue (channel). Arguments are handled in the manner of fmt.Println.
+func (logger Logger) Logln(lvl Level, args ...interface{}) {
+	status := logger.levelStatus(lvl)
+	if status.Enabled {
+		logger, ok := logger.sample()
+		if !ok {
+			return
+		}
+		rec := NewLogRec(lvl, logger, "", args, status.Stacktrace)
+		rec.newline = true
+		logger.logr.enqueue(rec)
+	}
+}
+
+// Traceln is a convenience method equivalent to `Logln(TraceLevel, args...)`.
+func (logger Logger) Traceln(args ...interface{}) {
+	logger.Logln(Trace, args...)
+}
+
+// Debugln is a convenience method equivalent to `Logln(DebugLevel, args...)`.
+func (logger Logger) Debugln(args ...interface{}) {
+	logger.Logln(Debug, args...)
+}
+
+// Infoln is a convenience method equivalent to `Logln(InfoLevel, args...)`.
+func (logger Logger) Infoln(args ...interface{}) {
+	logger.Logln(Info, args...)
+}
+
+// Println ensures compatibility with std lib logger.
+func (logger Logger) Println(args ...interface{}) {
+	logger.Infoln(args...)
+}
+
+// Warnln is a convenience method equivalent to `Logln(WarnLevel, args...)`.
+func (logger Logger) Warnln(args ...interface{}) {
+	logger.Logln(Warn, args...)
+}
+
+// Errorln is a convenience method equivalent to `Logln(ErrorLevel, args...)`.
+func (logger Logger) Errorln(args ...interface{}) {
+	logger.Logln(Error, args...)
+}
+
+// Fatalln is a convenience method equivalent to `Logln(FatalLevel, args...)`
+// followed by a call to os.Exit(1).
+func (logger Logger) Fatalln(args ...interface{}) {
+	logger.Logln(Fatal, args...)
+	logger.logr.exit(1)
+}
+
+// Panicln is a convenience method equivalent to `Logln(PanicLevel, args...)`
+// followed by a call to panic(). See `Logr.OnPanic`.
+func (logger Logger) Panicln(args ...interface{}) {
+	msg := fmt.Sprintln(args...)
+	logger.logPanic(msg[:len(msg)-1])
+}
@@ -0,0 +1,57 @@
+package logr_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+func TestTryLogDropsWhenFull(t *testing.T) {
+	var onQueueFull int32
+	lgr := &logr.Logr{
+		MaxQueueSize:   2,
+		EnqueueTimeout: 5 * time.Second,
+		OnQueueFull: func(rec *logr.LogRec, stats logr.QueueFullStats) bool {
+			atomic.AddInt32(&onQueueFull, 1)
+			return true
+		},
+	}
+	bt := newBlockingTarget()
+	if err := lgr.AddTarget(bt); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+
+	// the blocked target wedges the queue, which then fills.
+	var queued, dropped uint64
+	for i := 0; i < 100 && dropped == 0; i++ {
+		if logger.TryLog(logr.Info, "try") {
+			queued++
+		} else {
+			dropped++
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if dropped == 0 {
+		t.Fatal("expected TryLog to drop once the queue is full")
+	}
+	if !logger.TryLog(logr.Debug, "not enabled") {
+		t.Error("expected TryLog to succeed for a level not enabled")
+	}
+	if n := lgr.DroppedCount(); n != dropped {
+		t.Errorf("expected %d dropped counted, got %d", dropped, n)
+	}
+	if n := atomic.LoadInt32(&onQueueFull); n != 0 {
+		t.Errorf("expected OnQueueFull not called, called %d times", n)
+	}
+
+	close(bt.release)
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if n := uint64(len(bt.msgs)); n != queued {
+		t.Errorf("expected the %d queued records written, got %d", queued, n)
+	}
+}
@@ -0,0 +1,1537 @@
+package logr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wiggin77/merror"
+)
+
+// Logr maintains a list of log targets and accepts incoming
+// log records.
+type Logr struct {
+	tmux    sync.RWMutex // target mutex
+	targets []Target
+
+	mux                sync.RWMutex
+	maxQueueSizeActual int
+	inMux              sync.RWMutex // guards replacing or closing `in`
+	in                 chan *LogRec
+	inRead             atomic.Value // chan *LogRec, `in` for the read loop, see `readQueue`
+	inClosed           bool
+	queuedMux          sync.Mutex
+	queued             map[*LogRec]struct{} // records sent or being sent to `in`, see `Dump`
+	done               chan struct{}
+	once               sync.Once
+	shutdown           bool
+	shuttingDown       int32 // atomic; 1 while Shutdown is running
+	flushing           int32 // atomic; 1 while Flush is running
+	lvlCache           levelCache
+	drops              dropCounter
+	enqueues           enqueueBarrier
+	hookMux            sync.RWMutex // guards `OnExit` and `OnPanic`
+	loadShedding       int32        // atomic; 1 while shedding, see `LoadShedLevel`
+	degraded           int32        // atomic; 1 once processing stopped, see `MaxRestarts`
+	restartMux         sync.Mutex
+	restarts           []time.Time // recent restarts of the processing goroutine
+	redactMux          sync.RWMutex
+	redactors          []Redactor
+
+	metricsOnce    sync.Once
+	metricsDone    chan struct{}
+	metricsExited  chan struct{}
+	metrics        MetricsCollector
+	queueSizeGauge Gauge
+	loggedCounter  Counter
+	errorCounter   Counter
+
+	latencyHistogram Histogram
+
+	bufferPool sync.Pool
+	bufTracker bufferTracker
+
+	stats         statCounters
+	targetTimings targetTimings
+	rateLimits    targetRateLimits
+
+	seq     uint64
+	reorder *reorderBuffer
+
+	supervisor atomic.Value // *Supervisor
+
+	syncMux sync.Mutex // serializes fanout by the queue goroutine, SyncMode and writePanic
+
+	configMux  sync.Mutex
+	configured map[string]configuredTarget
+
+	schemaVersion atomic.Value
+	clock         atomic.Value // func() time.Time, see `SetClock`
+	recFilter     atomic.Value // func(*LogRec) bool, see `SetFilter`
+	stackLevels   atomic.Value
+	eventCounts   eventCounts
+
+	ctxFieldsMux sync.Mutex
+	ctxFields    atomic.Value // []contextField
+
+	lastRecTime  time.Time
+	skewReporter *durationSampler
+
+	pendingMux     sync.Mutex
+	pending        []*LogRec
+	pendingDone    bool
+	pendingDropped int
+
+	// MaxQueueSize is the maximum number of log records that can be queued.
+	// If exceeded, `OnQueueFull` is called which determines if the log
+	// record will be dropped or block until add is successful.
+	// If this is modified, it must be done before `Configure` or
+	// `AddTarget`; afterward use `SetMaxQueueSize`. Defaults to DefaultMaxQueueSize.
+	MaxQueueSize int
+
+	// OnLoggerError, when not nil, is called any time an internal
+	// logging error occurs. For example, this can happen when a
+	// target cannot connect to its data sink.
+	OnLoggerError func(error)
+
+	// OnQueueFull, when not nil, is called on an attempt to add
+	// a log record to a full Logr queue. stats describes the queue and the
+	// records dropped so far, e.g. to drop more aggressively as drops increase.
+	// `SetMaxQueueSize` can be used to modify the maximum queue size.
+	// This function should return quickly, with a bool indicating whether
+	// the log record should be dropped (true) or block until the log record
+	// is successfully added (false). If nil then blocking (false) is assumed.
+	OnQueueFull func(rec *LogRec, stats QueueFullStats) bool
+
+	// OnTargetQueueFull, when not nil, is called on an attempt to add
+	// a log record to a full target queue provided the target supports reporting
+	// this condition.
+	// This function should return quickly, with a bool indicating whether
+	// the log record should be dropped (true) or block until the log record
+	// is successfully added (false). If nil then blocking (false) is assumed.
+	OnTargetQueueFull func(target Target, rec *LogRec, maxQueueSize int) bool
+
+	// OnTargetPanic, when not nil, is called when a target's `Log` panics while
+	// delivering a log record, e.g. to quarantine the record or remove the
+	// target. The record is still delivered to the other targets. It is called
+	// from the goroutine delivering log records, so must not block, log to
+	// this Logr, or modify rec.
+	OnTargetPanic func(target Target, rec *LogRec, r interface{})
+
+	// OnExit, when not nil, is called when a FatalXXX style log API is called.
+	// When nil, then the default behavior is to cleanly shut down this Logr and
+	// call `os.Exit(code)`. Assigning this field after `AddTarget` is unsafe;
+	// use `SetOnExit` instead.
+	OnExit func(code int)
+
+	// OnPanic, when not nil, is called when a PanicXXX style log API is called.
+	// When nil, then the default behavior is to write the panic log record,
+	// with the full goroutine stack, directly to the targets, cleanly shut down
+	// this Logr and call `panic(err)`. Assigning this field after `AddTarget`
+	// is unsafe; use `SetOnPanic` instead.
+	OnPanic func(err interface{})
+
+	// ConcurrentFanout, when true, delivers each log record to all enabled
+	// targets in parallel, waiting for all of them before the next record, so
+	// that a slow target, e.g. a network target whose queue is full, does not
+	// delay delivery to fast ones. Defaults to false, delivering to targets in turn.
+	//
+	// The same *LogRec is passed to every target at the same time, so targets
+	// must treat it as read-only, including the map returned by `LogRec.Fields`;
+	// use `LogRec.WithTime` to get a copy to modify or retain. The Logr never
+	// calls a target's `Log` concurrently with itself, and each target still
+	// receives records in order.
+	ConcurrentFanout bool
+
+	// PoolLogRecs, when true, reuses log records once the Logr and all targets
+	// are done with them, reducing allocations under high volume. Targets built
+	// on `Basic` whose `Write` retains records must use `AcquireLogRec`. Must be
+	// set before the first log record is created.
+	PoolLogRecs bool
+
+	// PanicSynchronousFlush, when true, flushes this Logr before the panic log
+	// record is written by the default PanicXXX behavior, so that records
+	// logged before it are written first. See `OnPanic`.
+	PanicSynchronousFlush bool
+
+	// PanicDump, when not nil, receives the log records still queued when the
+	// default PanicXXX behavior writes the panic log record, see `Dump`. The
+	// records are dumped before any flush, so they are captured even if a
+	// target is stuck.
+	PanicDump io.Writer
+
+	// LoadShedLevel, when set, enables load shedding: when the Logr queue
+	// reaches `LoadShedHighWater`, log records less severe than this level are
+	// dropped, counted via `Stats.LoadShed`, rather than blocking or calling
+	// `OnQueueFull`, until the queue drains to `LoadShedLowWater`. For example
+	// `Warn` keeps warnings and errors while dropping info and debug records.
+	// See `IsLoadShedding`.
+	LoadShedLevel Level
+
+	// LoadShedHighWater is the fraction of the Logr queue capacity, greater
+	// than zero and at most one, at which load shedding starts. Defaults to
+	// DefaultLoadShedHighWater.
+	LoadShedHighWater float64
+
+	// LoadShedLowWater is the fraction of the Logr queue capacity, greater
+	// than zero and less than `LoadShedHighWater`, at which load shedding
+	// stops. Defaults to DefaultLoadShedLowWater.
+	LoadShedLowWater float64
+
+	// OnLoadShed, when not nil, is called when load shedding starts and stops,
+	// e.g. to raise an alert. It is called while logging, so must not block or
+	// log to this Logr.
+	OnLoadShed func(shedding bool)
+
+	// MaxRestarts is the maximum number of times, within `RestartWindow`, the
+	// goroutine processing the Logr queue is restarted after a panic, e.g. a
+	// deferred field or redactor that always panics on some record. Once
+	// exceeded the Logr is degraded, see `IsDegraded`. Defaults to
+	// DefaultMaxRestarts.
+	MaxRestarts int
+
+	// RestartWindow is the period over which restarts are counted for
+	// `MaxRestarts`. Defaults to DefaultRestartWindow.
+	RestartWindow time.Duration
+
+	// BatchSize is the maximum number of log records written per call to
+	// `WriteBatch` for targets whose RecordWriter implements
+	// `RecordBatchWriter`. Defaults to DefaultBatchSize.
+	BatchSize int
+
+	// BatchInterval is the maximum time a log record waits in a partial batch
+	// before the batch is written. Defaults to DefaultBatchInterval.
+	BatchInterval time.Duration
+
+	// EnqueueTimeout is the amount of time a log record can take to be queued.
+	// This only applies to blocking enqueue which happen after `logr.OnQueueFull`
+	// is called and returns false.
+	EnqueueTimeout time.Duration
+
+	// ShutdownTimeout is the amount of time `logr.Shutdown` can execute before
+	// timing out.
+	ShutdownTimeout time.Duration
+
+	// FlushTimeout is the amount of time `logr.Flush` can execute before
+	// timing out.
+	FlushTimeout time.Duration
+
+	// SyncMode, when true, prepares and delivers each log record on the
+	// goroutine logging it, bypassing the Logr and target queues, so that a
+	// record has been written by every target built on `Basic` when the logging
+	// call returns. Records are then not lost if the process exits without
+	// calling `Shutdown`, and output is ordered relative to other output, e.g.
+	// stdout, which suits CLI tools and tests. Throughput drops since logging
+	// goroutines are serialized and wait for every target to write, and
+	// `Flush` and `Shutdown` have little left to do. Targets and `OnLoggerError`
+	// must not log to the same Logr. Must be set before the first target is added.
+	SyncMode bool
+
+	// StrictOrdering, when true, fans out log records to targets in the order of
+	// their sequence numbers (see `LogRec.Sequence`), which are assigned as records
+	// are enqueued. Records enqueued concurrently by many goroutines, or blocked on
+	// a full queue, can otherwise reach targets slightly out of sequence. Records
+	// are reordered within a buffer of up to `ReorderWindow` records, which costs
+	// memory for the buffered records and adds latency of up to `ReorderTimeout`
+	// while waiting for a missing record, e.g. one dropped by `OnQueueFull`.
+	// Targets that deliver asynchronously (e.g. spilling to another target) may
+	// still reorder records. Must be set before the first target is added.
+	StrictOrdering bool
+
+	// ReorderWindow is the maximum number of records buffered for reordering when
+	// `StrictOrdering` is true. Defaults to DefaultReorderWindow.
+	ReorderWindow int
+
+	// ReorderTimeout is the maximum time a record is held waiting for records with
+	// earlier sequence numbers when `StrictOrdering` is true. Defaults to
+	// DefaultReorderTimeout.
+	ReorderTimeout time.Duration
+
+	// UseSyncMapLevelCache can be set to true before the first target is added
+	// when high concurrency (e.g. >32 cores) is expected. This may improve
+	// performance with large numbers of cores - benchmark for your use case.
+	UseSyncMapLevelCache bool
+
+	// MaxPooledFormatBuffer determines the maximum size of a buffer that can be
+	// pooled. To reduce allocations, the buffers needed during formatting (etc)
+	// are pooled. A very large log item will grow a buffer that could stay in
+	// memory indefinitely. This settings lets you control how big a pooled buffer
+	// can be - anything larger will be garbage collected after use.
+	// Defaults to 1MB.
+	MaxPooledBuffer int
+
+	// DisableBufferPool when true disables the buffer pool. See MaxPooledBuffer.
+	DisableBufferPool bool
+
+	// DebugBuffers, when true, tracks buffers borrowed via `BorrowBuffer` and
+	// reports (via `ReportError`) buffers released twice, and outstanding
+	// buffers exceeding `BufferLeakThreshold`, which usually means a target is
+	// not releasing buffers. Intended for development of custom targets and
+	// formatters; tracking adds overhead so leave false in production.
+	DebugBuffers bool
+
+	// BufferLeakThreshold is the number of outstanding borrowed buffers that
+	// is reported when `DebugBuffers` is true. The report repeats each time the
+	// count doubles. Defaults to DefaultBufferLeakThreshold.
+	BufferLeakThreshold int
+
+	// MetricsUpdateFreqMillis determines how often polled metrics are updated
+	// when metrics are enabled.
+	MetricsUpdateFreqMillis int64
+
+	// LatencyBuckets are the upper bounds, in seconds, of the buckets of the
+	// latency histogram when the `MetricsCollector` implements
+	// `LatencyCollector`. Defaults to DefaultLatencyBuckets. Must be set before
+	// calling `SetMetricsCollector`.
+	LatencyBuckets []float64
+
+	// StatsInterval, when non-zero, logs this Logr's stats (see `Stats`) plus
+	// the queue size as the fields of a log record every interval, so that the
+	// counts can be charted from the log stream without a metrics backend.
+	// Leave zero, the default, to not log stats, e.g. when a `MetricsCollector`
+	// is used. Must be set before the first target is added.
+	StatsInterval time.Duration
+
+	// StatsLevel is the level of the log records created for `StatsInterval`.
+	// Defaults to Info.
+	StatsLevel Level
+
+	// NoTargetPolicy determines what happens to log records created before the
+	// first target is added. Defaults to NoTargetDrop. NoTargetBuffer can be used
+	// to capture early startup logging before configuration completes.
+	NoTargetPolicy NoTargetPolicy
+
+	// NoTargetBufferSize is the maximum number of log records buffered when
+	// `NoTargetPolicy` is NoTargetBuffer. Records beyond this limit are dropped.
+	// Defaults to DefaultNoTargetBufferSize.
+	NoTargetBufferSize int
+
+	// RedactKeys is a list of case-insensitive key patterns used to identify
+	// sensitive values that should be masked, e.g. when logging configuration
+	// via `Logger.LogSnapshot`. Defaults to DefaultRedactKeys when nil; set to
+	// an empty slice to disable key based redaction.
+	RedactKeys []string
+
+	// RecordTTL, when non-zero, is the default maximum age of a queued log record.
+	// Records still queued after this duration are dropped and counted rather than
+	// formatted and delivered, which sheds stale work when recovering from a backlog.
+	// Panic, Fatal and Error records never expire. See `Logger.WithTTL`.
+	RecordTTL time.Duration
+
+	// DetectClockSkew, when true, reports (via `ReportError`, rate limited) any log
+	// record whose timestamp goes backward relative to the previous record by more than
+	// `ClockSkewTolerance`. This catches misconfigured clocks and system time jumps.
+	DetectClockSkew bool
+
+	// ClampClockSkew, when true and `DetectClockSkew` is true, replaces the timestamp
+	// of any log record going backward, even within `ClockSkewTolerance`, with the
+	// previous record's timestamp so that record times are monotonic. See
+	// `LogRec.Time`.
+	ClampClockSkew bool
+
+	// ClockSkewTolerance is the amount of time a log record's timestamp can go backward
+	// before it is considered skewed. Records created concurrently may be queued slightly
+	// out of order. Defaults to DefaultClockSkewTolerance.
+	ClockSkewTolerance time.Duration
+
+	// BaggageExtractor, when not nil, is called with the context of any log record
+	// created via a Logger with a context (see `Logger.WithContext`) and returns
+	// baggage entries, such as OpenTelemetry baggage members, to be added as fields
+	// with the `BaggagePrefix` prefix. Return nil for no baggage.
+	BaggageExtractor func(ctx context.Context) map[string]string
+
+	// BaggageKeys is an allowlist of baggage keys added as fields. When empty all
+	// baggage entries returned by `BaggageExtractor` are added; since baggage can be
+	// high-cardinality an allowlist is recommended.
+	BaggageKeys []string
+
+	// ContextDeadlineField, when true, adds the time remaining until the deadline of
+	// a log record's context (see `Logger.WithContext`) under `FieldKeyDeadlineRemaining`,
+	// as of when the record was created. The value is negative when the deadline has
+	// passed. Contexts without a deadline add nothing.
+	ContextDeadlineField bool
+
+	// EnableCaller, when true, captures the source location (file, line and
+	// function) of each logging call, available to formatters via
+	// `LogRec.Caller`. Capturing is only done for log records at levels enabled
+	// by at least one target, but still costs a stack walk per record.
+	EnableCaller bool
+
+	// CallerSkip is the number of additional stack frames skipped when
+	// capturing the caller, e.g. 1 when logging via a wrapper function, so the
+	// wrapper's caller is reported. Frames within logr are always skipped.
+	CallerSkip int
+
+	// MaxStackDepth is the maximum number of stack frames kept for log records
+	// with stack traces, after leading logr frames and any excluded frames are
+	// removed. Defaults to DefaultMaxStackFrames.
+	MaxStackDepth int
+
+	// StackExcludePrefixes removes stack frames whose function name begins with
+	// any of the prefixes, e.g. "net/http." or "runtime.", to trim framework noise
+	// from stack traces.
+	StackExcludePrefixes []string
+
+	// StackFrameFilter, when not nil, is called for each stack frame not already
+	// removed via `StackExcludePrefixes` and returns false to remove the frame,
+	// e.g. for regular expression matching.
+	StackFrameFilter func(frame runtime.Frame) bool
+}
+
+// AddTarget adds a target to the logger which will receive
+// log records for outputting.
+func (logr *Logr) AddTarget(target Target) error {
+	err := logr.addTarget(target)
+	logr.replayPending()
+	return err
+}
+
+// AddTargetChecked validates the target, if it implements
+// `TargetWithValidate`, then adds it like `AddTarget`. If validation fails the
+// target is not added and the error is returned, so misconfigured targets can
+// be reported at startup. Targets that do not implement validation are added
+// as by `AddTarget`.
+func (logr *Logr) AddTargetChecked(target Target) error {
+	if err := ValidateOf(target); err != nil {
+		return err
+	}
+	return logr.AddTarget(target)
+}
+
+// addTarget adds a target while holding locks.
+func (logr *Logr) addTarget(target Target) error {
+	logr.mux.Lock()
+	defer logr.mux.Unlock()
+
+	if logr.shutdown {
+		return fmt.Errorf("logr shut down")
+	}
+
+	logr.tmux.Lock()
+	defer logr.tmux.Unlock()
+	logr.targets = append(logr.targets, target)
+
+	var err error
+	if logr.metrics != nil {
+		if tm, ok := target.(TargetWithMetrics); ok {
+			err = tm.EnableMetrics(logr.metrics, logr.MetricsUpdateFreqMillis)
+		}
+	}
+
+	logr.once.Do(func() {
+		logr.inMux.Lock()
+		logr.maxQueueSizeActual = queueSize(logr.MaxQueueSize)
+		logr.in = make(chan *LogRec, logr.maxQueueSizeActual)
+		logr.inRead.Store(logr.in)
+		logr.inMux.Unlock()
+		logr.done = make(chan struct{})
+		if logr.UseSyncMapLevelCache {
+			logr.lvlCache = &syncMapLevelCache{}
+		} else {
+			logr.lvlCache = &arrayLevelCache{}
+		}
+		if logr.MaxPooledBuffer == 0 {
+			logr.MaxPooledBuffer = DefaultMaxPooledBuffer
+		}
+		logr.bufferPool = sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		}
+		logr.lvlCache.setup()
+		if logr.StatsInterval > 0 {
+			logr.startMetricsOnce()
+		}
+		if logr.StrictOrdering {
+			logr.reorder = newReorderBuffer(logr.reorderWindow(), logr.reorderTimeout())
+			go logr.startOrdered()
+		} else {
+			go logr.start()
+		}
+	})
+	logr.resetLevelCache()
+	return err
+}
+
+// ErrTargetNotFound is returned by `RemoveTarget` when the target was not
+// added to the Logr.
+var ErrTargetNotFound = errors.New("target not found")
+
+// ErrShutdown is returned by `Flush` and `SetMaxQueueSize` once the Logr is
+// shut down.
+var ErrShutdown = errors.New("logr shut down")
+
+// RemoveTarget removes a target from the Logr and shuts it down, e.g. to
+// detach a network target whose sink is permanently unavailable. Log records
+// already queued are flushed to all targets first, and the target is then
+// shut down within `ShutdownTimeout`. It is safe to call while logging.
+// `ErrTargetNotFound` is returned if the target is not present. Targets
+// added via `Configure` are also forgotten by it.
+func (logr *Logr) RemoveTarget(target Target) error {
+	logr.configMux.Lock()
+	for name, ct := range logr.configured {
+		if ct.target == target {
+			delete(logr.configured, name)
+		}
+	}
+	logr.configMux.Unlock()
+
+	return logr.removeTarget(target)
+}
+
+// RemoveTargetByName removes the target with the name, see `NamedTarget`,
+// as with `RemoveTarget`. Targets that are unnamed or do not implement
+// `NamedTarget` are never matched. An error is returned, and no target is
+// removed, if more than one target has the name. `ErrTargetNotFound` is
+// returned if no target has the name.
+func (logr *Logr) RemoveTargetByName(name string) error {
+	var matches []Target
+	for _, t := range logr.Targets() {
+		if nt, ok := t.(NamedTarget); ok && name != "" && nt.Name() == name {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return ErrTargetNotFound
+	case 1:
+		return logr.RemoveTarget(matches[0])
+	default:
+		types := make([]string, 0, len(matches))
+		for _, t := range matches {
+			types = append(types, fmt.Sprintf("%T", t))
+		}
+		return fmt.Errorf("%d targets named %q: %s", len(matches), name, strings.Join(types, ", "))
+	}
+}
+
+// removeTarget removes a target and shuts it down. Queued log records are
+// flushed first so they reach the target before it is removed.
+func (logr *Logr) removeTarget(target Target) error {
+	if logr.targetIndex(target) == -1 {
+		return ErrTargetNotFound
+	}
+
+	errs := merror.New()
+	logr.mux.RLock()
+	shutdown := logr.shutdown
+	logr.mux.RUnlock()
+	if !shutdown {
+		errs.Append(logr.flushTargets(context.Background(), target))
+	}
+
+	logr.tmux.Lock()
+	idx := logr.indexOf(target)
+	if idx == -1 {
+		logr.tmux.Unlock()
+		return ErrTargetNotFound // removed concurrently
+	}
+	// copy so that slices held by concurrent readers are not modified.
+	targets := make([]Target, 0, len(logr.targets)-1)
+	targets = append(targets, logr.targets[:idx]...)
+	logr.targets = append(targets, logr.targets[idx+1:]...)
+	logr.tmux.Unlock()
+
+	logr.targetTimings.m.Delete(target)
+	logr.rateLimits.m.Delete(target)
+	logr.ResetLevelCache()
+
+	ctx, cancel := context.WithTimeout(context.Background(), logr.shutdownTimeout())
+	defer cancel()
+	errs.Append(target.Shutdown(ctx))
+	return errs.ErrorOrNil()
+}
+
+// targetIndex returns the index of the target, or -1 if not present.
+func (logr *Logr) targetIndex(target Target) int {
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+	return logr.indexOf(target)
+}
+
+// indexOf returns the index of the target, or -1 if not present. Caller must
+// hold tmux.
+func (logr *Logr) indexOf(target Target) int {
+	for i, t := range logr.targets {
+		if t == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetFormatter replaces the Formatter of all targets that implement
+// `FormatterSetter`, e.g. to switch all output between plain text and
+// JSON with a single setting. Targets that do not support replacing the
+// formatter are skipped and identified in the returned error.
+func (logr *Logr) SetFormatter(formatter Formatter) error {
+	logr.tmux.Lock()
+	defer logr.tmux.Unlock()
+
+	errs := merror.New()
+	for _, t := range logr.targets {
+		if fs, ok := t.(FormatterSetter); ok {
+			fs.SetFormatter(formatter)
+		} else {
+			errs.Append(fmt.Errorf("target %v does not support SetFormatter", t))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// TargetError is the most recent error reported by a target.
+type TargetError struct {
+	Err  error
+	Time time.Time
+}
+
+// Targets returns a copy of the list of targets attached to this Logr, e.g.
+// for displaying target status. The list is empty, but not nil, when no
+// targets are attached.
+func (logr *Logr) Targets() []Target {
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+	targets := make([]Target, len(logr.targets))
+	copy(targets, logr.targets)
+	return targets
+}
+
+// TargetErrors returns the most recent error for each target implementing
+// `TargetWithLastError`. Targets without errors are not included.
+func (logr *Logr) TargetErrors() map[Target]TargetError {
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+
+	errs := make(map[Target]TargetError)
+	for _, t := range logr.targets {
+		if tle, ok := t.(TargetWithLastError); ok {
+			if err, when := tle.LastError(); err != nil {
+				errs[t] = TargetError{Err: err, Time: when}
+			}
+		}
+	}
+	return errs
+}
+
+// NewLogger creates a Logger using defaults. A `Logger` is light-weight
+// enough to create on-demand, but typically one or more Loggers are
+// created and re-used.
+func (logr *Logr) NewLogger() Logger {
+	logger := Logger{logr: logr}
+	return logger
+}
+
+var levelStatusDisabled = LevelStatus{}
+
+// IsLevelEnabled returns true if at least one target has the specified
+// level enabled. The result is cached so that subsequent checks are fast.
+func (logr *Logr) IsLevelEnabled(lvl Level) LevelStatus {
+	// Check cache. lvlCache may still be nil if no targets added.
+	if logr.lvlCache == nil {
+		return logr.noTargetStatus()
+	}
+	status, ok := logr.lvlCache.get(lvl.ID)
+	if ok {
+		return status
+	}
+
+	logr.mux.RLock()
+	defer logr.mux.RUnlock()
+
+	// Don't accept new log records after shutdown.
+	if logr.shutdown {
+		return levelStatusDisabled
+	}
+
+	status = LevelStatus{}
+
+	// Check each target.
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+	for _, t := range logr.targets {
+		e, s := t.IsLevelEnabled(lvl)
+		if e {
+			status.Enabled = true
+			if s {
+				status.Stacktrace = true
+				break // if both enabled then no sense checking more targets
+			}
+		}
+	}
+
+	if status.Enabled && !status.Stacktrace && logr.isStacktraceForced(lvl) {
+		status.Stacktrace = true
+	}
+
+	// Cache and return the result.
+	if err := logr.lvlCache.put(lvl.ID, status); err != nil {
+		logr.ReportError(err)
+		return LevelStatus{}
+	}
+	return status
+}
+
+// HasTargets returns true only if at least one target exists within the Logr.
+func (logr *Logr) HasTargets() bool {
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+	return len(logr.targets) > 0
+}
+
+// IsFlushing returns true only while a call to `Flush` is in progress.
+func (logr *Logr) IsFlushing() bool {
+	return atomic.LoadInt32(&logr.flushing) == 1
+}
+
+// IsShuttingDown returns true only while a call to `Shutdown` is in progress.
+// Once shutdown completes this returns false and further calls to `Shutdown`
+// return an error.
+func (logr *Logr) IsShuttingDown() bool {
+	return atomic.LoadInt32(&logr.shuttingDown) == 1
+}
+
+// ResetLevelCache resets the cached results of `IsLevelEnabled`. This is
+// called any time a Target is added or a target's level is changed.
+func (logr *Logr) ResetLevelCache() {
+	// Write lock so that new cache entries cannot be stored while we
+	// clear the cache.
+	logr.mux.Lock()
+	defer logr.mux.Unlock()
+	logr.resetLevelCache()
+}
+
+// WarmLevelCache populates the level cache for all known levels, meaning the
+// standard levels plus any levels added to a `CustomFilter`. This moves the
+// cost of the first `IsLevelEnabled` check per level to startup instead of
+// the first logging call. It is idempotent and should be called after
+// targets are added, or after `ResetLevelCache`.
+func (logr *Logr) WarmLevelCache() {
+	for _, lvl := range knownLevels() {
+		logr.IsLevelEnabled(lvl)
+	}
+}
+
+// SetSchemaVersion sets the log schema version which is attached to every
+// log record under the reserved `FieldKeySchemaVersion` key, allowing
+// downstream parsers to branch on it. User fields cannot overwrite it.
+// This can be called at any time to bump the version; an empty string
+// stops the version from being attached.
+func (logr *Logr) SetSchemaVersion(version string) {
+	logr.schemaVersion.Store(version)
+}
+
+// SchemaVersion returns the current log schema version, or empty string if
+// none set.
+func (logr *Logr) SchemaVersion() string {
+	v, _ := logr.schemaVersion.Load().(string)
+	return v
+}
+
+// resetLevelCache empties the level cache without locking.
+// mux.Lock must be held before calling this function.
+func (logr *Logr) resetLevelCache() {
+	// lvlCache may still be nil if no targets added.
+	if logr.lvlCache != nil {
+		logr.lvlCache.clear()
+	}
+}
+
+// tryEnqueue adds a log record to the logr queue without blocking. Returns
+// false, counting the record as dropped, if the queue is full. `OnQueueFull`
+// is not called.
+func (logr *Logr) tryEnqueue(rec *LogRec) bool {
+	defer logr.enqueues.leave(logr.enqueues.enter())
+	if rec.seq == 0 {
+		rec.seq = atomic.AddUint64(&logr.seq, 1)
+	}
+	if logr.latencyHistogram != nil {
+		rec.enqueued = time.Now()
+	}
+	if logr.queue() == nil && logr.enqueueNoTarget(rec) {
+		return true
+	}
+	if logr.SyncMode {
+		logr.processSync(rec)
+		return true
+	}
+
+	logr.inMux.RLock()
+	if logr.inClosed {
+		logr.inMux.RUnlock()
+		logr.dropShutdown(rec)
+		return false
+	}
+	if logr.loadShed(rec) {
+		logr.inMux.RUnlock()
+		return true
+	}
+	logr.track(rec)
+	select {
+	case logr.in <- rec:
+		logr.inMux.RUnlock()
+		return true
+	default:
+	}
+	logr.inMux.RUnlock()
+	logr.untrack(rec)
+
+	logr.drops.inc(time.Now())
+	logr.stats.inc(statDropped)
+	return false
+}
+
+// enqueue adds a log record to the logr queue. If the queue is full then
+// this function either blocks or the log record is dropped, depending on
+// the result of calling `OnQueueFull`.
+func (logr *Logr) enqueue(rec *LogRec) {
+	if rec.flush == nil {
+		defer logr.enqueues.leave(logr.enqueues.enter())
+	}
+	if rec.seq == 0 && rec.flush == nil {
+		rec.seq = atomic.AddUint64(&logr.seq, 1)
+	}
+	if logr.latencyHistogram != nil && rec.flush == nil {
+		rec.enqueued = time.Now()
+	}
+	if logr.queue() == nil && logr.enqueueNoTarget(rec) {
+		return
+	}
+	if logr.SyncMode {
+		logr.processSync(rec)
+		return
+	}
+
+	// sends hold the read lock so `SetMaxQueueSize` cannot swap the queue
+	// out from under them. Callbacks are called without it.
+	if logr.IsDegraded() {
+		logr.dropDegraded(rec)
+		return
+	}
+
+	logr.inMux.RLock()
+	if logr.inClosed {
+		logr.inMux.RUnlock()
+		logr.dropShutdown(rec)
+		return
+	}
+	if rec.flush == nil && logr.loadShed(rec) {
+		logr.inMux.RUnlock()
+		return
+	}
+	logr.track(rec)
+	select {
+	case logr.in <- rec:
+		logr.inMux.RUnlock()
+		return
+	default:
+	}
+	maxQueueSize := logr.maxQueueSizeActual
+	queueLen := len(logr.in)
+	logr.inMux.RUnlock()
+	logr.untrack(rec)
+
+	if logr.OnQueueFull != nil {
+		now := time.Now()
+		stats := QueueFullStats{MaxQueueSize: maxQueueSize, QueueLen: queueLen}
+		stats.Dropped, stats.DroppedRecent = logr.drops.get(now)
+		if logr.OnQueueFull(rec, stats) {
+			logr.drops.inc(now)
+			logr.stats.inc(statDropped)
+			return // drop the record
+		}
+	}
+
+	logr.inMux.RLock()
+	if logr.inClosed {
+		logr.inMux.RUnlock()
+		logr.dropShutdown(rec)
+		return
+	}
+	var queued bool
+	logr.track(rec)
+	select {
+	case <-time.After(logr.enqueueTimeout()):
+	case logr.in <- rec: // block until success or timeout
+		queued = true
+	}
+	logr.inMux.RUnlock()
+	if !queued {
+		logr.untrack(rec)
+		logr.ReportError(fmt.Errorf("enqueue timed out for log rec [%v]", rec))
+	}
+}
+
+// dropShutdown drops a log record, or fails a flush, once the Logr queue
+// is closed by `Shutdown`.
+func (logr *Logr) dropShutdown(rec *LogRec) {
+	if rec.flush != nil {
+		rec.flushErr = ErrShutdown
+		rec.flush <- struct{}{}
+		return
+	}
+	logr.stats.inc(statDropped)
+}
+
+// queue returns the current Logr queue, or nil if no target has been added.
+func (logr *Logr) queue() chan *LogRec {
+	logr.inMux.RLock()
+	defer logr.inMux.RUnlock()
+	return logr.in
+}
+
+// readQueue returns the current Logr queue for the read loop. Unlike `queue`
+// it takes no lock: `SetMaxQueueSize` waits for senders blocked on a full
+// queue, so a read loop waiting behind it could never drain the queue.
+func (logr *Logr) readQueue() chan *LogRec {
+	in, _ := logr.inRead.Load().(chan *LogRec)
+	return in
+}
+
+// QueueLen returns the number of log records in the Logr queue, or zero if
+// no target has been added. Together with `QueueCap` this can be used to
+// decide, e.g. within `OnQueueFull`, how full the queue is.
+func (logr *Logr) QueueLen() int {
+	logr.inMux.RLock()
+	defer logr.inMux.RUnlock()
+	if logr.in == nil {
+		return 0
+	}
+	return len(logr.in)
+}
+
+// QueueCap returns the capacity of the Logr queue, or zero if no target has
+// been added. See `SetMaxQueueSize`.
+func (logr *Logr) QueueCap() int {
+	logr.inMux.RLock()
+	defer logr.inMux.RUnlock()
+	if logr.in == nil {
+		return 0
+	}
+	return cap(logr.in)
+}
+
+// queueSize converts a `MaxQueueSize` value to a channel capacity.
+func queueSize(n int) int {
+	if n == 0 {
+		return DefaultMaxQueueSize
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// SetMaxQueueSize changes the maximum number of log records that can be
+// queued. If no target has been added yet this just sets `MaxQueueSize`.
+// Otherwise a new queue is allocated and any queued log records are moved
+// to it in order, without dropping records. Zero means DefaultMaxQueueSize,
+// as for `MaxQueueSize`. Returns an error if n is smaller than the number of
+// log records currently queued, or if this Logr is shut down.
+func (logr *Logr) SetMaxQueueSize(n int) error {
+	logr.inMux.Lock()
+	defer logr.inMux.Unlock()
+
+	if logr.in == nil {
+		logr.MaxQueueSize = n
+		return nil
+	}
+	if logr.inClosed {
+		return ErrShutdown
+	}
+
+	size := queueSize(n)
+	if queued := len(logr.in); size < queued {
+		return fmt.Errorf("cannot shrink queue to %d; %d log records queued", size, queued)
+	}
+
+	// no sends can happen while the lock is held, and the read loop only
+	// removes records, so everything queued fits in the new queue.
+	in := make(chan *LogRec, size)
+drain:
+	for {
+		select {
+		case rec := <-logr.in:
+			in <- rec
+		default:
+			break drain
+		}
+	}
+
+	// closing the old queue tells the read loop to switch to the new one.
+	old := logr.in
+	logr.in = in
+	logr.inRead.Store(in)
+	close(old)
+
+	logr.maxQueueSizeActual = size
+	logr.MaxQueueSize = n
+	return nil
+}
+
+// SetOnExit replaces `OnExit`, e.g. so a test can intercept `os.Exit`. Safe
+// to call at any time, unlike assigning the field. A nil func restores the
+// default behavior.
+func (logr *Logr) SetOnExit(onExit func(code int)) {
+	logr.hookMux.Lock()
+	defer logr.hookMux.Unlock()
+	logr.OnExit = onExit
+}
+
+// SetOnPanic replaces `OnPanic`. Safe to call at any time, unlike assigning
+// the field. A nil func restores the default behavior.
+func (logr *Logr) SetOnPanic(onPanic func(err interface{})) {
+	logr.hookMux.Lock()
+	defer logr.hookMux.Unlock()
+	logr.OnPanic = onPanic
+}
+
+// onExit returns `OnExit`, which may be nil.
+func (logr *Logr) onExit() func(code int) {
+	logr.hookMux.RLock()
+	defer logr.hookMux.RUnlock()
+	return logr.OnExit
+}
+
+// onPanic returns `OnPanic`, which may be nil.
+func (logr *Logr) onPanic() func(err interface{}) {
+	logr.hookMux.RLock()
+	defer logr.hookMux.RUnlock()
+	return logr.OnPanic
+}
+
+// exit is called by one of the FatalXXX style APIS. If `logr.OnExit` is not nil
+// then that method is called, otherwise the default behavior is to shut down this
+// Logr cleanly then call `os.Exit(code)`.
+func (logr *Logr) exit(code int) {
+	if onExit := logr.onExit(); onExit != nil {
+		onExit(code)
+		return
+	}
+
+	if err := logr.Shutdown(); err != nil {
+		logr.ReportError(err)
+	}
+	os.Exit(code)
+}
+
+// panic is called by one of the PanicXXX style APIS. If `logr.OnPanic` is not nil
+// then that method is called, otherwise the default behavior is to shut down this
+// Logr cleanly then call `panic(err)`.
+func (logr *Logr) panic(err interface{}) {
+	if onPanic := logr.onPanic(); onPanic != nil {
+		onPanic(err)
+		return
+	}
+
+	if err := logr.Shutdown(); err != nil {
+		logr.ReportError(err)
+	}
+	panic(err)
+}
+
+// Flush blocks while flushing the logr queue and all target queues, by
+// writing existing log records to valid targets.
+// Any attempts to add new log records will block until flush is complete.
+// `logr.FlushTimeout` determines how long flush can execute before
+// timing out. Each target is flushed with its own timeout, so the returned
+// error is a merror identifying each target that timed out or failed to
+// flush. Use `IsTimeoutError` to determine if the returned error is due to
+// a timeout. Returns ErrShutdown once the Logr is shut down.
+func (logr *Logr) Flush() error {
+	return logr.FlushWithContext(context.Background())
+}
+
+// FlushWithContext is like `Flush` but bounded by ctx, e.g. an HTTP request
+// context when flushing before responding. `FlushTimeout` applies only if
+// ctx has no deadline. When ctx is done before the flush completes a timeout
+// error is returned; log records continue to be written in the background.
+func (logr *Logr) FlushWithContext(ctx context.Context) error {
+	if !logr.HasTargets() {
+		return nil
+	}
+	return logr.flushTargets(ctx, nil)
+}
+
+// FlushTarget blocks while flushing the logr queue and the queue of the
+// specified target, like `Flush` but waiting only for that target.
+// Returns ErrTargetNotFound if the target was not added to this Logr.
+func (logr *Logr) FlushTarget(target Target) error {
+	if logr.targetIndex(target) < 0 {
+		return ErrTargetNotFound
+	}
+	return logr.flushTargets(context.Background(), target)
+}
+
+// flushTargets flushes the logr queue then the queue of target, or of all
+// targets if nil, waiting for each target until ctx is done or, if ctx has
+// no deadline, `FlushTimeout` expires.
+func (logr *Logr) flushTargets(ctx context.Context, target Target) error {
+	logr.mux.Lock()
+	defer logr.mux.Unlock()
+
+	atomic.StoreInt32(&logr.flushing, 1)
+	defer atomic.StoreInt32(&logr.flushing, 0)
+
+	if logr.shutdown {
+		return ErrShutdown
+	}
+	if logr.IsDegraded() {
+		return ErrDegraded
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, logr.flushTimeout())
+		defer cancel()
+	}
+
+	// the flush record is queued behind all records already queued; queue it
+	// in the background so a full queue cannot block past ctx.
+	rec := newFlushLogRec(logr.NewLogger())
+	rec.flushTarget = target
+	go logr.enqueue(rec)
+
+	select {
+	case <-ctx.Done():
+		return newTimeoutError(fmt.Sprintf("logr queue flush timeout (%v)", ctx.Err()))
+	case <-rec.flush:
+	}
+	if rec.flushErr != nil {
+		return rec.flushErr
+	}
+
+	// the read loop has issued the target flushes; wait for each.
+	errs := merror.New()
+	for _, pf := range rec.flushPending {
+		if err := pf.wait(ctx); err != nil {
+			errs.Append(err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Shutdown cleanly stops the logging engine after making best efforts
+// to flush all targets. Call this function right before application
+// exit - logr cannot be restarted once shut down.
+// `logr.ShutdownTimeout` determines how long shutdown can execute before
+// timing out. Use `IsTimeoutError` to determine if the returned error is
+// due to a timeout.
+func (logr *Logr) Shutdown() error {
+	return logr.ShutdownWithContext(context.Background())
+}
+
+// ShutdownWithContext is like `Shutdown` but draining the queue and shutting
+// down targets is bounded by ctx, e.g. a context from an orchestrated
+// application shutdown. `ShutdownTimeout` applies only if ctx has no
+// deadline. Targets are shut down concurrently, each bounded by ctx. Every
+// target is shut down even when ctx is done, though targets may then not
+// finish writing queued records; the returned error, for which
+// `IsTimeoutError` is true, names each target that had not finished.
+func (logr *Logr) ShutdownWithContext(ctx context.Context) error {
+	logr.mux.Lock()
+	if logr.shutdown {
+		logr.mux.Unlock()
+		return errors.New("Shutdown called again after shut down")
+	}
+	logr.shutdown = true
+	atomic.StoreInt32(&logr.shuttingDown, 1)
+	defer atomic.StoreInt32(&logr.shuttingDown, 0)
+	logr.resetLevelCache()
+	metricsExited := logr.metricsExited
+	if logr.metricsDone != nil {
+		close(logr.metricsDone)
+		logr.metricsDone = nil
+	}
+	logr.mux.Unlock()
+
+	// wait for the metrics updater so it cannot poll or log during shutdown.
+	if metricsExited != nil {
+		<-metricsExited
+	}
+
+	errs := merror.New()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, logr.shutdownTimeout())
+		defer cancel()
+	}
+
+	// close the incoming channel and wait for read loop to exit.
+	logr.inMux.Lock()
+	in := logr.in
+	if in != nil {
+		close(in)
+		logr.inClosed = true
+	}
+	logr.inMux.Unlock()
+	if in != nil {
+		select {
+		case <-ctx.Done():
+			errs.Append(newTimeoutError("logr queue shutdown timeout"))
+		case <-logr.done:
+		}
+	}
+
+	// logr.in channel should now be drained to targets and no more log records
+	// can be added.
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+	results := make([]targetShutdown, len(logr.targets))
+	var wg sync.WaitGroup
+	for i, t := range logr.targets {
+		// shut down every target, even once ctx is done, so that files,
+		// connections and goroutines are released.
+		wg.Add(1)
+		go func(t Target, res *targetShutdown) {
+			defer wg.Done()
+			res.err = t.Shutdown(ctx)
+			res.complete = shutdownComplete(t, ctx)
+		}(t, &results[i])
+	}
+	wg.Wait()
+
+	for i, res := range results {
+		if res.err != nil {
+			errs.Append(res.err)
+		}
+		if !res.complete {
+			// the target gave up waiting for its queue to drain.
+			errs.Append(newTimeoutError(fmt.Sprintf("logr target %s shutdown incomplete: %v",
+				metricsName(logr.targets[i]), ctx.Err())))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// targetShutdown is the result of shutting down a target.
+type targetShutdown struct {
+	err      error
+	complete bool
+}
+
+// shutdownComplete returns true if a target whose `Shutdown` has returned
+// finished writing its queued log records. Targets embedding `Basic` are
+// asked; others are complete if Shutdown returned before ctx was done.
+func shutdownComplete(t Target, ctx context.Context) bool {
+	if c, ok := t.(interface{ shutdownComplete() bool }); ok {
+		return c.shutdownComplete()
+	}
+	return ctx.Err() == nil
+}
+
+// ReportError is used to notify the host application of any internal logging errors.
+// If `OnLoggerError` is not nil, it is called with the error, otherwise the error is
+// output to `os.Stderr`.
+func (logr *Logr) ReportError(err interface{}) {
+	logr.stats.inc(statErrors)
+	if logr.errorCounter != nil {
+		logr.errorCounter.Inc()
+	}
+	if logr.OnLoggerError == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	logr.OnLoggerError(fmt.Errorf("%v", err))
+}
+
+// BorrowBuffer borrows a buffer from the pool. Release the buffer to reduce garbage collection.
+func (logr *Logr) BorrowBuffer() *bytes.Buffer {
+	var buf *bytes.Buffer
+	if logr.DisableBufferPool {
+		buf = &bytes.Buffer{}
+	} else {
+		buf = logr.bufferPool.Get().(*bytes.Buffer)
+	}
+	if logr.DebugBuffers {
+		logr.trackBorrow(buf)
+	}
+	return buf
+}
+
+// ReleaseBuffer returns a buffer to the pool to reduce garbage collection. The buffer is only
+// retained if less than MaxPooledBuffer.
+func (logr *Logr) ReleaseBuffer(buf *bytes.Buffer) {
+	if logr.DebugBuffers && !logr.trackRelease(buf) {
+		return
+	}
+	if !logr.DisableBufferPool && buf.Cap() < logr.MaxPooledBuffer {
+		buf.Reset()
+		logr.bufferPool.Put(buf)
+	}
+}
+
+// enqueueTimeout returns amount of time a log record can take to be queued.
+// This only applies to blocking enqueue which happen after `logr.OnQueueFull` is called
+// and returns false.
+func (logr *Logr) enqueueTimeout() time.Duration {
+	if logr.EnqueueTimeout == 0 {
+		return DefaultEnqueueTimeout
+	}
+	return logr.EnqueueTimeout
+}
+
+// shutdownTimeout returns the timeout duration for `logr.Shutdown`.
+func (logr *Logr) shutdownTimeout() time.Duration {
+	if logr.ShutdownTimeout == 0 {
+		return DefaultShutdownTimeout
+	}
+	return logr.ShutdownTimeout
+}
+
+// flushTimeout returns the timeout duration for `logr.Flush`.
+func (logr *Logr) flushTimeout() time.Duration {
+	if logr.FlushTimeout == 0 {
+		return DefaultFlushTimeout
+	}
+	return logr.FlushTimeout
+}
+
+// start selects on incoming log records until done channel signals.
+// Incoming log records are fanned out to all log targets.
+func (logr *Logr) start() {
+	in := logr.readQueue()
+	defer func() {
+		if r := recover(); r != nil {
+			// the record being processed is dropped rather than re-processed,
+			// since it may panic again.
+			logr.stats.inc(statDropped)
+			logr.ReportError(r)
+			if logr.allowRestart(time.Now()) {
+				go logr.start()
+				return
+			}
+			logr.degrade(in)
+		}
+	}()
+
+	for {
+		for rec := range in {
+			logr.untrack(rec)
+			logr.processQueued(in, rec)
+		}
+		// the queue is closed by `Shutdown`, or replaced by `SetMaxQueueSize`.
+		if next := logr.readQueue(); next != in && next != nil {
+			in = next
+			continue
+		}
+		break
+	}
+	close(logr.done)
+}
+
+// processSync processes or flushes a log record immediately, bypassing the queue.
+func (logr *Logr) processSync(rec *LogRec) {
+	logr.processQueued(logr.queue(), rec)
+}
+
+// processQueued processes or flushes a log record read from the queue in.
+// `syncMux` is held so records are never fanned out concurrently, e.g. by
+// `writePanic`.
+func (logr *Logr) processQueued(in <-chan *LogRec, rec *LogRec) {
+	logr.withSyncMux(func() {
+		if rec.flush != nil {
+			logr.flush(in, rec)
+		} else {
+			logr.process(rec)
+		}
+	})
+}
+
+// withSyncMux calls f holding `syncMux`, releasing it even if f panics.
+func (logr *Logr) withSyncMux(f func()) {
+	logr.syncMux.Lock()
+	defer logr.syncMux.Unlock()
+	f()
+}
+
+// process preps a log record and fans it out to targets, unless expired,
+// then releases the pipeline's reference to it.
+func (logr *Logr) process(rec *LogRec) {
+	if !logr.dropIfExpired(rec) {
+		logr.checkClock(rec)
+		rec.prep()
+		if logr.filterRecord(rec) {
+			logr.fanout(rec)
+			logr.observeLatency(rec)
+		}
+	}
+	logr.checkLoadShed()
+	ReleaseLogRec(rec)
+}
+
+// dropIfExpired returns true, and counts the record as expired, if the record
+// has a deadline which has passed.
+func (logr *Logr) dropIfExpired(rec *LogRec) bool {
+	if rec.expires.IsZero() || !rec.isExpired(logr.Now()) {
+		return false
+	}
+	logr.stats.inc(statExpired)
+	return true
+}
+
+// ExpiredCount returns the number of log records dropped because they were still
+// queued after their TTL elapsed. See `RecordTTL`.
+func (logr *Logr) ExpiredCount() uint64 {
+	return logr.stats.get(statExpired)
+}
+
+// startMetricsUpdater updates the metrics for any polled values every `MetricsUpdateFreqSecs` seconds,
+// and logs stats every `StatsInterval` if set, until logr is closed.
+func (logr *Logr) startMetricsUpdater(done <-chan struct{}, exited chan<- struct{}) {
+	defer close(exited)
+	nextStats := time.Now().Add(logr.StatsInterval)
+	for {
+		updateFreq := logr.MetricsUpdateFreqMillis
+		if updateFreq == 0 {
+			updateFreq = DefMetricsUpdateFreqMillis
+		}
+		if updateFreq < 250 {
+			updateFreq = 250 // don't peg the CPU
+		}
+		wait := time.Duration(updateFreq) * time.Millisecond
+		if logr.StatsInterval > 0 {
+			if untilStats := time.Until(nextStats); untilStats < wait {
+				wait = untilStats
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-time.After(wait):
+			if logr.queueSizeGauge != nil {
+				logr.queueSizeGauge.Set(float64(logr.QueueLen()))
+			}
+			logr.tmux.RLock()
+			UpdateQueueMetricsOf(logr.targets...)
+			logr.tmux.RUnlock()
+			if logr.StatsInterval > 0 && !time.Now().Before(nextStats) {
+				logr.logStats()
+				nextStats = time.Now().Add(logr.StatsInterval)
+			}
+		}
+	}
+}
+
+// fanout pushes a LogRec to all targets.
+func (logr *Logr) fanout(rec *LogRec) {
+	if rec.countOnly {
+		logr.countRecord(rec)
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logr.ReportError(fmt.Errorf("fanout failed, %v", r))
+		}
+	}()
+
+	var logged bool
+
+	logr.tmux.RLock()
+	defer logr.tmux.RUnlock()
+	sup := logr.failedOver()
+	if sup != nil {
+		sup.forward(rec)
+	}
+	switch {
+	case sup != nil && !sup.sw.Probe():
+		// forwarded to the standby only; probe records also reach the targets.
+	case logr.ConcurrentFanout:
+		logged = logr.fanoutConcurrent(rec)
+	default:
+		for _, target := range logr.targets {
+			if enabled, _ := target.IsLevelEnabled(rec.Level()); enabled && logr.allowTarget(target) {
+				retainFor(target, rec)
+				if logr.logSafe(target, rec) {
+					logged = true
+				}
+			}
+		}
+	}
+	if len(rec.logger.tees) > 0 && fanoutTees(rec) {
+		logged = true
+	}
+
+	if logged {
+		logr.stats.inc(statLogged)
+		if logr.loggedCounter != nil {
+			logr.loggedCounter.Inc()
+		}
+	}
+}
+
+// flush drains the queue, issues a flush to the targets requested by
+// flushRec and notifies when done. The queue is passed in by the read loop
+// since `SetMaxQueueSize` may be swapping it.
+func (logr *Logr) flush(in <-chan *LogRec, flushRec *LogRec) {
+	// first drain the records currently in the logr queue. The drain is bounded
+	// so that a flush completes even while loggers keep the queue busy.
+loop:
+	for n := len(in); n > 0; n-- {
+		var rec *LogRec
+		select {
+		case rec = <-in:
+			logr.untrack(rec)
+			if rec.flush == nil {
+				if logr.reorder != nil {
+					logr.reorder.push(rec, logr.process)
+				} else {
+					logr.process(rec)
+				}
+			}
+		default:
+			break loop
+		}
+	}
+	if logr.reorder != nil {
+		logr.reorder.release(logr.process, true)
+	}
+
+	logger := logr.NewLogger()
+
+	// issue a flush to each target; the caller waits for them.
+	logr.tmux.RLock()
+	pending := make([]pendingFlush, 0, len(logr.targets))
+	for _, target := range logr.targets {
+		if flushRec.flushTarget != nil && target != flushRec.flushTarget {
+			continue
+		}
+		rec := newFlushLogRec(logger)
+		target.Log(rec)
+		pending = append(pending, pendingFlush{target: target, rec: rec})
+	}
+	logr.tmux.RUnlock()
+
+	flushRec.flushPending = pending
+	flushRec.flush <- struct{}{}
+}
+
+// pendingFlush is a flush issued to a target by `flush`.
+type pendingFlush struct {
+	target Target
+	rec    *LogRec
+}
+
+// wait blocks until the target signals the flush is complete or ctx is done,
+// returning a timeout error or any error the target reported.
+func (pf pendingFlush) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return newTimeoutError(fmt.Sprintf("target %v flush timeout", pf.target))
+	case <-pf.rec.flush:
+	}
+	if pf.rec.flushErr != nil {
+		return fmt.Errorf("target %v flush failed: %w", pf.target, pf.rec.flushErr)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package logr_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/test"
+)
+
+func TestFlush(t *testing.T) {
+	buf := &bytes.Buffer{}
+	formatter := &format.Plain{DisableTimestamp: true, Delim: " | "}
+	filter := &logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Error}
+	target := test.NewSlowTarget(filter, formatter, buf, 3000)
+	target.Delay = time.Millisecond * 2
+	lgr := &logr.Logr{}
+	err := lgr.AddTarget(target)
+	if err != nil {
+		t.Error(err)
+	}
+
+	cfg := test.DoSomeLoggingCfg{
+		Lgr:        lgr,
+		Goroutines: 20,
+		Loops:      100,
+		Lvl:        logr.Error,
+	}
+	test.DoSomeLogging(cfg)
+	logger := lgr.NewLogger()
+	logger.Info("Last entry @!!@")
+
+	start := time.Now()
+
+	// blocks until flush is finished.
+	err = lgr.Flush()
+	if err != nil {
+		t.Error(err)
+	}
+
+	dur := time.Since(start)
+	t.Logf("Flush duration: %v", dur)
+
+	output := buf.String()
+	if !strings.Contains(output, "@!!@") {
+		t.Errorf("missing last log record")
+	}
+
+	// make sure logging can continue after flush.
+	test.DoSomeLogging(cfg)
+	logger.Info("Last entry %^^%")
+
+	// blocks until flush is finished.
+	err = lgr.Flush()
+	if err != nil {
+		t.Error(err)
+	}
+
+	output = buf.String()
+	if !strings.Contains(output, "%^^%") {
+		t.Errorf("missing last log record")
+	}
+}
+
+func TestWarmLevelCache(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	formatter := &format.Plain{DisableTimestamp: true, Delim: " | "}
+	if err := lgr.AddTarget(test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Warn}, formatter, buf, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	// warming twice changes nothing.
+	lgr.WarmLevelCache()
+	lgr.WarmLevelCache()
+	if !lgr.IsLevelEnabled(logr.Error).Enabled || lgr.IsLevelEnabled(logr.Debug).Enabled {
+		t.Error("wrong levels enabled after warming")
+	}
+
+	// adding a target resets the cache; warming again picks up its levels.
+	if err := lgr.AddTarget(test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Debug}, formatter, buf, 100)); err != nil {
+		t.Fatal(err)
+	}
+	lgr.WarmLevelCache()
+	if !lgr.IsLevelEnabled(logr.Debug).Enabled || lgr.IsLevelEnabled(logr.Trace).Enabled {
+		t.Error("wrong levels enabled after adding a target")
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFlushAfterShutdown(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	formatter := &format.Plain{DisableTimestamp: true, Delim: " | "}
+	if err := lgr.AddTarget(test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Info}, formatter, buf, 100)); err != nil {
+		t.Fatal(err)
+	}
+	group := &logr.FlushGroup{}
+	_ = group.Add("lgr", lgr)
+
+	lgr.NewLogger().Info("before shutdown")
+	if err := group.ShutdownAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lgr.Flush(); !errors.Is(err, logr.ErrShutdown) {
+		t.Errorf("expected ErrShutdown from Flush, got %v", err)
+	}
+	if err := lgr.FlushWithContext(context.Background()); !errors.Is(err, logr.ErrShutdown) {
+		t.Errorf("expected ErrShutdown from FlushWithContext, got %v", err)
+	}
+	if err := lgr.FlushAll(); !errors.Is(err, logr.ErrShutdown) {
+		t.Errorf("expected ErrShutdown from FlushAll, got %v", err)
+	}
+	var ge *logr.GroupError
+	if err := group.FlushAll(context.Background()); !errors.As(err, &ge) || !errors.Is(ge.Errors["lgr"], logr.ErrShutdown) {
+		t.Errorf("expected ErrShutdown from FlushGroup.FlushAll, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "before shutdown") {
+		t.Error("missing log record written before shutdown")
+	}
+}
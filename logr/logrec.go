@@ -0,0 +1,329 @@
+package logr
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	logrPkg string
+)
+
+func init() {
+	// Calc current package name
+	logrPkg = reflect.TypeOf(LogRec{}).PkgPath()
+}
+
+// LogRec collects raw, unformatted data to be logged.
+// Log records can be pooled, see `Logr.PoolLogRecs` and `AcquireLogRec`.
+type LogRec struct {
+	mux  sync.RWMutex
+	time time.Time
+
+	// replaces time when clamped by `Logr.ClampClockSkew`; set before fanout.
+	clampedTime time.Time
+
+	level  Level
+	logger Logger
+	ctx    context.Context
+
+	template string
+	newline  bool
+	args     []interface{}
+
+	stackPC     []uintptr
+	stackCount  int
+	stackForced bool
+	callerPC    []uintptr
+
+	seq uint64
+
+	// record is dropped instead of delivered if dequeued after this time.
+	expires time.Time
+
+	// fields owned by logr which user fields cannot overwrite.
+	reserved Fields
+
+	// counted but not output to targets.
+	countOnly bool
+
+	// when enqueued, if latency is being collected.
+	enqueued time.Time
+
+	// pooling; see `AcquireLogRec`.
+	pooled bool  // from the pool, returned when no references remain
+	refs   int32 // atomic; references held by the Logr and targets
+	pinned int32 // atomic; 1 if delivered to a target that may retain it
+
+	// flushes Logr and target queues when not nil.
+	flush        chan struct{}
+	flushTarget  Target         // only this target is flushed when not nil
+	flushPending []pendingFlush // target flushes issued, set before signaling flush
+	flushErr     error          // set by a target before signaling flush
+
+	// remaining fields calculated by `prep`
+	msg    string
+	frames []runtime.Frame
+	caller runtime.Frame
+	fields Fields
+}
+
+// NewLogRec creates a new LogRec with the current time, per `Logr.SetClock`,
+// and optional stack trace.
+func NewLogRec(lvl Level, logger Logger, template string, args []interface{}, incStacktrace bool) *LogRec {
+	var rec *LogRec
+	if logger.logr != nil && logger.logr.PoolLogRecs {
+		rec = newPooledLogRec()
+	} else {
+		rec = &LogRec{}
+	}
+	rec.time = logger.logr.Now()
+	rec.logger = logger
+	rec.level = lvl
+	rec.template = template
+	rec.args = args
+	rec.ctx = logger.ctx
+	rec.countOnly = logger.countOnly
+	if ttl := logger.recordTTL(); ttl > 0 && lvl.ID > Error.ID {
+		rec.expires = rec.time.Add(ttl)
+	}
+	rec.reserved = logger.reservedFields()
+	rec.callerPC = logger.logr.captureCaller()
+	if incStacktrace {
+		rec.stackForced = logger.logr.isStacktraceForced(lvl)
+		rec.stackPC = make([]uintptr, logger.logr.stackCaptureSize())
+		rec.stackCount = runtime.Callers(2, rec.stackPC)
+	}
+	return rec
+}
+
+// newFlushLogRec creates a LogRec that flushes the Logr queue and
+// any target queues that support flushing.
+func newFlushLogRec(logger Logger) *LogRec {
+	// buffered so targets can signal completion from within `Log`.
+	return &LogRec{logger: logger, flush: make(chan struct{}, 1)}
+}
+
+// prep resolves all args and field values to strings, and
+// resolves stack trace to frames.
+func (rec *LogRec) prep() {
+	rec.mux.Lock()
+	defer rec.mux.Unlock()
+
+	// resolve args
+	if rec.template == "" {
+		if rec.newline {
+			rec.msg = fmt.Sprintln(rec.args...)
+		} else {
+			rec.msg = fmt.Sprint(rec.args...)
+		}
+	} else {
+		rec.msg = fmt.Sprintf(rec.template, rec.args...)
+	}
+	if prefix := rec.logger.prefix; prefix != "" {
+		rec.msg = prefix + " " + rec.msg
+	}
+
+	// resolve fields
+	rec.fields = rec.logger.fields
+	if lgr := rec.logger.logr; lgr != nil {
+		rec.fields = mergeFields(rec.fields, lgr.contextFields(rec.ctx, rec.Time()))
+	}
+	rec.fields = resolveDeferredFields(rec.fields, rec.level, rec.logger.logr)
+	if lgr := rec.logger.logr; lgr != nil {
+		rec.fields = lgr.redactFields(rec.fields)
+	}
+
+	// resolve caller
+	if len(rec.callerPC) > 0 {
+		rec.caller, _ = rec.logger.logr.resolveCaller(rec.callerPC)
+	}
+
+	// resolve stack trace
+	if rec.stackCount > 0 {
+		frames := runtime.CallersFrames(rec.stackPC[:rec.stackCount])
+		for {
+			f, more := frames.Next()
+			rec.frames = append(rec.frames, f)
+			if !more {
+				break
+			}
+		}
+
+		// remove leading logr package entries.
+		var start int
+		for i, frame := range rec.frames {
+			pkg := getPackageName(frame.Function)
+			if pkg != "" && pkg != logrPkg {
+				start = i
+				break
+			}
+		}
+		rec.frames = rec.logger.logr.trimFrames(rec.frames[start:])
+	}
+}
+
+// WithTime returns a shallow copy of the log record while replacing
+// the time. This can be used by targets and formatters to adjust
+// the time, or take ownership of the log record.
+func (rec *LogRec) WithTime(time time.Time) *LogRec {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+
+	return &LogRec{
+		time:        time,
+		level:       rec.level,
+		logger:      rec.logger,
+		ctx:         rec.ctx,
+		template:    rec.template,
+		newline:     rec.newline,
+		args:        rec.args,
+		msg:         rec.msg,
+		stackPC:     rec.stackPC,
+		stackCount:  rec.stackCount,
+		stackForced: rec.stackForced,
+		callerPC:    rec.callerPC,
+		seq:         rec.seq,
+		frames:      rec.frames,
+		caller:      rec.caller,
+		fields:      rec.fields,
+		expires:     rec.expires,
+		reserved:    rec.reserved,
+		countOnly:   rec.countOnly,
+	}
+}
+
+// WithFields returns a shallow copy of the log record with the fields
+// added, replacing any with the same keys. This can be used by targets that
+// wrap other targets to annotate log records.
+func (rec *LogRec) WithFields(fields Fields) *LogRec {
+	r := rec.WithTime(rec.Time())
+	r.fields = mergeFields(rec.Fields(), fields)
+	return r
+}
+
+// WithMessage returns a shallow copy of the log record with the message and
+// fields replaced and no stack trace. This can be used by targets that wrap
+// other targets to deliver summary log records, such as a count of records
+// suppressed, at the level of the records summarized.
+func (rec *LogRec) WithMessage(msg string, fields Fields) *LogRec {
+	r := rec.WithTime(rec.Time())
+	r.template = ""
+	r.newline = false
+	r.args = nil
+	r.msg = msg
+	r.fields = fields
+	r.stackPC = nil
+	r.stackCount = 0
+	r.stackForced = false
+	r.frames = nil
+	return r
+}
+
+// isExpired returns true if this log record has a deadline which has passed.
+func (rec *LogRec) isExpired(now time.Time) bool {
+	// no locking needed as this field is not mutated.
+	return !rec.expires.IsZero() && now.After(rec.expires)
+}
+
+// IsFlush returns true if this log record is a flush marker rather than a
+// record to be output. Targets that wrap other targets must pass flush
+// markers to `ForwardFlush`; other targets can ignore this.
+func (rec *LogRec) IsFlush() bool {
+	return rec.flush != nil
+}
+
+// Logger returns the `Logger` that created this `LogRec`.
+func (rec *LogRec) Logger() Logger {
+	return rec.logger
+}
+
+// Time returns this log record's time stamp, as adjusted by
+// `Logr.ClampClockSkew` if clamped.
+func (rec *LogRec) Time() time.Time {
+	// no locking needed as these fields are not mutated once fanned out.
+	if !rec.clampedTime.IsZero() {
+		return rec.clampedTime
+	}
+	return rec.time
+}
+
+// Level returns this log record's Level.
+func (rec *LogRec) Level() Level {
+	// no locking needed as this field is not mutated.
+	return rec.level
+}
+
+// Fields returns this log record's Fields. After the log record is
+// prepped this includes any fields extracted from the context.
+func (rec *LogRec) Fields() Fields {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+	if rec.fields != nil {
+		return rec.fields
+	}
+	return rec.logger.fields
+}
+
+// ReservedFields returns fields set by Logr, such as the schema version
+// or event type, which formatters should render under their reserved keys.
+// User fields with the same keys should be renamed via `ProtectFields`.
+func (rec *LogRec) ReservedFields() Fields {
+	// no locking needed as this field is not mutated.
+	return rec.reserved
+}
+
+// SchemaVersion returns the schema version in effect when this log record
+// was created, or empty string if none.
+func (rec *LogRec) SchemaVersion() string {
+	v, _ := rec.reserved[FieldKeySchemaVersion].(string)
+	return v
+}
+
+// Msg returns this log record's message text.
+func (rec *LogRec) Msg() string {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+	return rec.msg
+}
+
+// StackFrames returns this log record's stack frames or
+// nil if no stack trace was required.
+func (rec *LogRec) StackFrames() []runtime.Frame {
+	rec.mux.RLock()
+	defer rec.mux.RUnlock()
+	return rec.frames
+}
+
+// String returns a string representation of this log record.
+func (rec *LogRec) String() string {
+	if rec.flush != nil {
+		return "[flusher]"
+	}
+
+	f := &DefaultFormatter{}
+	buf := rec.logger.logr.BorrowBuffer()
+	defer rec.logger.logr.ReleaseBuffer(buf)
+	buf, _ = f.Format(rec, true, buf)
+	return strings.TrimSpace(buf.String())
+}
+
+// getPackageName reduces a fully qualified function name to the package name
+// By sirupsen: https://github.com/sirupsen/logrus/blob/master/entry.go
+func getPackageName(f string) string {
+	for {
+		lastPeriod := strings.LastIndex(f, ".")
+		lastSlash := strings.LastIndex(f, "/")
+		if lastPeriod > lastSlash {
+			f = f[:lastPeriod]
+		} else {
+			break
+		}
+	}
+	return f
+}
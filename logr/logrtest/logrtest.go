@@ -0,0 +1,170 @@
+// Package logrtest provides a target that captures log records so tests can
+// assert what code under test logged.
+package logrtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+)
+
+// Target captures log records for assertions in tests. Assertion helpers
+// flush the Logr first, so records logged before the call are included.
+type Target struct {
+	logr.Basic
+	t   testing.TB
+	lgr *logr.Logr
+
+	mux  sync.Mutex
+	recs []*logr.LogRec
+}
+
+// NewTarget creates a target capturing the log records enabled by filter and
+// adds it to lgr. A nil filter captures all standard levels.
+func NewTarget(t testing.TB, lgr *logr.Logr, filter logr.Filter) (*Target, error) {
+	if filter == nil {
+		filter = &logr.StdFilter{Lvl: logr.Trace}
+	}
+	tt := &Target{t: t, lgr: lgr}
+	tt.Basic.Start(tt, tt, filter, nil, logr.DefaultMaxQueueSize)
+	if err := lgr.AddTarget(tt); err != nil {
+		_ = tt.Basic.Shutdown(context.Background())
+		return nil, err
+	}
+	return tt, nil
+}
+
+// NewTestLogger creates a Logr capturing all standard levels, returning a
+// Logger and the capturing target. Errors within the Logr are reported via
+// `t.Log`, and the Logr is shut down when the test completes.
+func NewTestLogger(t testing.TB) (logr.Logger, *Target) {
+	t.Helper()
+
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {
+		t.Logf("logr error: %v", err)
+	}
+
+	tt, err := NewTarget(t, lgr, nil)
+	if err != nil {
+		t.Fatalf("cannot add test target: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := lgr.Shutdown(); err != nil {
+			t.Errorf("logr shutdown: %v", err)
+		}
+	})
+	return lgr.NewLogger(), tt
+}
+
+// Write captures a copy of the log record.
+func (tt *Target) Write(rec *logr.LogRec) error {
+	rec = rec.WithTime(rec.Time())
+
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	tt.recs = append(tt.recs, rec)
+	return nil
+}
+
+// Records returns the captured log records, oldest first.
+func (tt *Target) Records() []*logr.LogRec {
+	tt.flush()
+
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	recs := make([]*logr.LogRec, len(tt.recs))
+	copy(recs, tt.recs)
+	return recs
+}
+
+// Reset discards the captured log records.
+func (tt *Target) Reset() {
+	tt.flush()
+
+	tt.mux.Lock()
+	defer tt.mux.Unlock()
+	tt.recs = nil
+}
+
+// Count returns the number of captured log records of the level.
+func (tt *Target) Count(lvl logr.Level) int {
+	var count int
+	for _, rec := range tt.Records() {
+		if rec.Level().ID == lvl.ID {
+			count++
+		}
+	}
+	return count
+}
+
+// Logged returns true if a log record of the level was captured whose
+// message contains substr.
+func (tt *Target) Logged(lvl logr.Level, substr string) bool {
+	for _, rec := range tt.Records() {
+		if rec.Level().ID == lvl.ID && strings.Contains(rec.Msg(), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssertLogged fails the test, listing the captured log records, unless a log
+// record of the level was captured whose message contains substr.
+func (tt *Target) AssertLogged(lvl logr.Level, substr string) bool {
+	tt.t.Helper()
+	if tt.Logged(lvl, substr) {
+		return true
+	}
+	tt.t.Errorf("expected %s log record containing %q; captured:\n%s", lvl.Name, substr, tt.dump())
+	return false
+}
+
+// AssertNotLogged fails the test, listing the captured log records, if a log
+// record of the level was captured whose message contains substr.
+func (tt *Target) AssertNotLogged(lvl logr.Level, substr string) bool {
+	tt.t.Helper()
+	if !tt.Logged(lvl, substr) {
+		return true
+	}
+	tt.t.Errorf("unexpected %s log record containing %q; captured:\n%s", lvl.Name, substr, tt.dump())
+	return false
+}
+
+// AssertCount fails the test unless exactly n log records of the level were captured.
+func (tt *Target) AssertCount(lvl logr.Level, n int) bool {
+	tt.t.Helper()
+	if count := tt.Count(lvl); count != n {
+		tt.t.Errorf("expected %d %s log records, got %d; captured:\n%s", n, lvl.Name, count, tt.dump())
+		return false
+	}
+	return true
+}
+
+// dump lists the captured log records, one per line.
+func (tt *Target) dump() string {
+	recs := tt.Records()
+	if len(recs) == 0 {
+		return "  (none)"
+	}
+	var sb strings.Builder
+	for _, rec := range recs {
+		fmt.Fprintf(&sb, "  %s: %s", rec.Level().Name, rec.Msg())
+		if fields := rec.Fields(); len(fields) > 0 {
+			fmt.Fprintf(&sb, " %v", fields)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// flush waits for log records queued within the Logr to be captured.
+func (tt *Target) flush() {
+	if err := tt.lgr.FlushTarget(tt); err != nil {
+		tt.t.Logf("logr flush: %v", err)
+	}
+}
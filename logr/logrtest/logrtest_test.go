@@ -0,0 +1,144 @@
+package logrtest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/logrtest"
+)
+
+// fakeTB records the failures reported by the assertion helpers instead of
+// failing the test.
+type fakeTB struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func TestCapture(t *testing.T) {
+	logger, tt := logrtest.NewTestLogger(t)
+
+	logger.Trace("trace record")
+	logger.WithField("user", "sam").Info("info record")
+	logger.Error("error one")
+	logger.Error("error two")
+
+	recs := tt.Records()
+	if len(recs) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(recs))
+	}
+	if recs[0].Msg() != "trace record" || recs[3].Msg() != "error two" {
+		t.Errorf("expected records oldest first, got %q ... %q", recs[0].Msg(), recs[3].Msg())
+	}
+	if recs[1].Fields()["user"] != "sam" {
+		t.Errorf("expected fields captured, got %v", recs[1].Fields())
+	}
+	if n := tt.Count(logr.Error); n != 2 {
+		t.Errorf("expected 2 error records, got %d", n)
+	}
+	if !tt.Logged(logr.Info, "info") || tt.Logged(logr.Warn, "info") {
+		t.Error("expected Logged to match level and message")
+	}
+
+	tt.Reset()
+	if n := len(tt.Records()); n != 0 {
+		t.Errorf("expected no records after Reset, got %d", n)
+	}
+	logger.Info("after reset")
+	if !tt.Logged(logr.Info, "after reset") {
+		t.Error("expected records captured after Reset")
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	logger, tt := logrtest.NewTestLogger(t)
+	logger.Warn("disk almost full")
+	logger.WithField("path", "/tmp").Error("disk full")
+
+	// passing assertions report nothing.
+	if !tt.AssertLogged(logr.Error, "disk full") || !tt.AssertNotLogged(logr.Info, "disk") || !tt.AssertCount(logr.Warn, 1) {
+		t.Error("expected the assertions to pass")
+	}
+
+	// failing assertions report the captured records, leaving t untouched.
+	ftb := &fakeTB{TB: t}
+	failing, ft := logrtest.NewTestLogger(ftb)
+	failing.Warn("disk almost full")
+	failing.WithField("path", "/tmp").Error("disk full")
+
+	if ft.AssertLogged(logr.Info, "disk full") {
+		t.Error("expected AssertLogged to fail for the wrong level")
+	}
+	if ft.AssertNotLogged(logr.Error, "full") {
+		t.Error("expected AssertNotLogged to fail")
+	}
+	if ft.AssertCount(logr.Error, 2) {
+		t.Error("expected AssertCount to fail")
+	}
+	if len(ftb.errs) != 3 {
+		t.Fatalf("expected 3 failures reported, got %v", ftb.errs)
+	}
+	for _, msg := range ftb.errs {
+		if !strings.Contains(msg, "  warn: disk almost full") || !strings.Contains(msg, "  error: disk full map[path:/tmp]") {
+			t.Errorf("expected the captured records listed, got %q", msg)
+		}
+	}
+	if !strings.Contains(ftb.errs[2], "expected 2 error log records, got 1") {
+		t.Errorf("unexpected AssertCount failure %q", ftb.errs[2])
+	}
+}
+
+func TestAssertionsNoRecords(t *testing.T) {
+	ftb := &fakeTB{TB: t}
+	_, tt := logrtest.NewTestLogger(ftb)
+
+	tt.AssertLogged(logr.Info, "anything")
+	if len(ftb.errs) != 1 || !strings.Contains(ftb.errs[0], "(none)") {
+		t.Errorf("expected the failure to show no records captured, got %v", ftb.errs)
+	}
+}
+
+func TestNewTargetFilter(t *testing.T) {
+	lgr := &logr.Logr{PoolLogRecs: true}
+	defer lgr.Shutdown()
+	tt, err := logrtest.NewTarget(t, lgr, &logr.StdFilter{Lvl: logr.Warn})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 100; i++ {
+		logger.Info("filtered")
+		logger.Error(fmt.Sprintf("error %d", i))
+	}
+
+	// only enabled levels are captured, and copies survive record reuse.
+	recs := tt.Records()
+	if len(recs) != 100 {
+		t.Fatalf("expected 100 records, got %d", len(recs))
+	}
+	for i, rec := range recs {
+		if want := fmt.Sprintf("error %d", i); rec.Msg() != want {
+			t.Errorf("expected %q, got %q", want, rec.Msg())
+		}
+	}
+}
+
+func TestNewTestLoggerShutdownOnCleanup(t *testing.T) {
+	var lgr *logr.Logr
+	t.Run("capture", func(t *testing.T) {
+		logger, _ := logrtest.NewTestLogger(t)
+		lgr = logger.Logr()
+	})
+	if err := lgr.Flush(); !errors.Is(err, logr.ErrShutdown) {
+		t.Errorf("expected the Logr shut down once the test completed, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package logr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wiggin77/merror"
+)
+
+const (
+	DefMetricsUpdateFreqMillis = 15000 // 15 seconds
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency
+// histogram buckets when `Logr.LatencyBuckets` is empty.
+var DefaultLatencyBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5}
+
+// Counter is a simple metrics sink that can only increment a value.
+// Implementations are external to Logr and provided via `MetricsCollector`.
+type Counter interface {
+	// Inc increments the counter by 1. Use Add to increment it by arbitrary non-negative values.
+	Inc()
+	// Add adds the given value to the counter. It panics if the value is < 0.
+	Add(float64)
+}
+
+// Gauge is a simple metrics sink that can receive values and increase or decrease.
+// Implementations are external to Logr and provided via `MetricsCollector`.
+type Gauge interface {
+	// Set sets the Gauge to an arbitrary value.
+	Set(float64)
+	// Add adds the given value to the Gauge. (The value can be negative, resulting in a decrease of the Gauge.)
+	Add(float64)
+	// Sub subtracts the given value from the Gauge. (The value can be negative, resulting in an increase of the Gauge.)
+	Sub(float64)
+}
+
+// MetricsCollector provides a way for users of this Logr package to have metrics pushed
+// in an efficient way to any backend, e.g. Prometheus.
+// For each target added to Logr, the supplied MetricsCollector will provide a Gauge
+// and Counters that will be called frequently as logging occurs.
+type MetricsCollector interface {
+	// QueueSizeGauge returns a Gauge that will be updated by the named target.
+	QueueSizeGauge(target string) (Gauge, error)
+	// LoggedCounter returns a Counter that will be incremented by the named target.
+	LoggedCounter(target string) (Counter, error)
+	// ErrorCounter returns a Counter that will be incremented by the named target.
+	ErrorCounter(target string) (Counter, error)
+	// DroppedCounter returns a Counter that will be incremented by the named target.
+	DroppedCounter(target string) (Counter, error)
+	// BlockedCounter returns a Counter that will be incremented by the named target.
+	BlockedCounter(target string) (Counter, error)
+}
+
+// Histogram is a metrics sink that counts observed values in buckets.
+// Implementations are external to Logr and provided via `LatencyCollector`.
+type Histogram interface {
+	// Observe adds a single observation to the histogram.
+	Observe(float64)
+}
+
+// LatencyCollector is an optional interface a `MetricsCollector` can implement
+// to receive the latency of each log record, in seconds, from when it is
+// enqueued to when the last target's `Log` returns. For targets built on
+// `Basic`, `Log` returns once the record is queued for the target, so the
+// latency includes time spent in the Logr queue and delivering to targets
+// but not the time targets take to write.
+type LatencyCollector interface {
+	// LatencyHistogram returns a Histogram with the bucket upper bounds, in
+	// seconds, that will observe the latency of log records.
+	LatencyHistogram(buckets []float64) (Histogram, error)
+}
+
+// TargetWithMetrics is a target that provides metrics.
+type TargetWithMetrics interface {
+	EnableMetrics(collector MetricsCollector, updateFreqMillis int64) error
+}
+
+// TargetWithQueueMetrics is a target with a queue whose size is polled by the
+// Logr metrics updater, along with the Logr queue size, every
+// `MetricsUpdateFreqMillis`. Targets wrapping other targets should forward
+// the call via `UpdateQueueMetricsOf`.
+type TargetWithQueueMetrics interface {
+	// UpdateQueueMetrics sets the queue size Gauge provided by `EnableMetrics`.
+	UpdateQueueMetrics()
+}
+
+// UpdateQueueMetricsOf updates the queue metrics of each target that
+// implements `TargetWithQueueMetrics`.
+func UpdateQueueMetricsOf(targets ...Target) {
+	for _, t := range targets {
+		if tq, ok := t.(TargetWithQueueMetrics); ok {
+			tq.UpdateQueueMetrics()
+		}
+	}
+}
+
+// latencyBuckets returns the latency histogram buckets, or the defaults.
+func (logr *Logr) latencyBuckets() []float64 {
+	if len(logr.LatencyBuckets) == 0 {
+		return DefaultLatencyBuckets
+	}
+	return logr.LatencyBuckets
+}
+
+// observeLatency reports the time since the log record was enqueued, if
+// latency is being collected.
+func (logr *Logr) observeLatency(rec *LogRec) {
+	if logr.latencyHistogram != nil && !rec.enqueued.IsZero() {
+		logr.latencyHistogram.Observe(time.Since(rec.enqueued).Seconds())
+	}
+}
+
+// metricsName returns the name used to label a target's metrics: the name
+// provided via `SetName` for a `NamedTarget`, otherwise the target's string
+// representation.
+func metricsName(target Target) string {
+	if nt, ok := target.(NamedTarget); ok && nt.Name() != "" {
+		return nt.Name()
+	}
+	return fmt.Sprintf("%v", target)
+}
+
+// startMetricsOnce starts the metrics updater, if not already started.
+func (logr *Logr) startMetricsOnce() {
+	logr.metricsOnce.Do(func() {
+		logr.metricsDone = make(chan struct{})
+		logr.metricsExited = make(chan struct{})
+		go logr.startMetricsUpdater(logr.metricsDone, logr.metricsExited)
+	})
+}
+
+// SetMetricsCollector enables metrics collection by supplying a MetricsCollector.
+// The MetricsCollector provides counters and gauges that are updated by log targets.
+func (logr *Logr) SetMetricsCollector(collector MetricsCollector) error {
+	if collector == nil {
+		return errors.New("collector cannot be nil")
+	}
+
+	logr.metrics = collector
+	logr.queueSizeGauge, _ = collector.QueueSizeGauge("_logr")
+	logr.loggedCounter, _ = collector.LoggedCounter("_logr")
+	logr.errorCounter, _ = collector.ErrorCounter("_logr")
+	if lc, ok := collector.(LatencyCollector); ok {
+		logr.latencyHistogram, _ = lc.LatencyHistogram(logr.latencyBuckets())
+	}
+
+	logr.startMetricsOnce()
+
+	merr := merror.New()
+
+	logr.tmux.Lock()
+	defer logr.tmux.Unlock()
+	for _, target := range logr.targets {
+		if tm, ok := target.(TargetWithMetrics); ok {
+			if err := tm.EnableMetrics(logr.metrics, logr.MetricsUpdateFreqMillis); err != nil {
+				merr.Append(err)
+			}
+		}
+
+	}
+	return merr.ErrorOrNil()
+}
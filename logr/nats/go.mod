@@ -0,0 +1,11 @@
+module github.com/mattermost/logr/nats
+
+go 1.12
+
+replace github.com/mattermost/logr => ../
+
+require (
+	github.com/mattermost/logr v0.0.0-00010101000000-000000000000
+	github.com/nats-io/nats.go v1.31.0
+	github.com/wiggin77/merror v1.0.2
+)
@@ -0,0 +1,281 @@
+// Package nats provides a target that publishes log records to a NATS subject
+// using the NATS Go client. It is a separate module so that Logr itself does
+// not depend on the NATS client. Importing it registers the "nats" target type
+// with `logr.Configure`.
+package nats
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/wiggin77/merror"
+)
+
+const (
+	// DefaultDialTimeout is the timeout for connecting to a NATS server when
+	// `Options.DialTimeout` is zero.
+	DefaultDialTimeout = time.Second * 5
+
+	// DefaultFlushTimeout is the time allowed for the server to confirm
+	// published records when the target is flushed, when
+	// `Options.FlushTimeout` is zero.
+	DefaultFlushTimeout = time.Second * 5
+)
+
+func init() {
+	logr.RegisterTargetType("nats", func(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+		var opts Options
+		if len(options) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(options))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&opts); err != nil {
+				return nil, err
+			}
+		}
+		return NewTarget(filter, formatter, opts, maxQueue)
+	})
+}
+
+// Options configures a NATS target.
+type Options struct {
+	// Address is the NATS server URL, e.g. "nats://localhost:4222", or a comma
+	// separated list of server URLs. A "tls://" URL requires TLS.
+	Address string
+
+	// Subject is the subject log records are published to.
+	Subject string
+
+	// Name is an optional client name reported to the server.
+	Name string
+
+	// User and Password, or Token, authenticate with the server.
+	User     string
+	Password string
+	Token    string
+
+	// TLSConfig, when not nil, connects using TLS.
+	TLSConfig *tls.Config
+
+	// DialTimeout is the timeout for connecting. Defaults to DefaultDialTimeout.
+	DialTimeout time.Duration
+
+	// FlushTimeout is the time allowed for the server to confirm published
+	// records when the target is flushed. Defaults to DefaultFlushTimeout.
+	FlushTimeout time.Duration
+
+	// Backoff determines the delay between reconnect attempts.
+	Backoff logr.Backoff
+
+	// ReconnectBufSize is the maximum number of bytes of log records buffered
+	// while reconnecting. Records that do not fit are counted as errors. Zero
+	// uses the client default of 8MB; -1 disables buffering.
+	ReconnectBufSize int
+}
+
+// Target publishes log records to a NATS subject. Each formatted record is one
+// message, so a JSON formatter is recommended. Lost connections are
+// re-established by the NATS client, which buffers records while reconnecting,
+// up to `Options.ReconnectBufSize`.
+//
+// Records that cannot be published, including those rejected by the server
+// after publishing, are counted by the target's error counter when metrics are
+// enabled.
+type Target struct {
+	logr.Basic
+	opts Options
+	conn *natsgo.Conn
+
+	mux          sync.Mutex
+	errorCounter logr.Counter
+	lgr          *logr.Logr // used to report errors from the NATS client
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewTarget creates a target that publishes log records to a NATS subject.
+// An error is returned if the initial connection cannot be made.
+func NewTarget(filter logr.Filter, formatter logr.Formatter, opts Options, maxQueue int) (*Target, error) {
+	if opts.Address == "" {
+		return nil, errors.New("nats target requires an address")
+	}
+	if opts.Subject == "" {
+		return nil, errors.New("nats target requires a subject")
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = DefaultDialTimeout
+	}
+	if opts.FlushTimeout <= 0 {
+		opts.FlushTimeout = DefaultFlushTimeout
+	}
+
+	t := &Target{opts: opts}
+	conn, err := natsgo.Connect(opts.Address, t.clientOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("nats target cannot connect to %s: %w", opts.Address, err)
+	}
+	t.conn = conn
+	t.Basic.Start(t, t, filter, formatter, maxQueue)
+	return t, nil
+}
+
+// clientOptions returns the NATS client options for the target options.
+func (t *Target) clientOptions() []natsgo.Option {
+	opts := []natsgo.Option{
+		natsgo.Timeout(t.opts.DialTimeout),
+		natsgo.MaxReconnects(-1),
+		natsgo.CustomReconnectDelay(t.opts.Backoff.Delay),
+		natsgo.DisconnectErrHandler(func(_ *natsgo.Conn, err error) {
+			if err != nil {
+				t.reportError(fmt.Errorf("nats target disconnected, reconnecting: %w", err))
+			}
+		}),
+		natsgo.ErrorHandler(func(_ *natsgo.Conn, _ *natsgo.Subscription, err error) {
+			t.incErrors()
+			t.reportError(fmt.Errorf("nats target publish failed: %w", err))
+		}),
+	}
+	if t.opts.Name != "" {
+		opts = append(opts, natsgo.Name(t.opts.Name))
+	}
+	if t.opts.User != "" {
+		opts = append(opts, natsgo.UserInfo(t.opts.User, t.opts.Password))
+	}
+	if t.opts.Token != "" {
+		opts = append(opts, natsgo.Token(t.opts.Token))
+	}
+	if t.opts.TLSConfig != nil {
+		opts = append(opts, natsgo.Secure(t.opts.TLSConfig))
+	}
+	if t.opts.ReconnectBufSize != 0 {
+		opts = append(opts, natsgo.ReconnectBufSize(t.opts.ReconnectBufSize))
+	}
+	return opts
+}
+
+// Write converts the log record to bytes, via the Formatter, and publishes it
+// to the NATS subject.
+func (t *Target) Write(rec *logr.LogRec) error {
+	lgr := rec.Logger().Logr()
+	buf := lgr.BorrowBuffer()
+	defer lgr.ReleaseBuffer(buf)
+
+	buf, err := t.Formatter().Format(rec, t.IncludeStacktrace(rec), buf)
+	if err != nil {
+		return err
+	}
+
+	t.mux.Lock()
+	t.lgr = lgr
+	t.mux.Unlock()
+
+	// Publish copies the payload into the client's buffer.
+	if err := t.conn.Publish(t.opts.Subject, bytes.TrimRight(buf.Bytes(), "\r\n")); err != nil {
+		return fmt.Errorf("nats target publish failed: %w", err)
+	}
+	return nil
+}
+
+// Flush waits for the server to confirm the records published so far. It is
+// called automatically when the target is flushed or shut down. Records
+// buffered while reconnecting are published once reconnected.
+func (t *Target) Flush() error {
+	if !t.conn.IsConnected() {
+		return nil
+	}
+	return t.conn.FlushTimeout(t.opts.FlushTimeout)
+}
+
+// Validate checks that a NATS server can be reached, dialing the known
+// servers if not currently connected.
+func (t *Target) Validate() error {
+	if t.conn.IsConnected() {
+		return nil
+	}
+	var err error
+	for _, server := range t.conn.Servers() {
+		u, perr := url.Parse(server)
+		if perr != nil {
+			err = perr
+			continue
+		}
+		var conn net.Conn
+		if conn, err = net.DialTimeout("tcp", u.Host, t.opts.DialTimeout); err == nil {
+			return conn.Close()
+		}
+	}
+	return fmt.Errorf("nats target cannot reach %s: %w", t.opts.Address, err)
+}
+
+// EnableMetrics enables metrics collection using the provided
+// MetricsCollector. Records rejected by the server after publishing are
+// counted by the target's error counter.
+func (t *Target) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if err := t.Basic.EnableMetrics(collector, updateFreqMillis); err != nil {
+		return err
+	}
+	name := t.Name()
+	if name == "" {
+		name = t.String()
+	}
+	counter, err := collector.ErrorCounter(name)
+	if err != nil {
+		return err
+	}
+	t.mux.Lock()
+	t.errorCounter = counter
+	t.mux.Unlock()
+	return nil
+}
+
+// Shutdown stops the target after publishing any queued records and waiting,
+// within the context deadline, for the server to confirm them, then closes
+// the connection. Records buffered while reconnecting are discarded.
+// Calling Shutdown more than once returns the result of the first call.
+func (t *Target) Shutdown(ctx context.Context) error {
+	t.shutdownOnce.Do(func() {
+		errs := merror.New()
+		errs.Append(t.Basic.Shutdown(ctx))
+		if t.conn.IsConnected() {
+			if _, ok := ctx.Deadline(); ok {
+				errs.Append(t.conn.FlushWithContext(ctx))
+			} else {
+				errs.Append(t.conn.FlushTimeout(t.opts.FlushTimeout))
+			}
+		}
+		t.conn.Close()
+		t.shutdownErr = errs.ErrorOrNil()
+	})
+	return t.shutdownErr
+}
+
+// incErrors increments the error counter, if metrics are enabled.
+func (t *Target) incErrors() {
+	t.mux.Lock()
+	counter := t.errorCounter
+	t.mux.Unlock()
+	if counter != nil {
+		counter.Inc()
+	}
+}
+
+// reportError reports an error from the NATS client, once a record has been
+// written.
+func (t *Target) reportError(err error) {
+	t.mux.Lock()
+	lgr := t.lgr
+	t.mux.Unlock()
+	if lgr != nil {
+		lgr.ReportError(err)
+	}
+}
@@ -0,0 +1,233 @@
+package nats_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/nats"
+	"github.com/mattermost/logr/test"
+)
+
+// fakeServer speaks enough of the NATS client protocol to accept
+// connections and publishes. Published payloads are sent to msgs.
+type fakeServer struct {
+	ln      net.Listener
+	tlsConf *tls.Config // when not nil, connections are upgraded after INFO
+	deny    string      // subject rejected with a permissions violation
+	msgs    chan string
+
+	mux   sync.Mutex
+	conns []net.Conn
+}
+
+func newFakeServer(t *testing.T, tlsConf *tls.Config, deny string) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, tlsConf: tlsConf, deny: deny, msgs: make(chan string, 100)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "nats://" + s.ln.Addr().String()
+}
+
+// close stops accepting connections and closes those accepted.
+func (s *fakeServer) close() {
+	s.ln.Close()
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mux.Lock()
+		s.conns = append(s.conns, conn)
+		s.mux.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	host, portStr, _ := net.SplitHostPort(s.ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	fmt.Fprintf(conn, `INFO {"server_id":"fake","version":"2.9.0","host":%q,"port":%d,"max_payload":1048576,"proto":1,"tls_required":%t}`+"\r\n",
+		host, port, s.tlsConf != nil)
+	if s.tlsConf != nil {
+		tlsConn := tls.Server(conn, s.tlsConf)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		conn = tlsConn
+	}
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			io.WriteString(conn, "PONG\r\n")
+		case strings.HasPrefix(line, "PUB"):
+			parts := strings.Fields(line)
+			size, _ := strconv.Atoi(parts[len(parts)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			if parts[1] == s.deny {
+				fmt.Fprintf(conn, "-ERR 'Permissions Violation for Publish to \"%s\"'\r\n", parts[1])
+				continue
+			}
+			s.msgs <- string(payload[:size])
+		}
+	}
+}
+
+func (s *fakeServer) expect(t *testing.T, want string) {
+	t.Helper()
+	select {
+	case msg := <-s.msgs:
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected message containing %q, got %q", want, msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("timed out waiting for %q", want)
+	}
+}
+
+func newLogr(t *testing.T, opts nats.Options) (*logr.Logr, *nats.Target) {
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {}
+	tgt, err := nats.NewTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{Delim: " | "}, opts, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tgt.SetName("nats")
+	if err := lgr.AddTarget(tgt); err != nil {
+		t.Fatal(err)
+	}
+	return lgr, tgt
+}
+
+func TestPublish(t *testing.T) {
+	srv := newFakeServer(t, nil, "")
+	defer srv.close()
+
+	lgr, _ := newLogr(t, nats.Options{Address: srv.url(), Subject: "logs"})
+	logger := lgr.NewLogger()
+	logger.Info("first")
+	logger.Info("second")
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	srv.expect(t, "first")
+	srv.expect(t, "second")
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPublishTLS(t *testing.T) {
+	// borrow the httptest certificate, valid for 127.0.0.1.
+	https := httptest.NewTLSServer(http.NotFoundHandler())
+	serverConf := &tls.Config{Certificates: https.TLS.Certificates}
+	clientConf := https.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	https.Close()
+
+	srv := newFakeServer(t, serverConf, "")
+	defer srv.close()
+
+	lgr, _ := newLogr(t, nats.Options{Address: srv.url(), Subject: "logs", TLSConfig: clientConf})
+	lgr.NewLogger().Info("secure")
+	srv.expect(t, "secure")
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPublishErrorsCounted(t *testing.T) {
+	srv := newFakeServer(t, nil, "denied")
+	defer srv.close()
+
+	lgr, _ := newLogr(t, nats.Options{Address: srv.url(), Subject: "denied"})
+	collector := test.NewTestMetricsCollector()
+	if err := lgr.SetMetricsCollector(collector); err != nil {
+		t.Fatal(err)
+	}
+	lgr.NewLogger().Info("rejected")
+	_ = lgr.Flush()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for collector.Get("nats").Errors < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("publish error was not counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShutdownTwice(t *testing.T) {
+	srv := newFakeServer(t, nil, "")
+	defer srv.close()
+
+	_, tgt := newLogr(t, nats.Options{Address: srv.url(), Subject: "logs"})
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	srv := newFakeServer(t, nil, "")
+	defer srv.close()
+
+	_, tgt := newLogr(t, nats.Options{Address: srv.url(), Subject: "logs", Backoff: logr.Backoff{Initial: time.Hour}})
+	defer tgt.Shutdown(context.Background())
+	if err := tgt.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	srv.close()
+	deadline := time.Now().Add(5 * time.Second)
+	for tgt.Validate() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected an error once the server is unreachable")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
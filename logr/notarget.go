@@ -0,0 +1,94 @@
+package logr
+
+import (
+	"fmt"
+	"os"
+)
+
+// NoTargetPolicy determines how log records are handled when they are
+// created before the first target is added.
+type NoTargetPolicy int
+
+const (
+	// NoTargetDrop silently drops log records until a target is added,
+	// counting them in `Stats.Dropped`. This is the default.
+	NoTargetDrop NoTargetPolicy = iota
+
+	// NoTargetBuffer buffers log records, up to `Logr.NoTargetBufferSize`,
+	// until the first target is added. The buffered records are then
+	// replayed, in order, to the Logr queue.
+	NoTargetBuffer
+
+	// NoTargetStderr writes log records to `os.Stderr` using the
+	// `DefaultFormatter` until a target is added.
+	NoTargetStderr
+)
+
+// noTargetStatus is the level status reported by `IsLevelEnabled` before
+// any targets are added. All levels are enabled (except for NoTargetDrop)
+// since the filters of future targets are not known.
+func (logr *Logr) noTargetStatus() LevelStatus {
+	if logr.NoTargetPolicy == NoTargetDrop {
+		return levelStatusDisabled
+	}
+	return LevelStatus{Enabled: true}
+}
+
+// enqueueNoTarget handles a log record created before the first target
+// is added, according to `NoTargetPolicy`. Returns false if the queue
+// now exists and the record should be enqueued normally.
+func (logr *Logr) enqueueNoTarget(rec *LogRec) bool {
+	switch logr.NoTargetPolicy {
+	case NoTargetBuffer:
+		logr.pendingMux.Lock()
+		defer logr.pendingMux.Unlock()
+		if logr.pendingDone {
+			return false
+		}
+		size := logr.NoTargetBufferSize
+		if size == 0 {
+			size = DefaultNoTargetBufferSize
+		}
+		if len(logr.pending) >= size {
+			logr.pendingDropped++
+			logr.stats.inc(statDropped)
+			return true
+		}
+		logr.pending = append(logr.pending, rec)
+	case NoTargetStderr:
+		rec.prep()
+		f := &DefaultFormatter{}
+		buf, _ := f.Format(rec, false, nil)
+		_, _ = os.Stderr.Write(buf.Bytes())
+	default:
+		logr.stats.inc(statDropped)
+	}
+	return true
+}
+
+// replayPending enqueues, in order, any log records buffered before the first
+// target was added. Must be called after the queue is created and without
+// holding `mux` or `tmux`, since the records are fanned out as they are added.
+// The records are enqueued without holding `pendingMux`, as enqueueing can
+// block on a full queue.
+func (logr *Logr) replayPending() {
+	logr.pendingMux.Lock()
+	if logr.pendingDone || logr.queue() == nil {
+		logr.pendingMux.Unlock()
+		return
+	}
+	logr.pendingDone = true
+
+	pending := logr.pending
+	dropped := logr.pendingDropped
+	logr.pending = nil
+	logr.pendingDropped = 0
+	logr.pendingMux.Unlock()
+
+	for _, rec := range pending {
+		logr.enqueue(rec)
+	}
+	if dropped > 0 {
+		logr.ReportError(fmt.Errorf("%d log records dropped before first target added; NoTargetBufferSize exceeded", dropped))
+	}
+}
@@ -0,0 +1,65 @@
+package logr_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/test"
+)
+
+func TestNoTargetDropIsSilent(t *testing.T) {
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	lgr.NewLogger().Error("nobody is listening")
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNoTargetBufferReplay(t *testing.T) {
+	// a queue smaller than the buffered records makes replay block.
+	lgr := &logr.Logr{NoTargetPolicy: logr.NoTargetBuffer, MaxQueueSize: 2}
+	lgr.OnLoggerError = func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	logger := lgr.NewLogger()
+
+	const count = 50
+	for i := 0; i < count; i++ {
+		logger.Info(fmt.Sprintf("pending %02d", i))
+	}
+
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	st := test.NewSlowTarget(filter, &format.Plain{Delim: " | ", DisableTimestamp: true}, buf, 1)
+	st.Delay = time.Millisecond
+	if err := lgr.AddTarget(st); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after replay")
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	output := buf.String()
+	for i := 0; i < count; i++ {
+		if !strings.Contains(output, fmt.Sprintf("pending %02d", i)) {
+			t.Errorf("missing pending record %d", i)
+		}
+	}
+	if strings.Index(output, "pending 00") > strings.Index(output, "pending 49") {
+		t.Error("pending records replayed out of order")
+	}
+	if !strings.Contains(output, "after replay") {
+		t.Error("missing record logged after replay")
+	}
+}
@@ -0,0 +1,64 @@
+package logr
+
+import (
+	"reflect"
+	"sync"
+)
+
+// objectValue is a value converted to fields via reflection when the log
+// record is prepped.
+type objectValue struct {
+	val interface{}
+}
+
+// Object returns a field whose value is v converted to nested fields, e.g.
+// `logger.WithFields(logr.Object("user", u)).Info("login")`. Exported struct
+// fields become fields, honoring `log:"name"`, `log:"-"` and `log:"redact"`
+// struct tags plus `Logr.RedactKeys`, as with `Logger.LogSnapshot`. Nested
+// structs and maps, including those within slices and arrays, become nested
+// fields up to a maximum depth, so self-referential values cannot recurse
+// without bound. Other values are logged as is.
+//
+// The conversion is deferred until the record is processed by the Logr and
+// so is skipped entirely for disabled levels; v must therefore not be
+// modified after logging. Struct type information is cached per type.
+func Object(key string, v interface{}) Fields {
+	return Fields{key: objectValue{val: v}}
+}
+
+// resolve converts the object to fields.
+func (ov objectValue) resolve(_ Level, lgr *Logr) (interface{}, bool) {
+	return lgr.snapshot(reflect.ValueOf(ov.val), 0), true
+}
+
+// structField describes an exported struct field for conversion to a field.
+type structField struct {
+	index  int
+	name   string
+	redact bool
+}
+
+// structFieldCache caches []structField per reflect.Type.
+var structFieldCache sync.Map
+
+// cachedStructFields returns the loggable fields of a struct type.
+func cachedStructFields(typ reflect.Type) []structField {
+	if v, ok := structFieldCache.Load(typ); ok {
+		return v.([]structField)
+	}
+
+	fields := make([]structField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name, opts := parseLogTag(sf)
+		if name == "-" {
+			continue
+		}
+		fields = append(fields, structField{index: i, name: name, redact: opts.redact})
+	}
+	v, _ := structFieldCache.LoadOrStore(typ, fields)
+	return v.([]structField)
+}
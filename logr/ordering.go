@@ -0,0 +1,176 @@
+package logr
+
+import (
+	"container/heap"
+	"time"
+)
+
+// reorderBuffer holds log records received out of sequence order until
+// the missing records arrive, the window is full, or the oldest buffered
+// record has waited for the reorder timeout.
+type reorderBuffer struct {
+	recs    recHeap
+	next    uint64 // next expected sequence number
+	window  int
+	timeout time.Duration
+	oldest  time.Time // when the oldest buffered record was received
+	timer   *time.Timer // reused by wait
+}
+
+func newReorderBuffer(window int, timeout time.Duration) *reorderBuffer {
+	return &reorderBuffer{next: 1, window: window, timeout: timeout}
+}
+
+// push adds a record then emits, in sequence order, all records that
+// can be released.
+func (rb *reorderBuffer) push(rec *LogRec, emit func(*LogRec)) {
+	if rec.seq < rb.next {
+		// late arrival after a gap was skipped; emit immediately.
+		emit(rec)
+		return
+	}
+	if len(rb.recs) == 0 {
+		rb.oldest = time.Now()
+	}
+	heap.Push(&rb.recs, rec)
+	rb.release(emit, false)
+}
+
+// release emits records in sequence order while the next expected record
+// is available. Gaps are skipped when force is true, the window is full,
+// or the reorder timeout has elapsed.
+func (rb *reorderBuffer) release(emit func(*LogRec), force bool) {
+	for len(rb.recs) > 0 {
+		min := rb.recs[0]
+		if min.seq != rb.next && !force && len(rb.recs) <= rb.window && time.Since(rb.oldest) < rb.timeout {
+			return
+		}
+		heap.Pop(&rb.recs)
+		rb.next = min.seq + 1
+		emit(min)
+		rb.oldest = time.Now()
+	}
+}
+
+// wait returns a channel that fires when the oldest buffered record reaches
+// the reorder timeout, or nil if nothing is buffered.
+func (rb *reorderBuffer) wait() <-chan time.Time {
+	if len(rb.recs) == 0 {
+		return nil
+	}
+	d := rb.timeout - time.Since(rb.oldest)
+	if rb.timer == nil {
+		rb.timer = time.NewTimer(d)
+		return rb.timer.C
+	}
+	if !rb.timer.Stop() {
+		// drain a fire that was not received so it cannot trigger early.
+		select {
+		case <-rb.timer.C:
+		default:
+		}
+	}
+	rb.timer.Reset(d)
+	return rb.timer.C
+}
+
+// stop releases the timer used by wait.
+func (rb *reorderBuffer) stop() {
+	if rb.timer != nil {
+		rb.timer.Stop()
+	}
+}
+
+// recHeap is a min-heap of log records ordered by sequence number.
+type recHeap []*LogRec
+
+func (h recHeap) Len() int            { return len(h) }
+func (h recHeap) Less(i, j int) bool  { return h[i].seq < h[j].seq }
+func (h recHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *recHeap) Push(x interface{}) { *h = append(*h, x.(*LogRec)) }
+func (h *recHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	rec := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return rec
+}
+
+// reorderWindow returns the maximum number of records buffered for reordering.
+func (logr *Logr) reorderWindow() int {
+	if logr.ReorderWindow <= 0 {
+		return DefaultReorderWindow
+	}
+	return logr.ReorderWindow
+}
+
+// reorderTimeout returns the maximum time a record is held waiting for
+// earlier records.
+func (logr *Logr) reorderTimeout() time.Duration {
+	if logr.ReorderTimeout <= 0 {
+		return DefaultReorderTimeout
+	}
+	return logr.ReorderTimeout
+}
+
+// startOrdered is the `StrictOrdering` equivalent of `start`, fanning out
+// records in sequence order.
+func (logr *Logr) startOrdered() {
+	in := logr.readQueue()
+	defer func() {
+		if r := recover(); r != nil {
+			// as for `start`, the record being processed is dropped.
+			logr.stats.inc(statDropped)
+			logr.ReportError(r)
+			if logr.allowRestart(time.Now()) {
+				go logr.startOrdered()
+				return
+			}
+			// records held for reordering are dropped along with those queued.
+			logr.withSyncMux(func() {
+				logr.reorder.release(logr.dropDegraded, true)
+			})
+			logr.reorder.stop()
+			logr.degrade(in)
+		}
+	}()
+
+	for {
+		select {
+		case rec, ok := <-in:
+			if !ok {
+				// the queue is closed by `Shutdown`, or replaced by `SetMaxQueueSize`.
+				if next := logr.readQueue(); next != in && next != nil {
+					in = next
+					continue
+				}
+				logr.withSyncMux(func() {
+					logr.reorder.release(logr.process, true)
+				})
+				logr.reorder.stop()
+				close(logr.done)
+				return
+			}
+			logr.untrack(rec)
+			logr.withSyncMux(func() {
+				if rec.flush != nil {
+					logr.flush(in, rec)
+				} else {
+					logr.reorder.push(rec, logr.process)
+				}
+			})
+		case <-logr.reorder.wait():
+			logr.withSyncMux(func() {
+				logr.reorder.release(logr.process, false)
+			})
+		}
+	}
+}
+
+// Sequence returns the sequence number assigned to this log record when it
+// was enqueued, starting at 1. Records created concurrently may be enqueued
+// out of sequence order; see `Logr.StrictOrdering`.
+func (rec *LogRec) Sequence() uint64 {
+	return rec.seq
+}
@@ -0,0 +1,99 @@
+package logr_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// seqTarget records the sequence number of each record written.
+type seqTarget struct {
+	logr.Basic
+	mux  sync.Mutex
+	seqs []uint64
+}
+
+func (st *seqTarget) Write(rec *logr.LogRec) error {
+	st.mux.Lock()
+	defer st.mux.Unlock()
+	st.seqs = append(st.seqs, rec.Sequence())
+	return nil
+}
+
+func TestStrictOrdering(t *testing.T) {
+	lgr := &logr.Logr{StrictOrdering: true, MaxQueueSize: 10000}
+	st := &seqTarget{}
+	st.Basic.Start(st, st, &logr.StdFilter{Lvl: logr.Info}, nil, 10000)
+	if err := lgr.AddTarget(st); err != nil {
+		t.Fatal(err)
+	}
+	logger := lgr.NewLogger()
+
+	const goroutines = 10
+	const records = 500
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < records; j++ {
+				logger.Info("ordered")
+			}
+		}()
+	}
+	wg.Wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	st.mux.Lock()
+	defer st.mux.Unlock()
+	if len(st.seqs) != goroutines*records {
+		t.Fatalf("expected %d records, got %d", goroutines*records, len(st.seqs))
+	}
+	for i, seq := range st.seqs {
+		if seq != uint64(i+1) {
+			t.Fatalf("record %d has sequence %d", i, seq)
+		}
+	}
+}
+
+func TestStrictOrderingDegrades(t *testing.T) {
+	lgr := &logr.Logr{StrictOrdering: true, MaxRestarts: 2, RestartWindow: time.Hour}
+	lgr.OnLoggerError = func(err error) {}
+	lgr.AddRedactor(func(field logr.Field) logr.Field {
+		panic("redactor failed")
+	})
+	st := &seqTarget{}
+	st.Basic.Start(st, st, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	if err := lgr.AddTarget(st); err != nil {
+		t.Fatal(err)
+	}
+
+	// each record panics while being prepared, restarting the ordered read
+	// loop until MaxRestarts is exceeded.
+	logger := lgr.NewLogger().WithField("user", "sam")
+	for i := 0; i < 20; i++ {
+		logger.Info("panics")
+	}
+	if err := lgr.Flush(); err != logr.ErrDegraded {
+		t.Errorf("expected ErrDegraded, got %v", err)
+	}
+	if !lgr.IsDegraded() {
+		t.Error("expected degraded after repeated panics")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if dropped := lgr.Stats().Dropped; dropped != 20 {
+		t.Errorf("expected 20 dropped, got %d", dropped)
+	}
+	st.mux.Lock()
+	defer st.mux.Unlock()
+	if len(st.seqs) != 0 {
+		t.Errorf("expected no records written, got %d", len(st.seqs))
+	}
+}
@@ -0,0 +1,213 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/logr"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// maxValueDepth limits the nesting of attribute values, e.g. error chains.
+const maxValueDepth = 8
+
+// Severity numbers defined by the OTLP logs data model.
+const (
+	SeverityUnspecified int32 = 0
+	SeverityTrace       int32 = 1
+	SeverityDebug       int32 = 5
+	SeverityInfo        int32 = 9
+	SeverityWarn        int32 = 13
+	SeverityError       int32 = 17
+	SeverityFatal       int32 = 21
+	SeverityFatal4      int32 = 24
+)
+
+// DefaultSeverity maps the standard logr levels to OTLP severity numbers,
+// with Panic as the most severe, FATAL4. Custom levels are unspecified; use
+// `Options.Severity` to map them.
+func DefaultSeverity(lvl logr.Level) int32 {
+	switch lvl.ID {
+	case logr.Panic.ID:
+		return SeverityFatal4
+	case logr.Fatal.ID:
+		return SeverityFatal
+	case logr.Error.ID:
+		return SeverityError
+	case logr.Warn.ID:
+		return SeverityWarn
+	case logr.Info.ID:
+		return SeverityInfo
+	case logr.Debug.ID:
+		return SeverityDebug
+	case logr.Trace.ID:
+		return SeverityTrace
+	}
+	return SeverityUnspecified
+}
+
+// exportRequest creates an ExportLogsServiceRequest with a single resource
+// and scope holding the LogRecords.
+func exportRequest(resource *resourcepb.Resource, scope *commonpb.InstrumentationScope, records []*logspb.LogRecord) *collogspb.ExportLogsServiceRequest {
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope:      scope,
+				LogRecords: records,
+			}},
+		}},
+	}
+}
+
+// attributes converts fields to KeyValues, sorted by key so the encoding is
+// deterministic.
+func attributes(attrs logr.Fields, depth int) []*commonpb.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, &commonpb.KeyValue{Key: k, Value: anyValue(attrs[k], depth)})
+	}
+	return kvs
+}
+
+// anyValue converts v to an AnyValue. Values without an OTLP equivalent,
+// e.g. structs, are converted to strings.
+func anyValue(v interface{}, depth int) *commonpb.AnyValue {
+	if depth > maxValueDepth {
+		return stringValue(fmt.Sprint(v))
+	}
+	switch val := v.(type) {
+	case nil:
+		return &commonpb.AnyValue{}
+	case string:
+		return stringValue(val)
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: val}}
+	case int:
+		return intValue(int64(val))
+	case int8:
+		return intValue(int64(val))
+	case int16:
+		return intValue(int64(val))
+	case int32:
+		return intValue(int64(val))
+	case int64:
+		return intValue(val)
+	case uint:
+		return uintValue(uint64(val))
+	case uint8:
+		return intValue(int64(val))
+	case uint16:
+		return intValue(int64(val))
+	case uint32:
+		return intValue(int64(val))
+	case uint64:
+		return uintValue(val)
+	case float32:
+		return doubleValue(float64(val))
+	case float64:
+		return doubleValue(val)
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: val}}
+	case time.Time:
+		return stringValue(val.Format(time.RFC3339Nano))
+	case time.Duration:
+		return stringValue(val.String())
+	case error:
+		return stringValue(val.Error())
+	case fmt.Stringer:
+		return stringValue(val.String())
+	case logr.Fields:
+		return kvListValue(val, depth)
+	case map[string]interface{}:
+		return kvListValue(val, depth)
+	case []logr.Field:
+		attrs := make(logr.Fields, len(val))
+		for _, f := range val {
+			attrs[f.Key] = f.Value
+		}
+		return kvListValue(attrs, depth)
+	}
+
+	// slices and arrays of any other element type.
+	rv := reflect.ValueOf(v)
+	if kind := rv.Kind(); kind == reflect.Slice || kind == reflect.Array {
+		arr := &commonpb.ArrayValue{Values: make([]*commonpb.AnyValue, 0, rv.Len())}
+		for i := 0; i < rv.Len(); i++ {
+			arr.Values = append(arr.Values, anyValue(rv.Index(i).Interface(), depth+1))
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: arr}}
+	}
+	return stringValue(fmt.Sprintf("%v", v))
+}
+
+func stringValue(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
+
+func intValue(i int64) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: i}}
+}
+
+// uintValue converts an unsigned integer, to a string if it overflows int64.
+func uintValue(u uint64) *commonpb.AnyValue {
+	if u > math.MaxInt64 {
+		return stringValue(fmt.Sprint(u))
+	}
+	return intValue(int64(u))
+}
+
+func doubleValue(f float64) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: f}}
+}
+
+func kvListValue(attrs logr.Fields, depth int) *commonpb.AnyValue {
+	kvs := &commonpb.KeyValueList{Values: attributes(attrs, depth+1)}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: kvs}}
+}
+
+// otlpID converts a trace or span ID field value, a hex string or bytes, to
+// the ID bytes. Returns nil if the value is not a valid, non-zero ID of size
+// bytes.
+func otlpID(v interface{}, size int) []byte {
+	var id []byte
+	switch val := v.(type) {
+	case string:
+		decoded, err := hex.DecodeString(strings.TrimSpace(val))
+		if err != nil {
+			return nil
+		}
+		id = decoded
+	case []byte:
+		id = val
+	case fmt.Stringer:
+		return otlpID(val.String(), size)
+	default:
+		return nil
+	}
+	if len(id) != size {
+		return nil
+	}
+	for _, c := range id {
+		if c != 0 {
+			return id
+		}
+	}
+	return nil
+}
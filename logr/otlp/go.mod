@@ -0,0 +1,12 @@
+module github.com/mattermost/logr/otlp
+
+go 1.12
+
+require (
+	github.com/mattermost/logr v1.0.9
+	github.com/wiggin77/merror v1.0.2
+	go.opentelemetry.io/proto/otlp v1.0.0
+	google.golang.org/grpc v1.56.2
+)
+
+replace github.com/mattermost/logr => ../
@@ -0,0 +1,479 @@
+// Package otlp provides a logr target that exports log records as
+// OpenTelemetry (OTLP) LogRecords over gRPC, e.g. to an OpenTelemetry
+// Collector. It is a separate module so that Logr itself does not depend on
+// gRPC.
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultBatchSize is the maximum number of log records per export when
+	// `Options.BatchSize` is zero.
+	DefaultBatchSize = 512
+
+	// DefaultBatchInterval is the maximum time a log record waits in a partial
+	// batch when `Options.BatchInterval` is zero.
+	DefaultBatchInterval = time.Second
+
+	// DefaultTimeout is the timeout of each export attempt when
+	// `Options.Timeout` is zero.
+	DefaultTimeout = time.Second * 10
+
+	// DefaultMaxRetries is the number of times a failed export is retried when
+	// `Options.MaxRetries` is zero.
+	DefaultMaxRetries = 5
+
+	// DefaultTraceIDField and DefaultSpanIDField are the fields holding the
+	// trace correlation IDs when `Options.TraceIDField` and
+	// `Options.SpanIDField` are empty.
+	DefaultTraceIDField = "trace_id"
+	DefaultSpanIDField  = "span_id"
+
+	// ScopeName is the instrumentation scope name of exported log records.
+	ScopeName = "github.com/mattermost/logr"
+
+	// stacktraceAttribute is the semantic convention attribute for a stack trace.
+	stacktraceAttribute = "exception.stacktrace"
+
+	// minExportTick is the minimum interval at which partial batches are checked.
+	minExportTick = time.Millisecond * 10
+
+	traceIDSize = 16
+	spanIDSize  = 8
+)
+
+// Options configures an OTLP target.
+type Options struct {
+	// Endpoint is the collector address, e.g. "localhost:4317".
+	Endpoint string
+
+	// Insecure, when true, connects without TLS, e.g. to a collector sidecar.
+	Insecure bool
+
+	// TLSConfig configures TLS when Insecure is false. When nil the system
+	// root CAs are used.
+	TLSConfig *tls.Config
+
+	// Headers are sent with every export, e.g. an API key for a hosted collector.
+	Headers map[string]string
+
+	// Resource attributes describe the entity producing the logs, e.g.
+	// "service.name". ServiceName, when not empty, sets "service.name".
+	Resource    logr.Fields
+	ServiceName string
+
+	// BatchSize is the maximum number of log records per export.
+	// Defaults to DefaultBatchSize.
+	BatchSize int
+
+	// BatchInterval is the maximum time a log record waits in a partial batch
+	// before it is exported. Defaults to DefaultBatchInterval.
+	BatchInterval time.Duration
+
+	// Timeout is the timeout of each export attempt. Defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times an export failing with a retryable
+	// status, e.g. Unavailable, is retried before the batch is dropped.
+	// Defaults to DefaultMaxRetries; a negative value disables retries.
+	MaxRetries int
+
+	// Backoff determines the delay between retries, and between attempts to
+	// reconnect to the collector.
+	Backoff logr.Backoff
+
+	// Severity maps a level to an OTLP severity number. Defaults to
+	// `DefaultSeverity`, which leaves custom levels unspecified.
+	Severity func(lvl logr.Level) int32
+
+	// TraceIDField and SpanIDField name the fields, e.g. extracted from the
+	// context via `Logr.RegisterContextField`, holding the trace and span IDs
+	// as hex strings or bytes. Valid IDs populate the LogRecord's trace
+	// correlation fields instead of attributes. Default to
+	// DefaultTraceIDField and DefaultSpanIDField.
+	TraceIDField string
+	SpanIDField  string
+
+	// DialOptions are added to those used to connect to the collector.
+	DialOptions []grpc.DialOption
+}
+
+// Target exports log records to an OTLP collector over gRPC. Each record's
+// message becomes the LogRecord body, its level the severity, and its fields
+// the attributes; nested fields, e.g. from `logr.ErrorField`, become nested
+// key/value lists.
+//
+// Records are batched within the target's own queue goroutine, so that
+// exports retried with backoff hold up only this target and never the Logr
+// queue. A batch is exported when it
+// reaches `Options.BatchSize`, when its oldest record has waited
+// `Options.BatchInterval`, and when the target is flushed or shut down. A
+// batch still failing after `Options.MaxRetries` is dropped and the error
+// reported via `logr.Logr.ReportError`.
+type Target struct {
+	logr.Basic
+	opts Options
+
+	conn     *grpc.ClientConn
+	client   collogspb.LogsServiceClient
+	md       metadata.MD
+	resource *resourcepb.Resource
+	scope    *commonpb.InstrumentationScope
+
+	ctx    context.Context // canceled when shut down, ending exports
+	cancel context.CancelFunc
+	quit   chan struct{}
+
+	mux     sync.Mutex // guards batch; held while exporting
+	batch   []*logspb.LogRecord
+	oldest  time.Time
+	dropped uint64
+	lgr     *logr.Logr // used to report errors from the batch goroutine
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewTarget creates a target that exports log records to the OTLP collector
+// at `Options.Endpoint`. The connection is made in the background, so an
+// error is only returned for invalid options.
+func NewTarget(filter logr.Filter, opts Options, maxQueue int) (*Target, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("otlp target requires an endpoint")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.BatchInterval <= 0 {
+		opts.BatchInterval = DefaultBatchInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.Severity == nil {
+		opts.Severity = DefaultSeverity
+	}
+	if opts.TraceIDField == "" {
+		opts.TraceIDField = DefaultTraceIDField
+	}
+	if opts.SpanIDField == "" {
+		opts.SpanIDField = DefaultSpanIDField
+	}
+
+	creds := grpc.WithTransportCredentials(insecure.NewCredentials())
+	if !opts.Insecure {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(opts.TLSConfig))
+	}
+	dialOpts := append([]grpc.DialOption{creds, grpc.WithConnectParams(connectParams(opts.Backoff, opts.Timeout))}, opts.DialOptions...)
+	conn, err := grpc.Dial(opts.Endpoint, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("otlp target cannot connect to %s: %w", opts.Endpoint, err)
+	}
+
+	resource := logr.Fields{}
+	for k, v := range opts.Resource {
+		resource[k] = v
+	}
+	if opts.ServiceName != "" {
+		resource["service.name"] = opts.ServiceName
+	}
+
+	t := &Target{
+		opts:     opts,
+		conn:     conn,
+		client:   collogspb.NewLogsServiceClient(conn),
+		md:       metadata.New(opts.Headers),
+		resource: &resourcepb.Resource{Attributes: attributes(resource, 0)},
+		scope:    &commonpb.InstrumentationScope{Name: ScopeName},
+		quit:     make(chan struct{}),
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	t.Basic.Start(t, t, filter, nil, maxQueue)
+	go t.startExporter()
+	return t, nil
+}
+
+// Write converts the log record to an OTLP LogRecord and adds it to the
+// batch, exporting the batch once full.
+func (t *Target) Write(rec *logr.LogRec) error {
+	lr := t.logRecord(rec)
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	t.lgr = rec.Logger().Logr()
+
+	if len(t.batch) == 0 {
+		t.oldest = time.Now()
+	}
+	t.batch = append(t.batch, lr)
+	if len(t.batch) < t.opts.BatchSize {
+		return nil
+	}
+	return t.export()
+}
+
+// Flush exports any partial batch. It is called automatically when the
+// target is flushed or shut down.
+func (t *Target) Flush() error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.export()
+}
+
+// Validate checks that the collector can be reached, connecting within
+// `Options.Timeout` if not currently connected.
+func (t *Target) Validate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.Timeout)
+	defer cancel()
+	t.conn.Connect()
+	for {
+		state := t.conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !t.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("otlp target cannot connect to %s: %s", t.opts.Endpoint, state)
+		}
+	}
+}
+
+// Dropped returns the number of log records dropped because their export
+// failed.
+func (t *Target) Dropped() uint64 {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.dropped
+}
+
+// Shutdown stops the target after exporting any queued records within the
+// context deadline, then closes the connection. Once ctx is done, exports
+// in progress, including retries, are abandoned. Calling Shutdown more than
+// once returns the result of the first call.
+func (t *Target) Shutdown(ctx context.Context) error {
+	t.shutdownOnce.Do(func() {
+		close(t.quit)
+
+		stop := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				t.cancel()
+			case <-stop:
+			}
+		}()
+
+		errs := merror.New()
+		errs.Append(t.Basic.Shutdown(ctx))
+		close(stop)
+		t.cancel()
+		errs.Append(t.conn.Close())
+		t.shutdownErr = errs.ErrorOrNil()
+	})
+	return t.shutdownErr
+}
+
+// logRecord converts a log record to an OTLP LogRecord.
+func (t *Target) logRecord(rec *logr.LogRec) *logspb.LogRecord {
+	attrs := logr.Fields{}
+	for k, v := range rec.ReservedFields() {
+		attrs[k] = v
+	}
+	for k, v := range rec.Fields() {
+		attrs[k] = v
+	}
+
+	lr := &logspb.LogRecord{
+		TimeUnixNano:         uint64(rec.Time().UnixNano()),
+		ObservedTimeUnixNano: uint64(time.Now().UnixNano()),
+		SeverityNumber:       logspb.SeverityNumber(t.opts.Severity(rec.Level())),
+		SeverityText:         rec.Level().Name,
+		Body:                 stringValue(rec.Msg()),
+	}
+	if id := otlpID(attrs[t.opts.TraceIDField], traceIDSize); id != nil {
+		lr.TraceId = id
+		delete(attrs, t.opts.TraceIDField)
+	}
+	if id := otlpID(attrs[t.opts.SpanIDField], spanIDSize); id != nil {
+		lr.SpanId = id
+		delete(attrs, t.opts.SpanIDField)
+	}
+	if t.IncludeStacktrace(rec) {
+		if frames := rec.StackFrames(); len(frames) > 0 {
+			attrs[stacktraceAttribute] = formatFrames(frames)
+		}
+	}
+	lr.Attributes = attributes(attrs, 0)
+	return lr
+}
+
+// export sends the batch, retrying with backoff, then empties it. Caller
+// must hold the mutex.
+func (t *Target) export() error {
+	if len(t.batch) == 0 {
+		return nil
+	}
+	records := t.batch
+	t.batch = nil
+
+	req := exportRequest(t.resource, t.scope, records)
+	err := t.exportWithRetry(req)
+	if err != nil {
+		t.dropped += uint64(len(records))
+		return fmt.Errorf("otlp target dropped %d records: %w", len(records), err)
+	}
+	return nil
+}
+
+// exportWithRetry sends an encoded request, retrying retryable failures.
+// Caller must hold the mutex.
+func (t *Target) exportWithRetry(req *collogspb.ExportLogsServiceRequest) error {
+	for attempt := 0; ; attempt++ {
+		err := t.exportOnce(req)
+		if err == nil || !retryable(err) || attempt >= t.opts.MaxRetries {
+			return err
+		}
+		select {
+		case <-t.ctx.Done():
+			return err
+		case <-time.After(t.opts.Backoff.Delay(attempt + 1)):
+		}
+	}
+}
+
+// exportOnce makes a single export call. Caller must hold the mutex.
+func (t *Target) exportOnce(req *collogspb.ExportLogsServiceRequest) error {
+	ctx, cancel := context.WithTimeout(t.ctx, t.opts.Timeout)
+	defer cancel()
+	if len(t.md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, t.md)
+	}
+
+	resp, err := t.client.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+	partial := resp.GetPartialSuccess()
+	if rejected, msg := partial.GetRejectedLogRecords(), partial.GetErrorMessage(); rejected > 0 || msg != "" {
+		// rejected records are not retried, per the OTLP specification. The
+		// mutex is held, so report directly.
+		if t.lgr != nil {
+			t.lgr.ReportError(fmt.Errorf("otlp collector rejected %d records: %s", rejected, msg))
+		}
+	}
+	return nil
+}
+
+// connectParams returns the gRPC connection parameters reconnecting with the
+// delays of b, allowing timeout for each connection attempt. gRPC reconnects
+// in the background while the collector is unreachable, and exports fail
+// with Unavailable meanwhile.
+func connectParams(b logr.Backoff, timeout time.Duration) grpc.ConnectParams {
+	cfg := backoff.Config{
+		BaseDelay:  b.Initial,
+		Multiplier: b.Multiplier,
+		Jitter:     b.Jitter,
+		MaxDelay:   b.Max,
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = logr.DefaultBackoffInitial
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = 2
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = logr.DefaultBackoffMax
+	}
+	return grpc.ConnectParams{Backoff: cfg, MinConnectTimeout: timeout}
+}
+
+// retryable returns true if an export error is transient, per the OTLP
+// specification.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Canceled, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+// startExporter exports partial batches once their oldest record has waited
+// `Options.BatchInterval`, until the target is shut down.
+func (t *Target) startExporter() {
+	tick := t.opts.BatchInterval / 2
+	if tick < minExportTick {
+		tick = minExportTick
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.quit:
+			return
+		case <-ticker.C:
+			t.mux.Lock()
+			var err error
+			if len(t.batch) > 0 && time.Since(t.oldest) >= t.opts.BatchInterval {
+				err = t.export()
+			}
+			t.mux.Unlock()
+			if err != nil {
+				t.reportError(err)
+			}
+		}
+	}
+}
+
+func (t *Target) reportError(err error) {
+	t.mux.Lock()
+	lgr := t.lgr
+	t.mux.Unlock()
+	if lgr != nil {
+		lgr.ReportError(err)
+	}
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (t *Target) String() string {
+	if name := t.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("otlp(%s)", t.opts.Endpoint)
+}
+
+// formatFrames formats stack frames like a Go panic stack trace.
+func formatFrames(frames []runtime.Frame) string {
+	var sb strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return sb.String()
+}
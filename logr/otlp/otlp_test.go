@@ -0,0 +1,276 @@
+package otlp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/otlp"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// logsServer is an in-process OTLP LogsService. Each export fails with the
+// next queued error, if any, or is recorded.
+type logsServer struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mux      sync.Mutex
+	errs     []error
+	partial  *collogspb.ExportLogsPartialSuccess
+	requests []*collogspb.ExportLogsServiceRequest
+	headers  []metadata.MD
+	exports  int
+}
+
+func (s *logsServer) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.exports++
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.requests = append(s.requests, req)
+	s.headers = append(s.headers, md)
+	return &collogspb.ExportLogsServiceResponse{PartialSuccess: s.partial}, nil
+}
+
+// records returns the log records received so far.
+func (s *logsServer) records() []*logspb.LogRecord {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	var records []*logspb.LogRecord
+	for _, req := range s.requests {
+		for _, rl := range req.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				records = append(records, sl.LogRecords...)
+			}
+		}
+	}
+	return records
+}
+
+// startServer serves s in-process, returning a dial option connecting to it.
+func startServer(t *testing.T, s *logsServer) (grpc.DialOption, func()) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(srv, s)
+	go srv.Serve(lis)
+
+	dialer := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+	return dialer, srv.Stop
+}
+
+func newTarget(t *testing.T, s *logsServer, opts otlp.Options) (*logr.Logr, *otlp.Target, func()) {
+	dialer, stop := startServer(t, s)
+	opts.Endpoint = "bufnet"
+	opts.Insecure = true
+	opts.DialOptions = append(opts.DialOptions, dialer)
+	tgt, err := otlp.NewTarget(&logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Panic}, opts, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {}
+	if err := lgr.AddTarget(tgt); err != nil {
+		t.Fatal(err)
+	}
+	return lgr, tgt, stop
+}
+
+// attr returns the value of the named attribute, or nil.
+func attr(kvs []*commonpb.KeyValue, key string) *commonpb.AnyValue {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	s := &logsServer{}
+	lgr, _, stop := newTarget(t, s, otlp.Options{
+		ServiceName: "test-service",
+		Headers:     map[string]string{"api-key": "secret"},
+	})
+	defer stop()
+
+	lgr.NewLogger().WithFields(logr.Fields{
+		"user":     "sam",
+		"count":    3,
+		"ratio":    0.5,
+		"ok":       true,
+		"ids":      []int{1, 2},
+		"err":      errors.New("boom"),
+		"nested":   logr.Fields{"a": "b"},
+		"trace_id": "0102030405060708090a0b0c0d0e0f10",
+		"span_id":  "0102030405060708",
+	}).Warn("hello")
+	if err := lgr.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.requests) != 1 {
+		t.Fatalf("expected 1 export, got %d", len(s.requests))
+	}
+	if got := s.headers[0].Get("api-key"); len(got) != 1 || got[0] != "secret" {
+		t.Errorf("expected api-key header, got %v", got)
+	}
+	rl := s.requests[0].ResourceLogs[0]
+	if v := attr(rl.Resource.Attributes, "service.name"); v.GetStringValue() != "test-service" {
+		t.Errorf("expected service.name test-service, got %v", v)
+	}
+	if name := rl.ScopeLogs[0].Scope.Name; name != otlp.ScopeName {
+		t.Errorf("expected scope %s, got %s", otlp.ScopeName, name)
+	}
+
+	records := s.records()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	lr := records[0]
+	if lr.Body.GetStringValue() != "hello" {
+		t.Errorf("expected body hello, got %v", lr.Body)
+	}
+	if lr.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_WARN || lr.SeverityText != "warn" {
+		t.Errorf("expected warn severity, got %v %q", lr.SeverityNumber, lr.SeverityText)
+	}
+	if lr.TimeUnixNano == 0 || lr.ObservedTimeUnixNano == 0 {
+		t.Error("expected timestamps")
+	}
+	if len(lr.TraceId) != 16 || lr.TraceId[15] != 0x10 || len(lr.SpanId) != 8 || lr.SpanId[7] != 0x08 {
+		t.Errorf("expected trace and span IDs, got %x %x", lr.TraceId, lr.SpanId)
+	}
+	if attr(lr.Attributes, "trace_id") != nil || attr(lr.Attributes, "span_id") != nil {
+		t.Error("expected trace and span IDs removed from attributes")
+	}
+
+	if v := attr(lr.Attributes, "user"); v.GetStringValue() != "sam" {
+		t.Errorf("expected user sam, got %v", v)
+	}
+	if v := attr(lr.Attributes, "count"); v.GetIntValue() != 3 {
+		t.Errorf("expected count 3, got %v", v)
+	}
+	if v := attr(lr.Attributes, "ratio"); v.GetDoubleValue() != 0.5 {
+		t.Errorf("expected ratio 0.5, got %v", v)
+	}
+	if v := attr(lr.Attributes, "ok"); !v.GetBoolValue() {
+		t.Errorf("expected ok true, got %v", v)
+	}
+	if v := attr(lr.Attributes, "err"); v.GetStringValue() != "boom" {
+		t.Errorf("expected err boom, got %v", v)
+	}
+	if v := attr(lr.Attributes, "ids").GetArrayValue().GetValues(); len(v) != 2 || v[1].GetIntValue() != 2 {
+		t.Errorf("expected ids [1 2], got %v", v)
+	}
+	if v := attr(lr.Attributes, "nested").GetKvlistValue().GetValues(); len(v) != 1 || attr(v, "a").GetStringValue() != "b" {
+		t.Errorf("expected nested a=b, got %v", v)
+	}
+}
+
+func TestExportRetriesUnavailable(t *testing.T) {
+	s := &logsServer{errs: []error{
+		status.Error(codes.Unavailable, "try again"),
+		status.Error(codes.Unavailable, "try again"),
+	}}
+	lgr, tgt, stop := newTarget(t, s, otlp.Options{
+		MaxRetries: 3,
+		Backoff:    logr.Backoff{Initial: time.Millisecond, Max: time.Millisecond},
+	})
+	defer stop()
+
+	lgr.NewLogger().Info("retried")
+	if err := lgr.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if s.exports != 3 || len(s.records()) != 1 {
+		t.Errorf("expected 3 exports and 1 record, got %d exports and %d records", s.exports, len(s.records()))
+	}
+	if dropped := tgt.Dropped(); dropped != 0 {
+		t.Errorf("expected 0 dropped, got %d", dropped)
+	}
+}
+
+func TestExportDropsOnPermanentError(t *testing.T) {
+	s := &logsServer{errs: []error{status.Error(codes.InvalidArgument, "bad request")}}
+	lgr, tgt, stop := newTarget(t, s, otlp.Options{MaxRetries: 3})
+	defer stop()
+
+	lgr.NewLogger().Info("dropped")
+	if err := lgr.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if s.exports != 1 {
+		t.Errorf("expected 1 export, got %d", s.exports)
+	}
+	if dropped := tgt.Dropped(); dropped != 1 {
+		t.Errorf("expected 1 dropped, got %d", dropped)
+	}
+}
+
+func TestExportPartialSuccessReported(t *testing.T) {
+	s := &logsServer{partial: &collogspb.ExportLogsPartialSuccess{RejectedLogRecords: 1, ErrorMessage: "too old"}}
+	lgr, _, stop := newTarget(t, s, otlp.Options{})
+	defer stop()
+
+	var mux sync.Mutex
+	var reported []error
+	lgr.OnLoggerError = func(err error) {
+		mux.Lock()
+		defer mux.Unlock()
+		reported = append(reported, err)
+	}
+
+	lgr.NewLogger().Info("rejected")
+	if err := lgr.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(reported) != 1 {
+		t.Errorf("expected 1 reported error, got %v", reported)
+	}
+}
+
+func TestShutdownTwice(t *testing.T) {
+	s := &logsServer{}
+	_, tgt, stop := newTarget(t, s, otlp.Options{})
+	defer stop()
+
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := &logsServer{}
+	_, tgt, stop := newTarget(t, s, otlp.Options{Timeout: 500 * time.Millisecond})
+	defer tgt.Shutdown(context.Background())
+
+	if err := tgt.Validate(); err != nil {
+		t.Error(err)
+	}
+	stop()
+	if err := tgt.Validate(); err == nil {
+		t.Error("expected an error once the collector is unreachable")
+	}
+}
@@ -0,0 +1,75 @@
+package logr
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// logPanic is called by the PanicXXX style APIs with the formatted message.
+// If `OnPanic` is not nil the message is logged normally and `OnPanic` is
+// called. Otherwise the message is written via `writePanic` and, after this
+// Logr is shut down, `panic(msg)` is called.
+func (logger Logger) logPanic(msg string) {
+	lgr := logger.logr
+	if onPanic := lgr.onPanic(); onPanic != nil {
+		logger.Log(Panic, msg)
+		onPanic(msg)
+		return
+	}
+	lgr.writePanic(logger, msg)
+	lgr.panic(msg)
+}
+
+// writePanic writes a Panic level log record, including the full stack of the
+// calling goroutine in `FieldKeyStack`, directly to the targets and waits for
+// each target to write it, so the crash reason is persisted even if the Logr
+// queue goroutine is torn down. The record is fanned out holding `syncMux`, so
+// it waits for any record the queue goroutine is fanning out. When
+// `PanicSynchronousFlush` is true the Logr is flushed first, so records logged
+// earlier are written before it.
+func (logr *Logr) writePanic(logger Logger, msg string) {
+	stack := debug.Stack()
+
+	if logr.PanicDump != nil {
+		if err := logr.Dump(logr.PanicDump); err != nil {
+			logr.ReportError(fmt.Errorf("logr panic dump: %w", err))
+		}
+	}
+
+	if logr.PanicSynchronousFlush {
+		if err := logr.Flush(); err != nil {
+			logr.ReportError(err)
+		}
+	}
+
+	status := logger.levelStatus(Panic)
+	if !status.Enabled {
+		return
+	}
+	rec := NewLogRec(Panic, logger.WithField(FieldKeyStack, string(stack)), "", []interface{}{msg}, status.Stacktrace)
+
+	// bypass the queue; clock skew checks are skipped since they may only be
+	// made from the queue goroutine.
+	rec.prep()
+	logr.withSyncMux(func() {
+		logr.fanout(rec)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), logr.flushTimeout())
+	defer cancel()
+	logr.tmux.RLock()
+	pending := make([]pendingFlush, 0, len(logr.targets))
+	for _, target := range logr.targets {
+		f := newFlushLogRec(logger)
+		target.Log(f)
+		pending = append(pending, pendingFlush{target: target, rec: f})
+	}
+	logr.tmux.RUnlock()
+
+	for _, pf := range pending {
+		if err := pf.wait(ctx); err != nil {
+			logr.ReportError(err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package logr_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func TestPanicWhileLogging(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	_ = lgr.AddTarget(target.NewWriterTarget(filter, &format.Plain{Delim: " | "}, buf, 1000))
+	logger := lgr.NewLogger()
+	logger.Info("started")
+	if err := lgr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// keep the queue goroutine fanning out while the panic record is written.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					logger.Info("busy")
+				}
+			}
+		}()
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected panic boom, got %v", r)
+			}
+		}()
+		logger.Panic("boom")
+	}()
+	close(done)
+	wg.Wait()
+
+	output := buf.String()
+	if !strings.Contains(output, "boom") {
+		t.Error("panic record not written")
+	}
+	if !strings.Contains(output, logr.FieldKeyStack) {
+		t.Error("panic record missing stack")
+	}
+}
+
+// panicTarget panics while logging records with the message "bad".
+type panicTarget struct {
+	rawTarget
+}
+
+func (pt *panicTarget) Log(rec *logr.LogRec) {
+	if !rec.IsFlush() && rec.Msg() == "bad" {
+		panic("target failed")
+	}
+	pt.rawTarget.Log(rec)
+}
+
+func testOnTargetPanic(t *testing.T, concurrent bool) {
+	type targetPanic struct {
+		target logr.Target
+		msg    string
+		r      interface{}
+	}
+	var mux sync.Mutex
+	var panics []targetPanic
+	var errs []error
+	pt := &panicTarget{}
+	lgr := &logr.Logr{
+		ConcurrentFanout: concurrent,
+		OnTargetPanic: func(target logr.Target, rec *logr.LogRec, r interface{}) {
+			mux.Lock()
+			defer mux.Unlock()
+			panics = append(panics, targetPanic{target: target, msg: rec.Msg(), r: r})
+		},
+		OnLoggerError: func(err error) {
+			mux.Lock()
+			defer mux.Unlock()
+			errs = append(errs, err)
+		},
+	}
+	ft := newFieldsTarget()
+	_ = lgr.AddTarget(pt)
+	_ = lgr.AddTarget(ft)
+
+	logger := lgr.NewLogger()
+	logger.Info("ok")
+	logger.Info("bad")
+	logger.Info("ok again")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// the other target still receives every record.
+	if len(ft.msgs) != 3 {
+		t.Errorf("expected 3 records on the healthy target, got %v", ft.msgs)
+	}
+	if len(pt.recs) != 2 {
+		t.Errorf("expected the panicking target to keep logging, got %d records", len(pt.recs))
+	}
+	if n := lgr.Stats().TargetPanics; n != 1 {
+		t.Errorf("expected 1 target panic counted, got %d", n)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	if len(panics) != 1 || panics[0].target != pt || panics[0].msg != "bad" || panics[0].r != "target failed" {
+		t.Errorf("expected OnTargetPanic called once for the bad record, got %+v", panics)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "target failed") {
+		t.Errorf("expected the panic reported, got %v", errs)
+	}
+}
+
+func TestOnTargetPanic(t *testing.T) {
+	testOnTargetPanic(t, false)
+}
+
+func TestOnTargetPanicConcurrentFanout(t *testing.T) {
+	testOnTargetPanic(t, true)
+}
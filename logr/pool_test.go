@@ -0,0 +1,252 @@
+package logr_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+// checkRec returns an error if a log record's message does not start with
+// its "check" field, as happens when a record is reused while still in use.
+func checkRec(rec *logr.LogRec) error {
+	check, _ := rec.Fields()["check"].(string)
+	if check == "" || !strings.HasPrefix(rec.Msg(), check) {
+		return fmt.Errorf("record reused: message %q, check %q", rec.Msg(), check)
+	}
+	return nil
+}
+
+// checkTarget checks each log record written, optionally retaining them via
+// `logr.AcquireLogRec` to check again later.
+type checkTarget struct {
+	logr.Basic
+	retain bool
+
+	mux      sync.Mutex
+	written  int
+	retained []*logr.LogRec
+	errs     []error
+}
+
+func newCheckTarget(retain bool) *checkTarget {
+	ct := &checkTarget{retain: retain}
+	ct.Basic.Start(ct, ct, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	return ct
+}
+
+func (ct *checkTarget) Write(rec *logr.LogRec) error {
+	ct.mux.Lock()
+	defer ct.mux.Unlock()
+	ct.written++
+	if err := checkRec(rec); err != nil {
+		ct.errs = append(ct.errs, err)
+	}
+	if ct.retain {
+		ct.retained = append(ct.retained, logr.AcquireLogRec(rec))
+	}
+	return nil
+}
+
+// release checks the retained log records again, then releases them.
+func (ct *checkTarget) release() []error {
+	ct.mux.Lock()
+	defer ct.mux.Unlock()
+	errs := ct.errs
+	for _, rec := range ct.retained {
+		if err := checkRec(rec); err != nil {
+			errs = append(errs, err)
+		}
+		logr.ReleaseLogRec(rec)
+	}
+	ct.retained = nil
+	return errs
+}
+
+// rawTarget is not built on `logr.Basic`, and retains every log record
+// delivered to it without acquiring it.
+type rawTarget struct {
+	mux  sync.Mutex
+	recs []*logr.LogRec
+}
+
+func (rt *rawTarget) SetName(name string) {}
+
+func (rt *rawTarget) IsLevelEnabled(lvl logr.Level) (bool, bool) {
+	return lvl.ID <= logr.Info.ID, false
+}
+
+func (rt *rawTarget) Formatter() logr.Formatter {
+	return &format.Plain{}
+}
+
+func (rt *rawTarget) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		logr.ForwardFlush(rec)
+		return
+	}
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	rt.recs = append(rt.recs, rec)
+}
+
+func (rt *rawTarget) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+var crashLine = regexp.MustCompile(`\| info \| (.*) \| check="?([^"]*)"?$`)
+
+func testPooledFanout(t *testing.T, concurrent bool) {
+	lgr := &logr.Logr{PoolLogRecs: true, ConcurrentFanout: concurrent}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	plain := &format.Plain{Delim: " | "}
+
+	checker := newCheckTarget(false)
+	retainer := newCheckTarget(true)
+	deduped := newCheckTarget(false)
+	dedup := target.NewDedupTarget(deduped, 20*time.Millisecond)
+	dedup.SetHash(target.DedupMsg)
+	slow := test.NewSlowTarget(filter, plain, &test.Buffer{}, 10)
+	slow.Delay = 100 * time.Microsecond
+	crash := target.NewCrashBufferTarget(filter, plain, 50, 100)
+	raw := &rawTarget{}
+	for _, tgt := range []logr.Target{checker, retainer, dedup, slow, crash, raw} {
+		if err := lgr.AddTarget(tgt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const workers = 8
+	const records = 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			logger := lgr.NewLogger()
+			for i := 0; i < records; i++ {
+				msg := fmt.Sprintf("w%d-%d", w, i)
+				logger.WithFields(logr.Fields{"check": msg}).Info(msg)
+				// duplicates, retained by the dedup target for its summaries.
+				logger.WithFields(logr.Fields{"check": "dup"}).Info("dup")
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if checker.written != workers*records*2 {
+		t.Errorf("expected %d records written, got %d", workers*records*2, checker.written)
+	}
+	for _, ct := range []*checkTarget{checker, retainer, deduped} {
+		for _, err := range ct.release() {
+			t.Error(err)
+		}
+	}
+	for _, rec := range raw.recs {
+		if err := checkRec(rec); err != nil {
+			t.Error(err)
+		}
+	}
+	if len(raw.recs) != workers*records*2 {
+		t.Errorf("expected %d records retained unacquired, got %d", workers*records*2, len(raw.recs))
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(crash.Dump())), "\n") {
+		m := crashLine.FindStringSubmatch(line)
+		if m == nil || !strings.HasPrefix(m[1], m[2]) {
+			t.Errorf("crash buffer line from a reused record: %q", line)
+		}
+	}
+}
+
+func TestPoolLogRecs(t *testing.T) {
+	testPooledFanout(t, false)
+}
+
+func TestPoolLogRecsConcurrentFanout(t *testing.T) {
+	testPooledFanout(t, true)
+}
+
+// orderTarget records the sequence of records per worker, failing on records
+// out of order.
+type orderTarget struct {
+	logr.Basic
+
+	mux  sync.Mutex
+	next map[int]int
+	errs []error
+}
+
+func newOrderTarget(maxQueue int) *orderTarget {
+	st := &orderTarget{next: make(map[int]int)}
+	st.Basic.Start(st, st, &logr.StdFilter{Lvl: logr.Info}, nil, maxQueue)
+	return st
+}
+
+func (st *orderTarget) Write(rec *logr.LogRec) error {
+	w, _ := rec.Fields()["w"].(int)
+	i, _ := rec.Fields()["i"].(int)
+	st.mux.Lock()
+	defer st.mux.Unlock()
+	if i != st.next[w] {
+		st.errs = append(st.errs, fmt.Errorf("worker %d: expected record %d, got %d", w, st.next[w], i))
+	}
+	st.next[w] = i + 1
+	return nil
+}
+
+func TestConcurrentFanoutOrderPerTarget(t *testing.T) {
+	lgr := &logr.Logr{ConcurrentFanout: true}
+	targets := []*orderTarget{newOrderTarget(100), newOrderTarget(100), newOrderTarget(1)}
+	for _, st := range targets {
+		_ = lgr.AddTarget(st)
+	}
+	// a slow target with a tiny queue blocks its `Log` most of the time.
+	slow := test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{}, &test.Buffer{}, 1)
+	slow.Delay = 50 * time.Microsecond
+	_ = lgr.AddTarget(slow)
+	counter := newCountTarget("count")
+	_ = lgr.AddTarget(counter)
+
+	const workers = 4
+	const records = 250
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			logger := lgr.NewLogger()
+			for i := 0; i < records; i++ {
+				logger.WithFields(logr.Fields{"w": w, "i": i}).Info("ordered")
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	for _, st := range targets {
+		for _, err := range st.errs {
+			t.Error(err)
+		}
+		for w := 0; w < workers; w++ {
+			if st.next[w] != records {
+				t.Errorf("worker %d: expected %d records, got %d", w, records, st.next[w])
+			}
+		}
+	}
+	if n := counter.written(); n != workers*records {
+		t.Errorf("expected %d records delivered, got %d", workers*records, n)
+	}
+}
@@ -0,0 +1,25 @@
+package logr
+
+// WithPrefix creates a new `Logger` that prepends prefix, followed by a
+// space, to the message of every log record, e.g. `WithPrefix("[auth]")`
+// logs "[auth] login failed". Prefixes compose: a logger derived from one
+// with a prefix adds its own after the parent's, e.g. "[auth] [oauth] ...".
+// An empty prefix returns the logger unchanged.
+func (logger Logger) WithPrefix(prefix string) Logger {
+	if prefix == "" {
+		return logger
+	}
+	l := logger
+	if l.prefix == "" {
+		l.prefix = prefix
+	} else {
+		l.prefix = l.prefix + " " + prefix
+	}
+	return l
+}
+
+// Prefix returns the message prefix of this Logger, including the prefixes of
+// any loggers it was derived from, or empty string if none. See `WithPrefix`.
+func (logger Logger) Prefix() string {
+	return logger.prefix
+}
@@ -0,0 +1,10 @@
+module github.com/mattermost/logr/prometheus
+
+go 1.12
+
+require (
+	github.com/mattermost/logr v1.0.9
+	github.com/prometheus/client_golang v1.7.1
+)
+
+replace github.com/mattermost/logr => ../
@@ -0,0 +1,214 @@
+// Package prometheus provides a `logr.MetricsCollector` that records Logr and
+// target metrics in a Prometheus registry. It is a separate module so that
+// Logr itself does not depend on the Prometheus client.
+package prometheus
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/mattermost/logr"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultNamespace prefixes metric names when `Options.Namespace` is empty.
+const DefaultNamespace = "logr"
+
+// targetLabel is the label holding the target name. The Logr's own queue and
+// counters use the target name "_logr".
+const targetLabel = "target"
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes all metric names, e.g. "myapp" yields
+	// "myapp_logged_total". Defaults to DefaultNamespace.
+	Namespace string
+
+	// Subsystem, when not empty, is added between the namespace and metric
+	// name, e.g. "myapp_log_logged_total".
+	Subsystem string
+
+	// ConstLabels are added to every metric.
+	ConstLabels prom.Labels
+
+	// Registry receives the metrics. A new registry is created when nil.
+	Registry *prom.Registry
+}
+
+// Collector implements `logr.MetricsCollector`, `logr.TimingCollector`,
+// `logr.EventCollector` and `logr.LatencyCollector`, keeping one time series
+// per target for each metric:
+//
+//	<ns>_queue_size           gauge, log records queued
+//	<ns>_logged_total         counter, log records written
+//	<ns>_errors_total         counter, errors writing log records
+//	<ns>_dropped_total        counter, log records dropped on a full queue
+//	<ns>_blocked_total        counter, times a full queue blocked logging
+//	<ns>_log_seconds_total    counter, time spent delivering log records
+//	<ns>_events_total         counter, count-only log records, by name
+//	<ns>_latency_seconds      histogram, enqueue to delivery to all targets
+//
+// Pass it to `Logr.SetMetricsCollector` and serve `Handler` on `/metrics`.
+type Collector struct {
+	opts     Options
+	registry *prom.Registry
+
+	queueSize *prom.GaugeVec
+	logged    *prom.CounterVec
+	errors    *prom.CounterVec
+	dropped   *prom.CounterVec
+	blocked   *prom.CounterVec
+	logTime   *prom.CounterVec
+	events    *prom.CounterVec
+
+	latencyMux sync.Mutex
+	latency    prom.Histogram // created on first use, with the Logr's buckets
+}
+
+// NewCollector creates a Collector and registers its metrics with the
+// registry in opts. An error is returned if the metrics cannot be registered,
+// e.g. because metrics with the same names are already registered.
+func NewCollector(opts Options) (*Collector, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+	if opts.Registry == nil {
+		opts.Registry = prom.NewRegistry()
+	}
+
+	gaugeVec := func(name, help string) *prom.GaugeVec {
+		return prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+		}, []string{targetLabel})
+	}
+	counterVec := func(name, help string, label string) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+		}, []string{label})
+	}
+
+	c := &Collector{
+		opts:      opts,
+		registry:  opts.Registry,
+		queueSize: gaugeVec("queue_size", "Number of log records queued."),
+		logged:    counterVec("logged_total", "Number of log records written.", targetLabel),
+		errors:    counterVec("errors_total", "Number of errors writing log records.", targetLabel),
+		dropped:   counterVec("dropped_total", "Number of log records dropped due to a full queue.", targetLabel),
+		blocked:   counterVec("blocked_total", "Number of times logging blocked on a full queue.", targetLabel),
+		logTime:   counterVec("log_seconds_total", "Time spent delivering log records, in seconds.", targetLabel),
+		events:    counterVec("events_total", "Number of count-only log records.", "name"),
+	}
+
+	collectors := c.Collectors()
+	for i, collector := range collectors {
+		if err := c.registry.Register(collector); err != nil {
+			for _, registered := range collectors[:i] {
+				c.registry.Unregister(registered)
+			}
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Registry returns the registry holding the metrics.
+func (c *Collector) Registry() *prom.Registry {
+	return c.registry
+}
+
+// Collectors returns the Prometheus collectors for the metrics, e.g. to
+// register them with another registry. The latency histogram is not included
+// since it is created on first use, see `LatencyHistogram`.
+func (c *Collector) Collectors() []prom.Collector {
+	return []prom.Collector{c.queueSize, c.logged, c.errors, c.dropped, c.blocked, c.logTime, c.events}
+}
+
+// Handler returns an HTTP handler serving the registry's metrics, e.g. on `/metrics`.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// QueueSizeGauge returns a Gauge that will be updated by the named target.
+func (c *Collector) QueueSizeGauge(target string) (logr.Gauge, error) {
+	return c.queueSize.GetMetricWithLabelValues(targetName(target))
+}
+
+// LoggedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) LoggedCounter(target string) (logr.Counter, error) {
+	return c.logged.GetMetricWithLabelValues(targetName(target))
+}
+
+// ErrorCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) ErrorCounter(target string) (logr.Counter, error) {
+	return c.errors.GetMetricWithLabelValues(targetName(target))
+}
+
+// DroppedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) DroppedCounter(target string) (logr.Counter, error) {
+	return c.dropped.GetMetricWithLabelValues(targetName(target))
+}
+
+// BlockedCounter returns a Counter that will be incremented by the named target.
+func (c *Collector) BlockedCounter(target string) (logr.Counter, error) {
+	return c.blocked.GetMetricWithLabelValues(targetName(target))
+}
+
+// LogTimeCounter returns a Counter that will be increased by the number of
+// seconds spent delivering log records to the named target.
+func (c *Collector) LogTimeCounter(target string) (logr.Counter, error) {
+	return c.logTime.GetMetricWithLabelValues(targetName(target))
+}
+
+// EventCounter returns a Counter that will be incremented for each count-only
+// log record with the specified name.
+func (c *Collector) EventCounter(name string) (logr.Counter, error) {
+	if name == "" {
+		return nil, errors.New("event name cannot be empty")
+	}
+	return c.events.GetMetricWithLabelValues(name)
+}
+
+// LatencyHistogram returns a Histogram with the bucket upper bounds, in
+// seconds, that will observe the latency of log records. The histogram is
+// created and registered on the first call; later calls, e.g. from another
+// Logr sharing this Collector, return the same histogram and buckets.
+func (c *Collector) LatencyHistogram(buckets []float64) (logr.Histogram, error) {
+	c.latencyMux.Lock()
+	defer c.latencyMux.Unlock()
+
+	if c.latency != nil {
+		return c.latency, nil
+	}
+	latency := prom.NewHistogram(prom.HistogramOpts{
+		Namespace:   c.opts.Namespace,
+		Subsystem:   c.opts.Subsystem,
+		Name:        "latency_seconds",
+		Help:        "Time from enqueueing log records to delivering them to all targets, in seconds.",
+		ConstLabels: c.opts.ConstLabels,
+		Buckets:     buckets,
+	})
+	if err := c.registry.Register(latency); err != nil {
+		return nil, err
+	}
+	c.latency = latency
+	return latency, nil
+}
+
+// targetName returns the label value for a target name, which may be empty
+// for unnamed targets.
+func targetName(target string) string {
+	if target == "" {
+		return "unnamed"
+	}
+	return target
+}
@@ -0,0 +1,92 @@
+package prometheus_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/prometheus"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorCountsLogging(t *testing.T) {
+	collector, err := prometheus.NewCollector(prometheus.Options{Namespace: "myapp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	out := target.NewWriterTarget(filter, &format.Plain{Delim: " | "}, ioutil.Discard, 100)
+	out.SetName("out")
+	bad := test.NewFailingTarget(filter, &format.Plain{Delim: " | "})
+	bad.SetName("bad")
+	_ = lgr.AddTarget(out)
+	_ = lgr.AddTarget(bad)
+	if err := lgr.SetMetricsCollector(collector); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Info("counted")
+	}
+	logger.Debug("filtered out")
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+
+	logged, err := collector.LoggedCounter("out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := testutil.ToFloat64(logged.(prom.Collector)); v != 3 {
+		t.Errorf("expected 3 logged, got %v", v)
+	}
+
+	// target errors are also reported to the Logr, counted under "_logr".
+	expected := `
+# HELP myapp_errors_total Number of errors writing log records.
+# TYPE myapp_errors_total counter
+myapp_errors_total{target="_logr"} 3
+myapp_errors_total{target="bad"} 3
+myapp_errors_total{target="out"} 0
+`
+	if err := testutil.GatherAndCompare(collector.Registry(), strings.NewReader(expected), "myapp_errors_total"); err != nil {
+		t.Error(err)
+	}
+
+	// the handler serves the same metrics.
+	rec := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `myapp_logged_total{target="out"} 3`) {
+		t.Errorf("metrics missing logged count:\n%s", body)
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorDuplicateRegistration(t *testing.T) {
+	registry := prom.NewRegistry()
+	if _, err := prometheus.NewCollector(prometheus.Options{Registry: registry}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prometheus.NewCollector(prometheus.Options{Registry: registry}); err == nil {
+		t.Error("expected an error registering the same metrics twice")
+	}
+
+	// a failed registration leaves no metrics behind, so a collector with
+	// another namespace can still be registered.
+	if _, err := prometheus.NewCollector(prometheus.Options{Registry: registry, Namespace: "other"}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,97 @@
+package logr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tb := logr.NewTokenBucket(10, 2)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// starts full.
+	if !tb.Allow(now) || !tb.Allow(now) {
+		t.Fatal("expected burst of 2")
+	}
+	if tb.Allow(now) {
+		t.Fatal("expected empty bucket")
+	}
+
+	// refills at 10 per second, up to the burst.
+	if !tb.Allow(now.Add(100 * time.Millisecond)) {
+		t.Error("expected a token after 100ms")
+	}
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if !tb.Allow(now) {
+			t.Errorf("expected token %d after refill", i)
+		}
+	}
+	if tb.Allow(now) {
+		t.Error("expected refill capped at burst")
+	}
+
+	// a clock going backwards does not refill.
+	if tb.Allow(now.Add(-time.Minute)) {
+		t.Error("expected no refill from an earlier time")
+	}
+}
+
+func TestTargetRateLimit(t *testing.T) {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	limited := target.NewWriterTarget(filter, &format.Plain{}, &test.Buffer{}, 100)
+	unlimited := target.NewWriterTarget(filter, &format.Plain{}, &test.Buffer{}, 100)
+	_ = lgr.AddTarget(limited)
+	_ = lgr.AddTarget(unlimited)
+	if err := lgr.SetTargetRateLimit(limited, 0.001, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 10; i++ {
+		logger.Info("limited")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if shed := lgr.TargetShedCount(limited); shed != 7 {
+		t.Errorf("expected 7 records shed, got %d", shed)
+	}
+	if shed := lgr.TargetShedCount(unlimited); shed != 0 {
+		t.Errorf("expected no records shed, got %d", shed)
+	}
+}
+
+func TestTargetRateLimitShedMetric(t *testing.T) {
+	lgr := &logr.Logr{}
+	limited := target.NewWriterTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{}, &test.Buffer{}, 100)
+	limited.SetName("webhook")
+	_ = lgr.AddTarget(limited)
+	collector := test.NewTestMetricsCollector()
+	if err := lgr.SetMetricsCollector(collector); err != nil {
+		t.Fatal(err)
+	}
+	if err := lgr.SetTargetRateLimit(limited, 0.001, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info("limited")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// counted under the target's metrics name.
+	if n := collector.Get("webhook/shed").Dropped; n != 4 {
+		t.Errorf("expected 4 shed counted for webhook/shed, got %v", n)
+	}
+}
@@ -0,0 +1,78 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// recPool holds log records for reuse when `Logr.PoolLogRecs` is true.
+var recPool = sync.Pool{
+	New: func() interface{} { return &LogRec{} },
+}
+
+// logRecReleaser is implemented by targets that release each log record
+// passed to `Log` once done with it, such as targets built on `Basic`.
+type logRecReleaser interface {
+	releasesLogRecs()
+}
+
+// releasesLogRecs marks targets built on Basic as releasing log records
+// after writing them, allowing them to be pooled.
+func (b *Basic) releasesLogRecs() {}
+
+// newPooledLogRec returns a zeroed log record from the pool, referenced once
+// by the Logr pipeline.
+func newPooledLogRec() *LogRec {
+	rec := recPool.Get().(*LogRec)
+	rec.pooled = true
+	rec.refs = 1
+	return rec
+}
+
+// AcquireLogRec adds a reference to a log record, preventing it from being
+// reused until released via `ReleaseLogRec`, and returns it.
+//
+// When `Logr.PoolLogRecs` is true, log records are returned to a pool once the
+// Logr and every target it delivered the record to are done with it. Targets
+// built on `Basic` are done once `RecordWriter.Write` returns; a `Write`
+// implementation that retains the record, e.g. to batch records, must call
+// AcquireLogRec first and `ReleaseLogRec` when done. Records delivered to any
+// other target are never reused, so such targets may retain them. Has no
+// effect on records that are not pooled.
+func AcquireLogRec(rec *LogRec) *LogRec {
+	if rec != nil && rec.pooled {
+		atomic.AddInt32(&rec.refs, 1)
+	}
+	return rec
+}
+
+// ReleaseLogRec removes a reference added via `AcquireLogRec`, returning the
+// log record to the pool when no references remain. The record must not be
+// used after releasing it. Has no effect on records that are not pooled.
+func ReleaseLogRec(rec *LogRec) {
+	if rec == nil || !rec.pooled {
+		return
+	}
+	if atomic.AddInt32(&rec.refs, -1) != 0 {
+		return
+	}
+	if atomic.LoadInt32(&rec.pinned) == 1 {
+		return // delivered to a target that may retain it.
+	}
+	*rec = LogRec{}
+	recPool.Put(rec)
+}
+
+// retainFor adds a reference to a pooled log record for a target about to
+// receive it, or pins the record so it is never reused if the target does not
+// release records.
+func retainFor(target Target, rec *LogRec) {
+	if !rec.pooled {
+		return
+	}
+	if _, ok := target.(logRecReleaser); ok {
+		atomic.AddInt32(&rec.refs, 1)
+		return
+	}
+	atomic.StoreInt32(&rec.pinned, 1)
+}
@@ -0,0 +1,82 @@
+package logr
+
+import (
+	"strings"
+)
+
+// Redactor inspects a structured field of a log record and returns it as is,
+// modified, e.g. with a masked value, or `DropField` to remove it.
+type Redactor func(field Field) Field
+
+// DropField is returned by a Redactor to remove the field from the log
+// record. Any field with an empty key is dropped.
+var DropField = Field{}
+
+// RedactedValue replaces the value of any field deemed sensitive.
+const RedactedValue = "********"
+
+// DefaultRedactKeys is the default list of key patterns used to identify
+// sensitive values when `Logr.RedactKeys` is nil.
+var DefaultRedactKeys = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "credential", "privatekey", "private_key"}
+
+// shouldRedactKey returns true if the key matches any of the configured
+// redaction key patterns. Patterns are matched as case-insensitive substrings.
+func (logr *Logr) shouldRedactKey(key string) bool {
+	patterns := logr.RedactKeys
+	if patterns == nil {
+		patterns = DefaultRedactKeys
+	}
+	if len(patterns) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		if p != "" && strings.Contains(key, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRedactor adds a Redactor applied to every structured field of each log
+// record when the record is prepared for targets. Redactors run in the order
+// added, each receiving the field returned by the previous one; once a field
+// is dropped later redactors are skipped. Fields of records already queued
+// may or may not be redacted. Records are not copied when no redactors are
+// added.
+func (logr *Logr) AddRedactor(redactor Redactor) {
+	if redactor == nil {
+		return
+	}
+	logr.redactMux.Lock()
+	defer logr.redactMux.Unlock()
+	logr.redactors = append(logr.redactors, redactor)
+}
+
+// redactFields applies the redactors to the fields, returning a new Fields if
+// any redactors are added, otherwise the fields as is.
+func (logr *Logr) redactFields(flds Fields) Fields {
+	if len(flds) == 0 {
+		return flds
+	}
+	logr.redactMux.RLock()
+	redactors := logr.redactors
+	logr.redactMux.RUnlock()
+	if len(redactors) == 0 {
+		return flds
+	}
+
+	redacted := make(Fields, len(flds))
+	for k, v := range flds {
+		f := Field{Key: k, Value: v}
+		for _, r := range redactors {
+			if f = r(f); f.Key == "" {
+				break
+			}
+		}
+		if f.Key != "" {
+			redacted[f.Key] = f.Value
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,88 @@
+package logr_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+)
+
+// fieldsTarget records the message and fields of each record written.
+type fieldsTarget struct {
+	logr.Basic
+
+	mux    sync.Mutex
+	msgs   []string
+	fields []logr.Fields
+}
+
+func newFieldsTarget() *fieldsTarget {
+	ft := &fieldsTarget{}
+	ft.Basic.Start(ft, ft, &logr.StdFilter{Lvl: logr.Debug}, nil, 100)
+	return ft
+}
+
+func (ft *fieldsTarget) Write(rec *logr.LogRec) error {
+	ft.mux.Lock()
+	defer ft.mux.Unlock()
+	ft.msgs = append(ft.msgs, rec.Msg())
+	ft.fields = append(ft.fields, rec.Fields())
+	return nil
+}
+
+func TestAddRedactor(t *testing.T) {
+	lgr := &logr.Logr{}
+	ft := newFieldsTarget()
+	_ = lgr.AddTarget(ft)
+
+	var mux sync.Mutex
+	var afterDrop []string
+	lgr.AddRedactor(func(field logr.Field) logr.Field {
+		if strings.Contains(field.Key, "password") {
+			field.Value = logr.RedactedValue
+		}
+		return field
+	})
+	lgr.AddRedactor(func(field logr.Field) logr.Field {
+		if field.Key == "internal" {
+			return logr.DropField
+		}
+		return field
+	})
+	lgr.AddRedactor(func(field logr.Field) logr.Field {
+		mux.Lock()
+		defer mux.Unlock()
+		afterDrop = append(afterDrop, field.Key)
+		// sees the value masked by the first redactor.
+		if field.Key == "db_password" && field.Value != logr.RedactedValue {
+			t.Errorf("expected redactors applied in order, got %v", field.Value)
+		}
+		return field
+	})
+
+	fields := logr.Fields{"db_password": "hunter2", "internal": "x", "user": "sam"}
+	lgr.NewLogger().WithFields(fields).Info("login")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if len(ft.fields) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(ft.fields))
+	}
+	got := ft.fields[0]
+	if got["db_password"] != logr.RedactedValue || got["user"] != "sam" {
+		t.Errorf("expected the password masked and user kept, got %v", got)
+	}
+	if _, ok := got["internal"]; ok {
+		t.Errorf("expected the internal field dropped, got %v", got)
+	}
+	for _, key := range afterDrop {
+		if key == "internal" {
+			t.Error("expected redactors after a drop to be skipped")
+		}
+	}
+	if fields["db_password"] != "hunter2" || fields["internal"] != "x" {
+		t.Errorf("expected the logged fields unchanged, got %v", fields)
+	}
+}
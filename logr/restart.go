@@ -0,0 +1,81 @@
+package logr
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDegraded is returned by `Flush` once the Logr is degraded, see `IsDegraded`.
+var ErrDegraded = errors.New("logr degraded, log records are no longer processed")
+
+// IsDegraded returns true once the goroutine processing the Logr queue has
+// panicked more than `MaxRestarts` times within `RestartWindow`. A degraded
+// Logr drops all log records, counting them as dropped in `Stats`, and
+// cannot be recovered; its targets can still be shut down via `Shutdown`.
+func (logr *Logr) IsDegraded() bool {
+	return atomic.LoadInt32(&logr.degraded) == 1
+}
+
+// allowRestart records a restart of the processing goroutine at now, returning
+// false if that would exceed `MaxRestarts` within `RestartWindow`.
+func (logr *Logr) allowRestart(now time.Time) bool {
+	maxRestarts := logr.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = DefaultMaxRestarts
+	}
+	window := logr.RestartWindow
+	if window <= 0 {
+		window = DefaultRestartWindow
+	}
+
+	logr.restartMux.Lock()
+	defer logr.restartMux.Unlock()
+
+	// drop restarts that have aged out of the window.
+	recent := logr.restarts[:0]
+	for _, t := range logr.restarts {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	logr.restarts = recent
+
+	if len(logr.restarts) >= maxRestarts {
+		return false
+	}
+	logr.restarts = append(logr.restarts, now)
+	return true
+}
+
+// degrade marks the Logr degraded, reports it, and discards the log records
+// queued until the queue is closed by `Shutdown`, so that blocked enqueues and
+// flushes return.
+func (logr *Logr) degrade(in chan *LogRec) {
+	atomic.StoreInt32(&logr.degraded, 1)
+	logr.ReportError(fmt.Errorf("logr processing stopped after repeated panics: %w", ErrDegraded))
+
+	for {
+		for rec := range in {
+			logr.untrack(rec)
+			logr.dropDegraded(rec)
+		}
+		if next := logr.readQueue(); next != in && next != nil {
+			in = next
+			continue
+		}
+		break
+	}
+	close(logr.done)
+}
+
+// dropDegraded drops a log record, or fails a flush, for a degraded Logr.
+func (logr *Logr) dropDegraded(rec *LogRec) {
+	if rec.flush != nil {
+		rec.flushErr = ErrDegraded
+		rec.flush <- struct{}{}
+		return
+	}
+	logr.stats.inc(statDropped)
+}
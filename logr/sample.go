@@ -0,0 +1,86 @@
+package logr
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// durationSampler allows at most one log record per window.
+type durationSampler struct {
+	window     int64 // nanoseconds
+	last       int64 // unix nanoseconds of last emitted record
+	suppressed uint64
+}
+
+// allow returns true if a record can be emitted at time now, along with the
+// number of records suppressed since the last emitted record.
+func (s *durationSampler) allow(now int64) (bool, uint64) {
+	for {
+		last := atomic.LoadInt64(&s.last)
+		if last != 0 && now-last < s.window {
+			atomic.AddUint64(&s.suppressed, 1)
+			return false, 0
+		}
+		if atomic.CompareAndSwapInt64(&s.last, last, now) {
+			return true, atomic.SwapUint64(&s.suppressed, 0)
+		}
+	}
+}
+
+// EveryDuration creates a new `Logger` that emits at most one log record per
+// duration d; the first record in each window is logged and the rest are
+// suppressed. When records have been suppressed, the next emitted record
+// includes their count in the `FieldKeySuppressed` field and the sampling rate,
+// suppressed count plus one, in the reserved `FieldKeySampleRate` field.
+// The window is shared by all Loggers derived from the returned Logger, so
+// create it once per call site, e.g. in a package variable or before a loop.
+func (logger Logger) EveryDuration(d time.Duration) Logger {
+	l := logger
+	l.sampler = &durationSampler{window: int64(d)}
+	return l
+}
+
+// sample applies any sampling to this Logger, returning false if the
+// record should be suppressed.
+func (logger Logger) sample() (Logger, bool) {
+	if logger.sampler == nil {
+		return logger, true
+	}
+	ok, suppressed := logger.sampler.allow(logger.logr.Now().UnixNano())
+	if ok && suppressed > 0 {
+		logger = logger.WithField(FieldKeySuppressed, suppressed)
+		logger.sampleRate = suppressed + 1
+	}
+	return logger, ok
+}
+
+// WithSampleRate returns a shallow copy of the log record with the sampling
+// rate set in the reserved `FieldKeySampleRate` field, indicating the record
+// represents 1 in rate occurrences so aggregators can weight it. This is used
+// by targets that sample log records. A rate of 1 or less clears the field.
+func (rec *LogRec) WithSampleRate(rate uint64) *LogRec {
+	r := rec.WithTime(rec.Time())
+	reserved := make(Fields, len(rec.reserved)+1)
+	for k, v := range rec.reserved {
+		reserved[k] = v
+	}
+	if rate > 1 {
+		reserved[FieldKeySampleRate] = rate
+	} else {
+		delete(reserved, FieldKeySampleRate)
+	}
+	if len(reserved) == 0 {
+		reserved = nil
+	}
+	r.reserved = reserved
+	return r
+}
+
+// SampleRate returns the sampling rate of this log record, N meaning the
+// record represents 1 in N occurrences, or 1 if the record was not sampled.
+func (rec *LogRec) SampleRate() uint64 {
+	if v, ok := rec.reserved[FieldKeySampleRate].(uint64); ok {
+		return v
+	}
+	return 1
+}
@@ -0,0 +1,125 @@
+package logr_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/test"
+)
+
+// shutdownTracker records whether its target was shut down.
+type shutdownTracker struct {
+	*test.SlowTarget
+	shutdown int32
+}
+
+func (st *shutdownTracker) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&st.shutdown, 1)
+	return st.SlowTarget.Shutdown(ctx)
+}
+
+func TestShutdownWithContextExpired(t *testing.T) {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	formatter := &format.Plain{Delim: " | "}
+
+	var targets []*shutdownTracker
+	for _, name := range []string{"slow1", "slow2"} {
+		st := &shutdownTracker{SlowTarget: test.NewSlowTarget(filter, formatter, &test.Buffer{}, 1000)}
+		st.Delay = time.Millisecond * 50
+		st.SetName(name)
+		if err := lgr.AddTarget(st); err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, st)
+	}
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 20; i++ {
+		logger.Info("queued")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	err := lgr.ShutdownWithContext(ctx)
+
+	if !logr.IsTimeoutError(err) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	for _, st := range targets {
+		if atomic.LoadInt32(&st.shutdown) != 1 {
+			t.Errorf("target %s not shut down", st.Name())
+		}
+		if !strings.Contains(err.Error(), st.Name()) {
+			t.Errorf("error does not name target %s: %v", st.Name(), err)
+		}
+	}
+}
+
+func TestBasicLogAfterShutdown(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	st := test.NewSlowTarget(filter, &format.Plain{Delim: " | "}, buf, 1000)
+	st.Delay = 0
+	if err := lgr.AddTarget(st); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := st.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	// the record is dropped rather than sent on the closed queue.
+	lgr.NewLogger().Info("after shutdown")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if buf.String() != "" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestShutdownWithContextNamesIncompleteTargets(t *testing.T) {
+	lgr := &logr.Logr{}
+	formatter := &format.Plain{Delim: " | "}
+
+	// the fast target only accepts errors, so its queue stays empty.
+	fast := test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Error}, formatter, &test.Buffer{}, 1000)
+	fast.Delay = 0
+	fast.SetName("fast")
+	slow := test.NewSlowTarget(&logr.StdFilter{Lvl: logr.Info}, formatter, &test.Buffer{}, 1000)
+	slow.Delay = time.Millisecond * 50
+	slow.SetName("slow")
+	// the slow target is shut down first, so ctx is done by the time the
+	// fast one would be if shut down in turn.
+	_ = lgr.AddTarget(slow)
+	_ = lgr.AddTarget(fast)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 20; i++ {
+		logger.Info("queued")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	err := lgr.ShutdownWithContext(ctx)
+
+	if !logr.IsTimeoutError(err) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "slow") {
+		t.Errorf("error does not name the slow target: %v", err)
+	}
+	if strings.Contains(err.Error(), "fast") {
+		t.Errorf("error names the fast target, which finished: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package logr
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxSnapshotDepth bounds recursion when converting nested structs and maps.
+const maxSnapshotDepth = 16
+
+// LogSnapshot logs a struct or map, such as an application config, as
+// structured fields under key. Nested structs and maps, including those
+// within slices and arrays, become nested `Fields`. Values are masked with
+// `RedactedValue` when the struct field has a `log:"redact"` tag or the field
+// name/map key matches one of the `Logr.RedactKeys` patterns. Struct fields
+// tagged `log:"-"` are skipped, and `log:"name"` renames a field.
+func (logger Logger) LogSnapshot(lvl Level, msg string, key string, v interface{}) {
+	status := logger.logr.IsLevelEnabled(lvl)
+	if !status.Enabled {
+		return
+	}
+	logger.WithField(key, logger.logr.snapshot(reflect.ValueOf(v), 0)).Log(lvl, msg)
+}
+
+// snapshot converts a value to Fields (structs and maps), or returns
+// the value unchanged.
+func (logr *Logr) snapshot(val reflect.Value, depth int) interface{} {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return nil
+	}
+	if depth >= maxSnapshotDepth {
+		return fmt.Sprintf("%v", val)
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		sfs := cachedStructFields(val.Type())
+		flds := make(Fields, len(sfs))
+		for _, sf := range sfs {
+			if sf.redact || logr.shouldRedactKey(sf.name) {
+				flds[sf.name] = RedactedValue
+				continue
+			}
+			flds[sf.name] = logr.snapshot(val.Field(sf.index), depth+1)
+		}
+		return flds
+	case reflect.Map:
+		flds := make(Fields, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			name := fmt.Sprintf("%v", iter.Key().Interface())
+			if logr.shouldRedactKey(name) {
+				flds[name] = RedactedValue
+				continue
+			}
+			flds[name] = logr.snapshot(iter.Value(), depth+1)
+		}
+		return flds
+	case reflect.Slice, reflect.Array:
+		if !snapshotElems(val.Type().Elem()) {
+			break
+		}
+		elems := make([]interface{}, val.Len())
+		for i := range elems {
+			elems[i] = logr.snapshot(val.Index(i), depth+1)
+		}
+		return elems
+	}
+
+	if val.CanInterface() {
+		return val.Interface()
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+// snapshotElems returns true if slice or array elements of type typ may
+// contain structs or maps, which must be converted so that sensitive values
+// are redacted.
+func snapshotElems(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// logTagOpts are the options parsed from a `log` struct tag.
+type logTagOpts struct {
+	redact bool
+}
+
+// parseLogTag returns the field name and options from a `log` struct tag of
+// the form `log:"name,redact"`. The struct field name is used if no name is
+// provided, and "-" means the field should be skipped. A lone `log:"redact"`
+// is treated as an option rather than a name.
+func parseLogTag(sf reflect.StructField) (string, logTagOpts) {
+	var opts logTagOpts
+	name := sf.Name
+
+	tag, ok := sf.Tag.Lookup("log")
+	if !ok {
+		return name, opts
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] == "redact" {
+		parts = append([]string{""}, parts...)
+	}
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		if opt == "redact" {
+			opts.redact = true
+		}
+	}
+	return name, opts
+}
@@ -0,0 +1,75 @@
+package logr_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+type snapshotUser struct {
+	Name     string
+	Password string `log:"redact"`
+	APIToken string
+}
+
+type snapshotConfig struct {
+	Users    []snapshotUser
+	Admins   [2]*snapshotUser
+	Settings []map[string]string
+	Ports    []int
+}
+
+func TestSnapshotRedactsSliceElements(t *testing.T) {
+	cfg := snapshotConfig{
+		Users:    []snapshotUser{{Name: "bob", Password: "hunter2", APIToken: "tok-bob"}},
+		Admins:   [2]*snapshotUser{{Name: "alice", Password: "swordfish", APIToken: "tok-alice"}},
+		Settings: []map[string]string{{"db_secret": "s3cr3t", "mode": "fast"}},
+		Ports:    []int{8065, 8067},
+	}
+	anon := []struct {
+		Password string `log:"redact"`
+	}{{Password: "letmein"}}
+
+	tests := []struct {
+		name string
+		log  func(logger logr.Logger)
+	}{
+		{name: "LogSnapshot", log: func(logger logr.Logger) {
+			logger.LogSnapshot(logr.Info, "config", "cfg", cfg)
+			logger.LogSnapshot(logr.Info, "anon", "anon", anon)
+		}},
+		{name: "Object", log: func(logger logr.Logger) {
+			logger.WithFields(logr.Object("cfg", &cfg)).Info("config")
+			logger.WithFields(logr.Object("anon", anon)).Info("anon")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lgr := &logr.Logr{}
+			buf := &test.Buffer{}
+			filter := &logr.StdFilter{Lvl: logr.Info}
+			_ = lgr.AddTarget(target.NewWriterTarget(filter, &format.JSON{DisableTimestamp: true}, buf, 1000))
+
+			tt.log(lgr.NewLogger())
+			if err := lgr.Shutdown(); err != nil {
+				t.Error(err)
+			}
+
+			output := buf.String()
+			for _, secret := range []string{"hunter2", "tok-bob", "swordfish", "tok-alice", "s3cr3t", "letmein"} {
+				if strings.Contains(output, secret) {
+					t.Errorf("secret %q logged: %s", secret, output)
+				}
+			}
+			for _, want := range []string{"bob", "alice", "fast", "8065", logr.RedactedValue} {
+				if !strings.Contains(output, want) {
+					t.Errorf("missing %q: %s", want, output)
+				}
+			}
+		})
+	}
+}
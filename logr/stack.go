@@ -0,0 +1,63 @@
+package logr
+
+import (
+	"runtime"
+	"strings"
+)
+
+// stackCaptureSlack is the number of extra program counters captured beyond
+// `MaxStackDepth` to allow for leading logr frames and excluded frames.
+const stackCaptureSlack = 16
+
+// maxStackDepth returns the maximum number of stack frames kept per log record.
+func (logr *Logr) maxStackDepth() int {
+	if logr == nil || logr.MaxStackDepth <= 0 {
+		return DefaultMaxStackFrames
+	}
+	return logr.MaxStackDepth
+}
+
+// stackCaptureSize returns the number of program counters to capture so that,
+// after trimming, up to `MaxStackDepth` frames remain.
+func (logr *Logr) stackCaptureSize() int {
+	depth := logr.maxStackDepth()
+	if logr != nil && (len(logr.StackExcludePrefixes) > 0 || logr.StackFrameFilter != nil) {
+		return depth*2 + stackCaptureSlack
+	}
+	return depth + stackCaptureSlack
+}
+
+// trimFrames removes excluded frames and caps the number of frames
+// at `MaxStackDepth`. Leading logr frames must already be removed.
+func (logr *Logr) trimFrames(frames []runtime.Frame) []runtime.Frame {
+	depth := logr.maxStackDepth()
+	if logr == nil || (len(logr.StackExcludePrefixes) == 0 && logr.StackFrameFilter == nil) {
+		if len(frames) > depth {
+			frames = frames[:depth]
+		}
+		return frames
+	}
+
+	kept := frames[:0]
+	for _, frame := range frames {
+		if len(kept) >= depth {
+			break
+		}
+		if logr.keepFrame(frame) {
+			kept = append(kept, frame)
+		}
+	}
+	return kept
+}
+
+func (logr *Logr) keepFrame(frame runtime.Frame) bool {
+	for _, prefix := range logr.StackExcludePrefixes {
+		if strings.HasPrefix(frame.Function, prefix) {
+			return false
+		}
+	}
+	if logr.StackFrameFilter != nil {
+		return logr.StackFrameFilter(frame)
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package logr
+
+// SetStacktraceLevels forces stack traces to be captured for log records at
+// the specified levels, in addition to any levels for which targets request
+// stack traces, e.g. to capture stacks for Error records during an
+// investigation without redeploying. Targets include the stack trace in
+// their output for these levels regardless of their own filters. Passing
+// nil or an empty slice reverts to the stack traces requested by targets.
+// The level cache is reset so the change takes effect immediately.
+func (logr *Logr) SetStacktraceLevels(levels []Level) {
+	var ids map[LevelID]struct{}
+	if len(levels) > 0 {
+		ids = make(map[LevelID]struct{}, len(levels))
+		for _, lvl := range levels {
+			ids[lvl.ID] = struct{}{}
+		}
+	}
+	logr.stackLevels.Store(ids)
+	logr.ResetLevelCache()
+}
+
+// isStacktraceForced returns true if stack traces are forced for the level
+// via `SetStacktraceLevels`.
+func (logr *Logr) isStacktraceForced(lvl Level) bool {
+	ids, _ := logr.stackLevels.Load().(map[LevelID]struct{})
+	_, ok := ids[lvl.ID]
+	return ok
+}
+
+// StacktraceForced returns true if a stack trace was forced for this log
+// record via `Logr.SetStacktraceLevels`, meaning targets should include
+// the stack trace regardless of their own filters.
+func (rec *LogRec) StacktraceForced() bool {
+	return rec.stackForced
+}
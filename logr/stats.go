@@ -0,0 +1,224 @@
+package logr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the counters maintained by a Logr. Unlike
+// `MetricsCollector`, which pushes counts to an external metrics system,
+// stats are always maintained and can be read via `Logr.Stats`.
+type Stats struct {
+	// Logged is the number of log records delivered to at least one target.
+	Logged uint64
+
+	// Errors is the number of errors reported via `Logr.ReportError`.
+	Errors uint64
+
+	// Dropped is the number of log records dropped because the Logr queue was
+	// full, or because no targets were added and the no-target buffer was full.
+	Dropped uint64
+
+	// Expired is the number of log records dropped because their TTL elapsed.
+	Expired uint64
+
+	// Counted is the number of count-only log records. See `Logger.CountOnly`.
+	Counted uint64
+
+	// TargetDropped is the number of log records dropped because a target
+	// queue was full.
+	TargetDropped uint64
+
+	// TargetErrors is the number of errors writing log records in targets.
+	TargetErrors uint64
+
+	// Shed is the number of times a log record was not delivered to a target
+	// because of the target's rate limit. See `Logr.SetTargetRateLimit`.
+	Shed uint64
+
+	// LoadShed is the number of log records dropped because the Logr queue
+	// was overloaded. See `Logr.LoadShedLevel`.
+	LoadShed uint64
+
+	// TargetPanics is the number of times a target panicked while delivering
+	// a log record. See `Logr.OnTargetPanic`.
+	TargetPanics uint64
+
+	// Filtered is the number of log records dropped by the filter set via
+	// `Logr.SetFilter`.
+	Filtered uint64
+}
+
+type statID int
+
+const (
+	statLogged statID = iota
+	statErrors
+	statDropped
+	statExpired
+	statCounted
+	statTargetDropped
+	statTargetErrors
+	statShed
+	statLoadShed
+	statTargetPanics
+	statFiltered
+	numStats
+)
+
+// statCounters maintains Logr counters. Counters are incremented atomically
+// under a read lock so that snapshots and resets, made under the write lock,
+// are consistent across all counters.
+type statCounters struct {
+	mux    sync.RWMutex
+	counts [numStats]uint64
+}
+
+func (s *statCounters) inc(id statID) {
+	s.mux.RLock()
+	atomic.AddUint64(&s.counts[id], 1)
+	s.mux.RUnlock()
+}
+
+func (s *statCounters) get(id statID) uint64 {
+	return atomic.LoadUint64(&s.counts[id])
+}
+
+func (s *statCounters) snapshot(reset bool) Stats {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	stats := Stats{
+		Logged:        s.counts[statLogged],
+		Errors:        s.counts[statErrors],
+		Dropped:       s.counts[statDropped],
+		Expired:       s.counts[statExpired],
+		Counted:       s.counts[statCounted],
+		TargetDropped: s.counts[statTargetDropped],
+		TargetErrors:  s.counts[statTargetErrors],
+		Shed:          s.counts[statShed],
+		LoadShed:      s.counts[statLoadShed],
+		TargetPanics:  s.counts[statTargetPanics],
+		Filtered:      s.counts[statFiltered],
+	}
+	if reset {
+		s.counts = [numStats]uint64{}
+	}
+	return stats
+}
+
+// Stats returns a consistent snapshot of this Logr's counters.
+func (logr *Logr) Stats() Stats {
+	return logr.stats.snapshot(false)
+}
+
+// ResetStats sets all counters to zero, e.g. between test cases, and returns
+// the counts prior to the reset. Counts made concurrently with the reset are
+// either included in the returned snapshot or counted afresh, never lost.
+func (logr *Logr) ResetStats() Stats {
+	return logr.stats.snapshot(true)
+}
+
+// StatsMsg is the message of the log records created for `Logr.StatsInterval`.
+const StatsMsg = "logr stats"
+
+// logStats logs a snapshot of the stats and the queue size.
+func (logr *Logr) logStats() {
+	stats := logr.Stats()
+	lvl := logr.StatsLevel
+	if lvl.Name == "" {
+		lvl = Info
+	}
+	logr.NewLogger().WithFields(Fields{
+		"queue_size":     logr.QueueLen(),
+		"logged":         stats.Logged,
+		"errors":         stats.Errors,
+		"dropped":        stats.Dropped,
+		"expired":        stats.Expired,
+		"counted":        stats.Counted,
+		"target_dropped": stats.TargetDropped,
+		"target_errors":  stats.TargetErrors,
+		"shed":           stats.Shed,
+		"load_shed":      stats.LoadShed,
+		"target_panics":  stats.TargetPanics,
+		"filtered":       stats.Filtered,
+	}).Log(lvl, StatsMsg)
+}
+
+// QueueFullStats describes the Logr queue and the log records dropped
+// because it was full, passed to `Logr.OnQueueFull`.
+type QueueFullStats struct {
+	// MaxQueueSize is the capacity of the queue.
+	MaxQueueSize int
+
+	// QueueLen is the number of log records queued.
+	QueueLen int
+
+	// Dropped is the total number of log records dropped because the queue
+	// was full. See `Logr.DroppedCount`.
+	Dropped uint64
+
+	// DroppedRecent is the approximate number of log records dropped because
+	// the queue was full within the last DropRateInterval.
+	DroppedRecent uint64
+}
+
+// DroppedCount returns the total number of log records dropped because the
+// Logr queue was full, either by `OnQueueFull` returning true or by
+// `Logger.TryLog`. Unlike `Stats.Dropped` it is never reset.
+func (logr *Logr) DroppedCount() uint64 {
+	return atomic.LoadUint64(&logr.drops.total)
+}
+
+// dropCounter counts dropped log records, in total and within a sliding
+// window of DropRateInterval.
+type dropCounter struct {
+	total uint64 // atomic
+
+	mux   sync.Mutex
+	start time.Time // start of the current interval
+	curr  uint64    // drops within the current interval
+	prev  uint64    // drops within the previous interval
+}
+
+// inc counts a dropped log record.
+func (d *dropCounter) inc(now time.Time) {
+	atomic.AddUint64(&d.total, 1)
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.roll(now)
+	d.curr++
+}
+
+// get returns the total drops and the drops within the last DropRateInterval,
+// estimated by weighting the previous interval by how much of it overlaps.
+func (d *dropCounter) get(now time.Time) (total uint64, recent uint64) {
+	total = atomic.LoadUint64(&d.total)
+
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.roll(now)
+	overlap := 1 - float64(now.Sub(d.start))/float64(DropRateInterval)
+	if overlap < 0 || overlap > 1 {
+		overlap = 0
+	}
+	return total, d.curr + uint64(float64(d.prev)*overlap)
+}
+
+// roll starts a new interval if the current one has elapsed.
+func (d *dropCounter) roll(now time.Time) {
+	elapsed := now.Sub(d.start)
+	if elapsed < DropRateInterval {
+		return
+	}
+	if elapsed < 2*DropRateInterval {
+		d.prev = d.curr
+		d.start = d.start.Add(DropRateInterval)
+	} else {
+		d.prev = 0
+		d.start = now
+	}
+	d.curr = 0
+}
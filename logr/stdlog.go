@@ -0,0 +1,84 @@
+package logr
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"sync"
+)
+
+// DefaultStdLogMaxLine is the maximum number of bytes of a partial line
+// buffered by a `StdLogWriter` before it is logged without waiting for the
+// newline.
+const DefaultStdLogMaxLine = 64 * 1024
+
+// stdLogWriter adapts a Logger to io.Writer, see `StdLogWriter`.
+type stdLogWriter struct {
+	logger Logger
+	lvl    Level
+
+	mux     sync.Mutex
+	partial []byte // bytes written after the last newline
+}
+
+// StdLogWriter returns an io.Writer that logs each line written as a log
+// record at the level, e.g. for libraries that only accept an io.Writer.
+// Lines are split on newlines, which are trimmed, and empty lines are skipped.
+// A write not ending in a newline is buffered until the rest of the line is
+// written, or until DefaultStdLogMaxLine bytes are buffered.
+//
+// The writer is safe for concurrent use. Each write ending in a newline is
+// logged as a whole, but partial lines written concurrently by different
+// goroutines may be joined.
+func (logger Logger) StdLogWriter(lvl Level) io.Writer {
+	return &stdLogWriter{logger: logger, lvl: lvl}
+}
+
+// StdLogger returns a `*log.Logger`, without prefix or flags, that logs each
+// line as a log record at the level, e.g. for `http.Server.ErrorLog`. See
+// `StdLogWriter`.
+func (logger Logger) StdLogger(lvl Level) *log.Logger {
+	return log.New(logger.StdLogWriter(lvl), "", 0)
+}
+
+// Write logs each complete line in p, buffering any partial line.
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	data := p
+	if len(w.partial) > 0 {
+		w.partial = append(w.partial, p...)
+		data = w.partial
+	}
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.logLine(data[:i])
+		data = data[i+1:]
+	}
+
+	switch {
+	case len(data) >= DefaultStdLogMaxLine:
+		w.logLine(data)
+		w.partial = w.partial[:0]
+	case len(data) > 0:
+		// copy, since the remainder may alias p or the buffer itself.
+		w.partial = append(w.partial[:0:0], data...)
+	default:
+		w.partial = w.partial[:0]
+	}
+	return len(p), nil
+}
+
+// logLine logs a line, without its trailing carriage return, if not empty.
+func (w *stdLogWriter) logLine(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return
+	}
+	w.logger.Log(w.lvl, string(line))
+}
@@ -0,0 +1,75 @@
+package logr
+
+// Logw checks that the level matches one or more targets, and if so,
+// generates a log record with fields from alternating keys and values, e.g.
+// `logger.Logw(Info, "login", "user", name, "attempts", n)`, or typed fields,
+// e.g. `logger.Logw(Info, "login", String("user", name))`. Keys must be
+// strings; a value with a missing or non-string key is logged under
+// `FieldKeyBadKey`. Nothing is converted when the level is disabled.
+func (logger Logger) Logw(lvl Level, msg string, keysAndValues ...interface{}) {
+	if !logger.levelStatus(lvl).Enabled {
+		return
+	}
+	logger.WithFields(sugarFields(keysAndValues)).Log(lvl, msg)
+}
+
+// Tracew is a convenience method equivalent to `Logw(TraceLevel, msg, keysAndValues...)`.
+func (logger Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	logger.Logw(Trace, msg, keysAndValues...)
+}
+
+// Debugw is a convenience method equivalent to `Logw(DebugLevel, msg, keysAndValues...)`.
+func (logger Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	logger.Logw(Debug, msg, keysAndValues...)
+}
+
+// Infow is a convenience method equivalent to `Logw(InfoLevel, msg, keysAndValues...)`.
+func (logger Logger) Infow(msg string, keysAndValues ...interface{}) {
+	logger.Logw(Info, msg, keysAndValues...)
+}
+
+// Warnw is a convenience method equivalent to `Logw(WarnLevel, msg, keysAndValues...)`.
+func (logger Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	logger.Logw(Warn, msg, keysAndValues...)
+}
+
+// Errorw is a convenience method equivalent to `Logw(ErrorLevel, msg, keysAndValues...)`.
+func (logger Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	logger.Logw(Error, msg, keysAndValues...)
+}
+
+// Withw creates a new `Logger` with any existing fields plus fields from
+// alternating keys and values, e.g. `logger.Withw("subsystem", "auth")`, as
+// for `Logw`. The fields apply to every log record from the new logger and
+// from loggers derived from it.
+func (logger Logger) Withw(keysAndValues ...interface{}) Logger {
+	if len(keysAndValues) == 0 {
+		return logger
+	}
+	return logger.WithFields(sugarFields(keysAndValues))
+}
+
+// sugarFields converts alternating keys and values to Fields. A typed `Field`
+// takes the place of a key and value. A non-string key, or a trailing key
+// without a value, is logged under `FieldKeyBadKey`.
+func sugarFields(keysAndValues []interface{}) Fields {
+	flds := make(Fields, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); {
+		if f, ok := keysAndValues[i].(Field); ok {
+			if f.Key != "" {
+				flds[f.Key] = f.Value
+			}
+			i++
+			continue
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok || i+1 == len(keysAndValues) {
+			flds[FieldKeyBadKey] = keysAndValues[i]
+			i++
+			continue
+		}
+		flds[key] = keysAndValues[i+1]
+		i += 2
+	}
+	return flds
+}
@@ -0,0 +1,154 @@
+package logr
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultSupervisorCheckInterval is how often a Supervisor checks target
+	// health when `SupervisorOptions.CheckInterval` is zero.
+	DefaultSupervisorCheckInterval = time.Second
+
+	// DefaultSupervisorFailAfter is the number of consecutive failed checks
+	// before failing over when `SupervisorOptions.FailAfter` is zero.
+	DefaultSupervisorFailAfter = 3
+
+	// DefaultSupervisorRecoverAfter is the number of consecutive healthy checks
+	// before failing back when `SupervisorOptions.RecoverAfter` is zero.
+	DefaultSupervisorRecoverAfter = 10
+)
+
+// SupervisorOptions configures a Supervisor or `HealthSwitch`.
+type SupervisorOptions struct {
+	// CheckInterval is how often target health is checked.
+	// Defaults to DefaultSupervisorCheckInterval.
+	CheckInterval time.Duration
+
+	// FailAfter is the number of consecutive checks finding all primary
+	// targets unhealthy before failing over to the standby.
+	// Defaults to DefaultSupervisorFailAfter.
+	FailAfter int
+
+	// RecoverAfter is the number of consecutive checks finding a primary
+	// target healthy before failing back to the primary. Use a value larger
+	// than `FailAfter` to avoid flapping. Defaults to DefaultSupervisorRecoverAfter.
+	RecoverAfter int
+
+	// IsHealthy, when not nil, reports whether a primary target is healthy,
+	// e.g. by dialing its network endpoint. Defaults to `HealthOf`.
+	IsHealthy func(target Target) bool
+
+	// OnSwitch, when not nil, is called after delivery switches to the
+	// standby (failedOver true) or back to the primary.
+	OnSwitch func(failedOver bool)
+}
+
+// Supervisor monitors the health of a primary Logr's targets and, when all
+// of them are failing, delivers the primary's log records to a standby Logr
+// until the primary recovers. See `HealthSwitch` for how health is checked.
+//
+// Log records continue to be created via Loggers of the primary. As each
+// record is fanned out it is delivered either to the primary's targets or to
+// the standby, so no record is duplicated by a switch, except for the probe
+// records delivered to both while failed over when `SupervisorOptions.IsHealthy`
+// is nil. Records already queued within a failing primary target when failing
+// over are not recovered. Records are handed to the standby without blocking
+// the primary; those that do not fit in the standby's queue are dropped, see
+// `Dropped`.
+type Supervisor struct {
+	primary *Logr
+	standby *Logr
+	sw      *HealthSwitch
+
+	forwarded uint64
+	dropped   uint64
+
+	stopOnce sync.Once
+}
+
+// NewSupervisor creates a Supervisor that fails over delivery of primary's
+// log records to standby when all primary targets are unhealthy. Call `Stop`
+// before shutting down either Logr.
+func NewSupervisor(primary *Logr, standby *Logr, opts SupervisorOptions) (*Supervisor, error) {
+	if primary == nil || standby == nil {
+		return nil, errors.New("supervisor requires primary and standby")
+	}
+	if primary == standby {
+		return nil, errors.New("supervisor primary and standby must differ")
+	}
+
+	s := &Supervisor{
+		primary: primary,
+		standby: standby,
+	}
+
+	primary.mux.Lock()
+	defer primary.mux.Unlock()
+	if sup, _ := primary.supervisor.Load().(*Supervisor); sup != nil {
+		return nil, errors.New("primary already supervised")
+	}
+	s.sw = NewHealthSwitch(s.primaryTargets, opts)
+	primary.supervisor.Store(s)
+	return s, nil
+}
+
+// IsFailedOver returns true while log records are delivered to the standby.
+func (s *Supervisor) IsFailedOver() bool {
+	return s.sw.IsFailedOver()
+}
+
+// Forwarded returns the number of log records delivered to the standby.
+func (s *Supervisor) Forwarded() uint64 {
+	return atomic.LoadUint64(&s.forwarded)
+}
+
+// Dropped returns the number of log records dropped because the standby's
+// queue was full. They are also counted in the standby's `Stats`.
+func (s *Supervisor) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Stop stops supervising and restores delivery to the primary's targets.
+func (s *Supervisor) Stop() {
+	s.stopOnce.Do(func() {
+		s.sw.Stop()
+
+		s.primary.mux.Lock()
+		s.primary.supervisor.Store((*Supervisor)(nil))
+		s.primary.mux.Unlock()
+	})
+}
+
+// primaryTargets returns a copy of the primary's targets.
+func (s *Supervisor) primaryTargets() []Target {
+	s.primary.tmux.RLock()
+	defer s.primary.tmux.RUnlock()
+	return append([]Target(nil), s.primary.targets...)
+}
+
+// forward hands a log record from the primary to the standby without
+// blocking, since it is called while the primary fans out.
+func (s *Supervisor) forward(rec *LogRec) {
+	r := rec.WithTime(rec.Time())
+	r.seq = 0 // sequenced by the standby
+	// re-home the record so the standby's stats, errors and buffers are used;
+	// tees were already delivered by the primary.
+	r.logger.logr = s.standby
+	r.logger.tees = nil
+	if !s.standby.tryEnqueue(r) {
+		atomic.AddUint64(&s.dropped, 1)
+		return
+	}
+	atomic.AddUint64(&s.forwarded, 1)
+}
+
+// failedOver returns the Supervisor if this Logr is supervised and failed over.
+func (logr *Logr) failedOver() *Supervisor {
+	if sup, _ := logr.supervisor.Load().(*Supervisor); sup != nil && sup.IsFailedOver() {
+		return sup
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package logr_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func TestSupervisorFailover(t *testing.T) {
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	primary := &logr.Logr{}
+	primary.OnLoggerError = func(err error) {}
+	_ = primary.AddTarget(test.NewFailingTarget(filter, &format.Plain{}))
+
+	standby := &logr.Logr{}
+	buf := &test.Buffer{}
+	_ = standby.AddTarget(target.NewWriterTarget(filter, &format.Plain{}, buf, 100))
+
+	opts := logr.SupervisorOptions{CheckInterval: 5 * time.Millisecond, FailAfter: 2, RecoverAfter: 3}
+	sup, err := logr.NewSupervisor(primary, standby, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := primary.NewLogger()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !sup.IsFailedOver() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for failover")
+		}
+		logger.Info("primary down")
+		time.Sleep(time.Millisecond)
+	}
+
+	// the primary still fails its probe records, so delivery must not switch back.
+	for i := 0; i < 50; i++ {
+		logger.Info("forwarded")
+		time.Sleep(time.Millisecond)
+		if !sup.IsFailedOver() {
+			t.Fatal("switched back to a primary that is still down")
+		}
+	}
+
+	sup.Stop()
+	if err := primary.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if err := standby.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if sup.Forwarded() == 0 || !strings.Contains(buf.String(), "forwarded") {
+		t.Error("standby did not receive records")
+	}
+}
+
+// logrTarget records the Logr of each record written, blocking while its
+// gate is closed.
+type logrTarget struct {
+	logr.Basic
+	gate chan struct{}
+
+	mux   sync.Mutex
+	logrs []*logr.Logr
+}
+
+func (lt *logrTarget) Write(rec *logr.LogRec) error {
+	<-lt.gate
+	lt.mux.Lock()
+	defer lt.mux.Unlock()
+	lt.logrs = append(lt.logrs, rec.Logger().Logr())
+	return nil
+}
+
+func TestSupervisorForwardDoesNotBlock(t *testing.T) {
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	primary := &logr.Logr{}
+	primary.OnLoggerError = func(err error) {}
+	_ = primary.AddTarget(test.NewFailingTarget(filter, &format.Plain{}))
+
+	// a standby stuck writing, with room for few records.
+	standby := &logr.Logr{MaxQueueSize: 2, EnqueueTimeout: time.Minute}
+	lt := &logrTarget{gate: make(chan struct{})}
+	lt.Basic.Start(lt, lt, filter, nil, 1)
+	_ = standby.AddTarget(lt)
+
+	opts := logr.SupervisorOptions{
+		CheckInterval: 5 * time.Millisecond,
+		FailAfter:     1,
+		RecoverAfter:  1000,
+		IsHealthy:     func(logr.Target) bool { return false },
+	}
+	sup, err := logr.NewSupervisor(primary, standby, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !sup.IsFailedOver() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for failover")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// the primary keeps flowing while the standby is full.
+	logger := primary.NewLogger()
+	for i := 0; i < 20; i++ {
+		logger.Info("forwarded")
+	}
+	start := time.Now()
+	if err := primary.Flush(); err != nil {
+		t.Error(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("primary blocked by a full standby for %v", elapsed)
+	}
+	dropped := sup.Dropped()
+	if dropped == 0 || sup.Forwarded()+dropped != 20 {
+		t.Errorf("expected 20 records forwarded or dropped, got %d and %d", sup.Forwarded(), dropped)
+	}
+	if n := standby.Stats().Dropped; n != dropped {
+		t.Errorf("expected %d dropped counted by the standby, got %d", dropped, n)
+	}
+	if n := primary.Stats().Dropped; n != 0 {
+		t.Errorf("expected no drops counted by the primary, got %d", n)
+	}
+
+	sup.Stop()
+	close(lt.gate)
+	if err := primary.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if err := standby.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// forwarded records belong to the standby.
+	lt.mux.Lock()
+	defer lt.mux.Unlock()
+	if uint64(len(lt.logrs)) != sup.Forwarded() {
+		t.Errorf("expected %d records written, got %d", sup.Forwarded(), len(lt.logrs))
+	}
+	for _, l := range lt.logrs {
+		if l != standby {
+			t.Fatal("forwarded record still refers to the primary Logr")
+		}
+	}
+}
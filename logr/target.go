@@ -0,0 +1,510 @@
+package logr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wiggin77/merror"
+)
+
+// errTargetShutdown completes flush requests for targets already shut down.
+var errTargetShutdown = errors.New("target shut down")
+
+// Target represents a destination for log records such as file,
+// database, TCP socket, etc.
+type Target interface {
+	// SetName provides an option name for the target.
+	SetName(name string)
+
+	// IsLevelEnabled returns true if this target should emit
+	// logs for the specified level. Also determines if
+	// a stack trace is required.
+	IsLevelEnabled(Level) (enabled bool, stacktrace bool)
+
+	// Formatter returns the Formatter associated with this Target.
+	Formatter() Formatter
+
+	// Log outputs the log record to this target's destination.
+	Log(rec *LogRec)
+
+	// Shutdown makes best effort to flush target queue and
+	// frees/closes all resources.
+	Shutdown(ctx context.Context) error
+}
+
+// NamedTarget is a target with a stable name, set via `SetName`, which can
+// be used to find the target, e.g. via `Logr.RemoveTargetByName`.
+type NamedTarget interface {
+	// Name returns the target name, or empty string if the target is unnamed.
+	Name() string
+}
+
+// FormatterSetter is a target whose Formatter can be replaced at runtime.
+// Implementations must be safe for concurrent use with `Log`.
+type FormatterSetter interface {
+	SetFormatter(formatter Formatter)
+}
+
+// TargetWithLastError is a target that records the most recent error
+// encountered while writing log records.
+type TargetWithLastError interface {
+	// LastError returns the most recent error and when it occurred, or
+	// nil and zero time if no errors have occurred.
+	LastError() (error, time.Time)
+}
+
+// TargetWithValidate is a target that can check its configuration, e.g.
+// resolve its address or open its file, so that `Logr.AddTargetChecked` fails
+// at startup rather than the target failing to write every log record.
+type TargetWithValidate interface {
+	// Validate returns an error if the target cannot output log records.
+	Validate() error
+}
+
+// ValidateOf validates each target that implements `TargetWithValidate`,
+// returning the error of the target that fails, or of all that fail.
+func ValidateOf(targets ...Target) error {
+	errs := merror.New()
+	for _, t := range targets {
+		if tv, ok := t.(TargetWithValidate); ok {
+			if err := tv.Validate(); err != nil {
+				errs.Append(fmt.Errorf("target %s: %w", t, err))
+			}
+		}
+	}
+	if errs.Len() == 1 {
+		return errs.Errors()[0]
+	}
+	return errs.ErrorOrNil()
+}
+
+// TargetWithTryLog is a target that can attempt to queue a log record without
+// blocking indefinitely, allowing wrappers to spill records to another target.
+type TargetWithTryLog interface {
+	// TryLog attempts to queue the log record, waiting up to timeout for space.
+	// Returns false if the log record was not queued.
+	TryLog(rec *LogRec, timeout time.Duration) bool
+}
+
+// ForwardFlush is used by targets that wrap other targets to handle a flush
+// log record (see `LogRec.IsFlush`). Each wrapped target is flushed in turn,
+// blocking until finished, and then the flush is signaled complete along
+// with any errors the wrapped targets reported.
+func ForwardFlush(rec *LogRec, targets ...Target) {
+	errs := merror.New()
+	for _, t := range targets {
+		f := newFlushLogRec(rec.logger)
+		t.Log(f)
+		<-f.flush
+		if f.flushErr != nil {
+			errs.Append(f.flushErr)
+		}
+	}
+	rec.flushErr = errs.ErrorOrNil()
+	rec.flush <- struct{}{}
+}
+
+// RecordWriter can convert a LogRecord to bytes and output to some data sink.
+type RecordWriter interface {
+	Write(rec *LogRec) error
+}
+
+// RecordFlusher is implemented by a RecordWriter that buffers output.
+// Flush is called whenever the target queue has been drained by a flush
+// request, and once more when the target shuts down.
+type RecordFlusher interface {
+	Flush() error
+}
+
+// Basic provides the basic functionality of a Target that can be used
+// to more easily compose your own Targets. To use, just embed Basic
+// in your target type, implement `RecordWriter`, and call `(*Basic).Start`.
+type Basic struct {
+	target Target
+	name   string
+
+	filter    Filter
+	fmux      sync.RWMutex
+	formatter Formatter
+
+	in      chan *LogRec
+	done    chan struct{}
+	w       RecordWriter
+	syncMux sync.Mutex // serializes writes in `Logr.SyncMode`
+
+	// set when w is a RecordBatchWriter; the batch is only accessed by the
+	// queue goroutine, or under syncMux in `Logr.SyncMode`.
+	bw       RecordBatchWriter
+	batch    []*LogRec
+	batchDue time.Time
+
+	// inMux guards closing `in` against concurrent sends; quit is closed
+	// first so senders blocked on a full queue give up.
+	inMux        sync.RWMutex
+	closed       bool
+	quit         chan struct{}
+	shutdownOnce sync.Once
+
+	errMux      sync.RWMutex
+	lastErr     error
+	lastErrTime time.Time
+
+	consecutiveFailures int64 // atomic; reset by a successful write
+
+	queueSizeGauge Gauge
+	loggedCounter  Counter
+	errorCounter   Counter
+	droppedCounter Counter
+	blockedCounter Counter
+}
+
+// Start initializes this target helper and starts accepting log records for processing.
+func (b *Basic) Start(target Target, rw RecordWriter, filter Filter, formatter Formatter, maxQueued int) {
+	if filter == nil {
+		filter = &StdFilter{Lvl: Fatal}
+	}
+	if formatter == nil {
+		formatter = &DefaultFormatter{}
+	}
+
+	b.target = target
+	b.filter = filter
+	b.formatter = formatter
+	b.in = make(chan *LogRec, maxQueued)
+	b.done = make(chan struct{}, 1)
+	b.quit = make(chan struct{})
+	b.w = rw
+	b.bw, _ = rw.(RecordBatchWriter)
+	go b.start()
+}
+
+// SetName provides an optional name for the target.
+func (b *Basic) SetName(name string) {
+	b.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (b *Basic) Name() string {
+	return b.name
+}
+
+// IsLevelEnabled returns true if this target should emit
+// logs for the specified level. Also determines if
+// a stack trace is required.
+func (b *Basic) IsLevelEnabled(lvl Level) (enabled bool, stacktrace bool) {
+	return b.filter.IsEnabled(lvl), b.filter.IsStacktraceEnabled(lvl)
+}
+
+// IncludeStacktrace returns true if the stack trace should be output for the
+// log record, either because this target's filter requests it for the level
+// or because it was forced via `Logr.SetStacktraceLevels`.
+func (b *Basic) IncludeStacktrace(rec *LogRec) bool {
+	return rec.StacktraceForced() || b.filter.IsStacktraceEnabled(rec.Level())
+}
+
+// Formatter returns the Formatter associated with this Target.
+func (b *Basic) Formatter() Formatter {
+	b.fmux.RLock()
+	defer b.fmux.RUnlock()
+	return b.formatter
+}
+
+// SetFormatter replaces the Formatter associated with this Target. Log
+// records already being formatted are unaffected.
+func (b *Basic) SetFormatter(formatter Formatter) {
+	if formatter == nil {
+		formatter = &DefaultFormatter{}
+	}
+	b.fmux.Lock()
+	defer b.fmux.Unlock()
+	b.formatter = formatter
+}
+
+// Shutdown stops processing log records after making best
+// effort to flush queue. Log records logged afterwards are dropped.
+// Shutdown may be called more than once.
+func (b *Basic) Shutdown(ctx context.Context) error {
+	// close the incoming channel and wait for read loop to exit.
+	b.shutdownOnce.Do(func() {
+		close(b.quit)
+		b.inMux.Lock()
+		b.closed = true
+		close(b.in)
+		b.inMux.Unlock()
+	})
+	select {
+	case <-ctx.Done():
+	case <-b.done:
+	}
+
+	// b.in channel should now be drained.
+	return nil
+}
+
+// shutdownComplete returns true once the queue goroutine has exited after
+// `Shutdown`, having written all queued log records.
+func (b *Basic) shutdownComplete() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Log outputs the log record to this targets destination.
+func (b *Basic) Log(rec *LogRec) {
+	lgr := rec.Logger().Logr()
+	if lgr.SyncMode {
+		b.logSync(rec)
+		return
+	}
+	b.inMux.RLock()
+	defer b.inMux.RUnlock()
+	if b.closed {
+		b.dropShutdown(rec)
+		return
+	}
+	select {
+	case b.in <- rec:
+	default:
+		handler := lgr.OnTargetQueueFull
+		if handler != nil && handler(b.target, rec, cap(b.in)) {
+			lgr.stats.inc(statTargetDropped)
+			if b.droppedCounter != nil {
+				b.droppedCounter.Inc()
+			}
+			ReleaseLogRec(rec)
+			return // drop the record
+		}
+		if b.blockedCounter != nil {
+			b.blockedCounter.Inc()
+		}
+
+		select {
+		case <-time.After(lgr.enqueueTimeout()):
+			lgr.ReportError(fmt.Errorf("target enqueue timeout for log rec [%v]", rec))
+			lgr.stats.inc(statTargetDropped)
+			if b.droppedCounter != nil {
+				b.droppedCounter.Inc()
+			}
+			ReleaseLogRec(rec)
+		case <-b.quit:
+			b.dropShutdown(rec)
+		case b.in <- rec: // block until success or timeout
+		}
+	}
+}
+
+// dropShutdown drops a log record logged after the target was shut down,
+// completing it if a flush record.
+func (b *Basic) dropShutdown(rec *LogRec) {
+	if rec.flush != nil {
+		rec.flushErr = errTargetShutdown
+		rec.flush <- struct{}{}
+		return
+	}
+	rec.Logger().Logr().stats.inc(statTargetDropped)
+	if b.droppedCounter != nil {
+		b.droppedCounter.Inc()
+	}
+	ReleaseLogRec(rec)
+}
+
+// TryLog attempts to queue the log record, waiting up to timeout for space.
+// Returns false if the queue remained full or the target is shut down. Unlike
+// `Log`, `OnTargetQueueFull` is not called.
+func (b *Basic) TryLog(rec *LogRec, timeout time.Duration) bool {
+	b.inMux.RLock()
+	defer b.inMux.RUnlock()
+	if b.closed {
+		return false
+	}
+	select {
+	case b.in <- rec:
+		return true
+	default:
+	}
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case b.in <- rec:
+		return true
+	case <-timer.C:
+		return false
+	case <-b.quit:
+		return false
+	}
+}
+
+// EnableMetrics enables metrics collection using the provided MetricsCollector.
+// Metrics are labeled with the target name, see `NamedTarget`. The queue size
+// is polled by the Logr metrics updater every `updateFreqMillis`.
+func (b *Basic) EnableMetrics(collector MetricsCollector, updateFreqMillis int64) error {
+	name := metricsName(b.target)
+	var err error
+
+	if b.queueSizeGauge, err = collector.QueueSizeGauge(name); err != nil {
+		return err
+	}
+	if b.loggedCounter, err = collector.LoggedCounter(name); err != nil {
+		return err
+	}
+	if b.errorCounter, err = collector.ErrorCounter(name); err != nil {
+		return err
+	}
+	if b.droppedCounter, err = collector.DroppedCounter(name); err != nil {
+		return err
+	}
+	if b.blockedCounter, err = collector.BlockedCounter(name); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (b *Basic) String() string {
+	if b.name != "" {
+		return b.name
+	}
+	return fmt.Sprintf("%T", b.target)
+}
+
+// Start accepts log records via In channel and writes to the
+// supplied writer, until Done channel signaled.
+func (b *Basic) start() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintln(os.Stderr, "Basic.start -- ", r)
+			go b.start()
+		}
+	}()
+
+	if b.bw != nil {
+		b.startBatching()
+	} else {
+		for rec := range b.in {
+			if rec.flush != nil {
+				b.flush(rec)
+			} else {
+				b.write(rec)
+			}
+		}
+	}
+	if err := b.flushWriter(); err != nil {
+		fmt.Fprintln(os.Stderr, "Basic.start -- ", err)
+	}
+	close(b.done)
+}
+
+// write writes a log record, counting it or the failure, then releases it.
+func (b *Basic) write(rec *LogRec) {
+	if b.bw != nil {
+		b.addToBatch(rec)
+		return
+	}
+	defer ReleaseLogRec(rec)
+	err := b.w.Write(rec)
+	if err != nil {
+		b.writeFailed(rec, err)
+		return
+	}
+	if atomic.LoadInt64(&b.consecutiveFailures) != 0 {
+		atomic.StoreInt64(&b.consecutiveFailures, 0)
+	}
+	if b.loggedCounter != nil {
+		b.loggedCounter.Inc()
+	}
+}
+
+// logSync writes or flushes a log record immediately, bypassing the queue.
+func (b *Basic) logSync(rec *LogRec) {
+	b.syncMux.Lock()
+	defer b.syncMux.Unlock()
+	if rec.flush != nil {
+		b.flush(rec)
+	} else {
+		b.write(rec)
+		b.writeBatch()
+	}
+}
+
+// writeFailed counts, records and reports an error writing a log record.
+func (b *Basic) writeFailed(rec *LogRec, err error) {
+	atomic.AddInt64(&b.consecutiveFailures, 1)
+	rec.Logger().Logr().stats.inc(statTargetErrors)
+	if b.errorCounter != nil {
+		b.errorCounter.Inc()
+	}
+	b.errMux.Lock()
+	b.lastErr = err
+	b.lastErrTime = time.Now()
+	b.errMux.Unlock()
+
+	rec.Logger().Logr().ReportError(err)
+}
+
+// LastError returns the most recent error writing a log record and when it
+// occurred, or nil and zero time if no errors have occurred.
+func (b *Basic) LastError() (error, time.Time) {
+	b.errMux.RLock()
+	defer b.errMux.RUnlock()
+	return b.lastErr, b.lastErrTime
+}
+
+// UpdateQueueMetrics sets the queue size Gauge to the number of log records
+// queued. Called by the Logr metrics updater.
+func (b *Basic) UpdateQueueMetrics() {
+	if b.queueSizeGauge != nil {
+		b.queueSizeGauge.Set(float64(len(b.in)))
+	}
+}
+
+// flush drains the records currently queued, flushes any buffered output and
+// notifies when done. The drain is bounded so that a flush completes even
+// while the queue is kept busy. Flush records drained, e.g. queued by a later
+// flush while an earlier one timed out, complete along with this one since
+// the records queued before them have been written.
+func (b *Basic) flush(flushRec *LogRec) {
+	flushRecs := []*LogRec{flushRec}
+loop:
+	for n := len(b.in); n > 0; n-- {
+		select {
+		case rec := <-b.in:
+			if rec.flush != nil {
+				flushRecs = append(flushRecs, rec)
+			} else {
+				b.write(rec)
+			}
+		default:
+			break loop
+		}
+	}
+	err := b.flushWriter()
+	if err != nil {
+		b.writeFailed(flushRec, err)
+	}
+	for _, rec := range flushRecs {
+		rec.flushErr = err
+		rec.flush <- struct{}{}
+	}
+}
+
+// flushWriter writes any partial batch then flushes the RecordWriter if it
+// buffers output.
+func (b *Basic) flushWriter() error {
+	b.writeBatch()
+	if f, ok := b.w.(RecordFlusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
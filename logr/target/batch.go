@@ -0,0 +1,147 @@
+package target
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+)
+
+// Compression is a payload compression method for batch sending targets.
+type Compression int
+
+const (
+	// CompressionNone sends payloads uncompressed.
+	CompressionNone Compression = iota
+
+	// CompressionGzip compresses payloads with gzip.
+	CompressionGzip
+)
+
+// String returns the Content-Encoding for the compression method.
+func (c Compression) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	default:
+		return "identity"
+	}
+}
+
+// Compress compresses a whole batch payload.
+func (c Compression) Compress(payload []byte) ([]byte, error) {
+	switch c {
+	case CompressionNone:
+		return payload, nil
+	case CompressionGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %d", c)
+	}
+}
+
+// HTTPBatchSender posts batched payloads, such as a JSON array of log
+// records, to an HTTP endpoint. It provides the shared request handling for
+// targets that send batches over HTTP.
+//
+// When `Compression` is set each batch is compressed as a whole and sent with
+// the matching Content-Encoding header. If the server responds 415 Unsupported
+// Media Type the batch is resent uncompressed, and all later batches are sent
+// uncompressed.
+type HTTPBatchSender struct {
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// URL is the endpoint batches are posted to.
+	URL string
+
+	// ContentType is the Content-Type of the uncompressed payload, e.g. "application/json".
+	ContentType string
+
+	// Header contains additional request headers, such as authorization.
+	Header http.Header
+
+	// Compression is the payload compression method.
+	Compression Compression
+
+	uncompressed int32 // atomic; 1 after the server rejected compression
+}
+
+// Send posts a batch payload, returning an error if the request fails or the
+// server does not respond with a 2xx status.
+func (s *HTTPBatchSender) Send(ctx context.Context, payload []byte) error {
+	compression := s.Compression
+	if atomic.LoadInt32(&s.uncompressed) == 1 {
+		compression = CompressionNone
+	}
+
+	status, err := s.post(ctx, payload, compression)
+	if err == nil && status == http.StatusUnsupportedMediaType && compression != CompressionNone {
+		atomic.StoreInt32(&s.uncompressed, 1)
+		status, err = s.post(ctx, payload, CompressionNone)
+	}
+	if err != nil {
+		return err
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("batch post to %s failed with status %d", s.URL, status)
+	}
+	return nil
+}
+
+// IsCompressing returns true if batches are being compressed, i.e. compression
+// is configured and the server has not rejected it.
+func (s *HTTPBatchSender) IsCompressing() bool {
+	return s.Compression != CompressionNone && atomic.LoadInt32(&s.uncompressed) == 0
+}
+
+// post sends one request, returning the response status.
+func (s *HTTPBatchSender) post(ctx context.Context, payload []byte, compression Compression) (int, error) {
+	if s.URL == "" {
+		return 0, errors.New("batch sender requires a URL")
+	}
+	body, err := compression.Compress(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range s.Header {
+		req.Header[k] = v
+	}
+	if s.ContentType != "" {
+		req.Header.Set("Content-Type", s.ContentType)
+	}
+	if compression != CompressionNone {
+		req.Header.Set("Content-Encoding", compression.String())
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,243 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// DefaultBurstGap is the quiet period that ends a burst when
+// `BurstOptions.Gap` is zero.
+const DefaultBurstGap = time.Second
+
+// BurstOptions configures a burst target.
+type BurstOptions struct {
+	// Gap is how long a burst must be quiet before it ends. Defaults to DefaultBurstGap.
+	Gap time.Duration
+
+	// Key, when not nil, returns the key identifying which burst a log record
+	// belongs to. Defaults to the level and message.
+	Key func(rec *logr.LogRec) string
+}
+
+// burst tracks one in-progress burst.
+type burst struct {
+	first  time.Time
+	now    func() time.Time // clock of the Logr the records came from
+	last   *logr.LogRec
+	count  uint64 // records suppressed, including last
+	weight uint64 // sum of the sample rates of records suppressed, including last
+}
+
+// Burst is a target that wraps another target and logs only the first and
+// last log records of each burst of matching records. The first record is
+// delivered immediately and the rest are suppressed. When the burst ends,
+// because no matching record arrives within the gap, or when the target is
+// flushed or shut down, the last record is delivered with the number of
+// records suppressed between first and last in the `logr.FieldKeySuppressed`
+// field and the burst duration in the `logr.FieldKeyBurstDuration` field. The
+// last record's sampling rate, see `logr.LogRec.SampleRate`, is set to the
+// number of records it represents: the suppressed records plus itself, taking
+// into account any sampling done before the records reached this target.
+type Burst struct {
+	name   string
+	target logr.Target
+	opts   BurstOptions
+
+	mux    sync.Mutex
+	bursts map[string]*burst
+	quit   chan struct{}
+	done   chan struct{}
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewBurstTarget creates a target that wraps target and logs the first and
+// last log records of each burst.
+func NewBurstTarget(target logr.Target, opts BurstOptions) *Burst {
+	if opts.Gap <= 0 {
+		opts.Gap = DefaultBurstGap
+	}
+	if opts.Key == nil {
+		opts.Key = burstKey
+	}
+	b := &Burst{
+		target: target,
+		opts:   opts,
+		bursts: make(map[string]*burst),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go b.start()
+	return b
+}
+
+// burstKey is the default burst key.
+func burstKey(rec *logr.LogRec) string {
+	return rec.Level().Name + ":" + rec.Msg()
+}
+
+// SetName provides an optional name for the target.
+func (b *Burst) SetName(name string) {
+	b.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (b *Burst) Name() string {
+	return b.name
+}
+
+// IsLevelEnabled returns the wrapped target's level status.
+func (b *Burst) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	return b.target.IsLevelEnabled(lvl)
+}
+
+// Formatter returns the wrapped target's Formatter.
+func (b *Burst) Formatter() logr.Formatter {
+	return b.target.Formatter()
+}
+
+// Log delivers the log record if it starts a burst, otherwise it is
+// suppressed until the burst ends.
+func (b *Burst) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		b.endBursts(true)
+		logr.ForwardFlush(rec, b.target)
+		return
+	}
+
+	key := b.opts.Key(rec)
+	now := rec.Time()
+
+	b.mux.Lock()
+	var ended *logr.LogRec
+	bst, ok := b.bursts[key]
+	if ok && now.Sub(bst.lastTime()) >= b.opts.Gap {
+		ended = bst.summary()
+		ok = false
+	}
+	if ok {
+		bst.last = rec
+		bst.count++
+		bst.weight += rec.SampleRate()
+	} else {
+		b.bursts[key] = &burst{first: now, now: rec.Logger().Logr().Now}
+	}
+	b.mux.Unlock()
+
+	if ended != nil {
+		b.target.Log(ended)
+	}
+	if !ok {
+		b.target.Log(rec)
+	}
+}
+
+// lastTime returns the time of the most recent record in the burst.
+func (bst *burst) lastTime() time.Time {
+	if bst.last == nil {
+		return bst.first
+	}
+	return bst.last.Time()
+}
+
+// summary returns the last record annotated with the suppressed count and
+// burst duration, or nil if the burst had a single record.
+func (bst *burst) summary() *logr.LogRec {
+	if bst.last == nil {
+		return nil
+	}
+	return bst.last.WithFields(logr.Fields{
+		logr.FieldKeySuppressed:    bst.count - 1,
+		logr.FieldKeyBurstDuration: bst.last.Time().Sub(bst.first),
+	})
+}
+
+// endBursts ends all bursts, or only those quiet for the gap when all is
+// false, delivering their summaries.
+func (b *Burst) endBursts(all bool) {
+	var summaries []*logr.LogRec
+
+	b.mux.Lock()
+	for key, bst := range b.bursts {
+		if !all && bst.now().Sub(bst.lastTime()) < b.opts.Gap {
+			continue
+		}
+		if rec := bst.summary(); rec != nil {
+			summaries = append(summaries, rec)
+		}
+		delete(b.bursts, key)
+	}
+	b.mux.Unlock()
+
+	for _, rec := range summaries {
+		b.target.Log(rec)
+	}
+}
+
+// start periodically ends quiet bursts until the target is shut down.
+func (b *Burst) start() {
+	defer close(b.done)
+	interval := b.opts.Gap / 2
+	if interval < time.Millisecond*10 {
+		interval = time.Millisecond * 10
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.quit:
+			return
+		case <-ticker.C:
+			b.endBursts(false)
+		}
+	}
+}
+
+// Health returns the wrapped target's health.
+func (b *Burst) Health() logr.TargetHealth {
+	return logr.HealthOf(b.target)
+}
+
+// EnableMetrics enables metrics collection for the wrapped target, if supported.
+func (b *Burst) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if tm, ok := b.target.(logr.TargetWithMetrics); ok {
+		return tm.EnableMetrics(collector, updateFreqMillis)
+	}
+	return nil
+}
+
+// Validate validates the wrapped target, if supported.
+func (b *Burst) Validate() error {
+	return logr.ValidateOf(b.target)
+}
+
+// UpdateQueueMetrics updates the queue metrics of the wrapped target, if supported.
+func (b *Burst) UpdateQueueMetrics() {
+	logr.UpdateQueueMetricsOf(b.target)
+}
+
+// Shutdown delivers the summaries of any bursts in progress then shuts down
+// the wrapped target. Calling Shutdown more than once returns the result of
+// the first call.
+func (b *Burst) Shutdown(ctx context.Context) error {
+	b.shutdownOnce.Do(func() {
+		close(b.quit)
+		<-b.done
+		b.endBursts(true)
+		b.shutdownErr = b.target.Shutdown(ctx)
+	})
+	return b.shutdownErr
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (b *Burst) String() string {
+	if b.name != "" {
+		return b.name
+	}
+	return fmt.Sprintf("%T", b)
+}
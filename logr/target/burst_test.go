@@ -0,0 +1,50 @@
+package target_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func TestBurstSummary(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	writer := target.NewWriterTarget(filter, &format.Plain{Delim: " | ", DisableTimestamp: true}, buf, 100)
+	burst := target.NewBurstTarget(writer, target.BurstOptions{Gap: time.Hour})
+	_ = lgr.AddTarget(burst)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info("repeated")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	output := buf.String()
+	if n := strings.Count(output, "repeated"); n != 2 {
+		t.Errorf("expected first and last records, got %d records:\n%s", n, output)
+	}
+	if !strings.Contains(output, logr.FieldKeySuppressed+"=3") {
+		t.Errorf("expected 3 records suppressed:\n%s", output)
+	}
+}
+
+func TestBurstShutdownTwice(t *testing.T) {
+	writer := target.NewWriterTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{}, &test.Buffer{}, 100)
+	burst := target.NewBurstTarget(writer, target.BurstOptions{})
+
+	if err := burst.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := burst.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
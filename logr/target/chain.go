@@ -0,0 +1,227 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+)
+
+// DefaultChainRetryInterval is how often a failing stage is sent a log record
+// when `ChainStage.RetryInterval` is zero.
+const DefaultChainRetryInterval = time.Second
+
+// ChainStage is one target within a Chain.
+type ChainStage struct {
+	// Target receives log records for this stage.
+	Target logr.Target
+
+	// Timeout is the maximum amount of time to wait for queue space in this
+	// stage before spilling the log record to the next stage. Zero means
+	// spill immediately when the queue is full.
+	Timeout time.Duration
+
+	// RetryInterval is how often a stage whose most recent write failed, see
+	// `logr.HealthOf`, is sent a log record to check whether it has recovered.
+	// That record is also spilled to the next stage. Defaults to
+	// DefaultChainRetryInterval.
+	RetryInterval time.Duration
+}
+
+// Chain is a target that wraps an ordered list of targets, such as a fast
+// primary and a slower secondary. Each log record is delivered to the first
+// stage that accepts it; when a stage's queue is full, or its writes are
+// failing, the record spills to the next stage instead of being dropped or
+// blocking. Log records that no stage accepts are dropped and counted.
+//
+// Stages should implement `logr.TargetWithTryLog` (all targets built on
+// `logr.Basic` do). A stage that does not is treated as always accepting.
+type Chain struct {
+	name   string
+	stages []ChainStage
+
+	accepted []uint64
+	dropped  uint64
+	retried  []int64 // unix nanos each failing stage was last sent a record
+
+	acceptedCounters []logr.Counter
+	droppedCounter   logr.Counter
+}
+
+// NewChainTarget creates a target that spills log records through the stages in order.
+func NewChainTarget(stages ...ChainStage) *Chain {
+	stages = append([]ChainStage(nil), stages...)
+	for i := range stages {
+		if stages[i].RetryInterval <= 0 {
+			stages[i].RetryInterval = DefaultChainRetryInterval
+		}
+	}
+	return &Chain{
+		stages:   stages,
+		accepted: make([]uint64, len(stages)),
+		retried:  make([]int64, len(stages)),
+	}
+}
+
+// SetName provides an optional name for the target.
+func (c *Chain) SetName(name string) {
+	c.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (c *Chain) Name() string {
+	return c.name
+}
+
+// IsLevelEnabled returns true if any stage has the level enabled.
+func (c *Chain) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	for _, st := range c.stages {
+		e, s := st.Target.IsLevelEnabled(lvl)
+		enabled = enabled || e
+		stacktrace = stacktrace || s
+	}
+	return enabled, stacktrace
+}
+
+// Formatter returns the Formatter of the first stage.
+func (c *Chain) Formatter() logr.Formatter {
+	if len(c.stages) == 0 {
+		return &logr.DefaultFormatter{}
+	}
+	return c.stages[0].Target.Formatter()
+}
+
+// Log delivers the log record to the first stage that accepts it.
+func (c *Chain) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		logr.ForwardFlush(rec, c.targets()...)
+		return
+	}
+
+	for i, st := range c.stages {
+		if enabled, _ := st.Target.IsLevelEnabled(rec.Level()); !enabled {
+			continue
+		}
+		if h := logr.HealthOf(st.Target); h.Known && !h.Up {
+			// spill, after sending the record to the failing stage if due a retry.
+			if c.retryDue(i, rec.Logger().Logr().Now()) {
+				c.tryLog(st, rec)
+			}
+			continue
+		}
+		if !c.tryLog(st, rec) {
+			continue // spill to next stage
+		}
+		atomic.AddUint64(&c.accepted[i], 1)
+		if c.acceptedCounters != nil {
+			c.acceptedCounters[i].Inc()
+		}
+		return
+	}
+
+	atomic.AddUint64(&c.dropped, 1)
+	if c.droppedCounter != nil {
+		c.droppedCounter.Inc()
+	}
+}
+
+// tryLog delivers the log record to the stage, returning false if the stage
+// queue remained full.
+func (c *Chain) tryLog(st ChainStage, rec *logr.LogRec) bool {
+	if tt, ok := st.Target.(logr.TargetWithTryLog); ok {
+		return tt.TryLog(rec, st.Timeout)
+	}
+	st.Target.Log(rec)
+	return true
+}
+
+// retryDue returns true, at most once per retry interval, if a failing stage
+// should be sent a log record.
+func (c *Chain) retryDue(i int, now time.Time) bool {
+	last := atomic.LoadInt64(&c.retried[i])
+	if now.UnixNano()-last < int64(c.stages[i].RetryInterval) {
+		return false
+	}
+	return atomic.CompareAndSwapInt64(&c.retried[i], last, now.UnixNano())
+}
+
+// StageCounts returns the number of log records accepted by each stage,
+// in stage order, and the number of log records dropped by all stages.
+func (c *Chain) StageCounts() (accepted []uint64, dropped uint64) {
+	accepted = make([]uint64, len(c.accepted))
+	for i := range c.accepted {
+		accepted[i] = atomic.LoadUint64(&c.accepted[i])
+	}
+	return accepted, atomic.LoadUint64(&c.dropped)
+}
+
+// EnableMetrics enables metrics collection for this target and any stages
+// that support metrics. The number of log records accepted per stage is
+// counted via a LoggedCounter named "<name>/stage<N>".
+func (c *Chain) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	errs := merror.New()
+
+	counters := make([]logr.Counter, len(c.stages))
+	for i, st := range c.stages {
+		counter, err := collector.LoggedCounter(fmt.Sprintf("%v/stage%d", c, i))
+		if err != nil {
+			return err
+		}
+		counters[i] = counter
+
+		if tm, ok := st.Target.(logr.TargetWithMetrics); ok {
+			errs.Append(tm.EnableMetrics(collector, updateFreqMillis))
+		}
+	}
+	dropped, err := collector.DroppedCounter(fmt.Sprintf("%v", c))
+	if err != nil {
+		return err
+	}
+	c.acceptedCounters = counters
+	c.droppedCounter = dropped
+	return errs.ErrorOrNil()
+}
+
+// Validate validates all stages, if supported.
+func (c *Chain) Validate() error {
+	targets := make([]logr.Target, 0, len(c.stages))
+	for _, st := range c.stages {
+		targets = append(targets, st.Target)
+	}
+	return logr.ValidateOf(targets...)
+}
+
+// UpdateQueueMetrics updates the queue metrics of all stages, if supported.
+func (c *Chain) UpdateQueueMetrics() {
+	for _, st := range c.stages {
+		logr.UpdateQueueMetricsOf(st.Target)
+	}
+}
+
+// Shutdown shuts down all stages.
+func (c *Chain) Shutdown(ctx context.Context) error {
+	errs := merror.New()
+	for _, st := range c.stages {
+		errs.Append(st.Target.Shutdown(ctx))
+	}
+	return errs.ErrorOrNil()
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (c *Chain) String() string {
+	if c.name != "" {
+		return c.name
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+func (c *Chain) targets() []logr.Target {
+	targets := make([]logr.Target, 0, len(c.stages))
+	for _, st := range c.stages {
+		targets = append(targets, st.Target)
+	}
+	return targets
+}
@@ -0,0 +1,64 @@
+package target_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func TestChainSpillsOnWriteErrors(t *testing.T) {
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	formatter := &format.Plain{Delim: " | ", DisableTimestamp: true}
+	primary := test.NewFailingTarget(filter, formatter)
+	buf := &test.Buffer{}
+	secondary := target.NewWriterTarget(filter, formatter, buf, 100)
+	chain := target.NewChainTarget(
+		target.ChainStage{Target: primary, RetryInterval: time.Hour},
+		target.ChainStage{Target: secondary},
+	)
+	_ = lgr.AddTarget(chain)
+	logger := lgr.NewLogger()
+
+	// the primary accepts the first record, then fails to write it.
+	logger.Info("lost")
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	for i := 0; i < 3; i++ {
+		logger.Info("spilled")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if n := strings.Count(buf.String(), "spilled"); n != 3 {
+		t.Errorf("expected 3 spilled records, got %d", n)
+	}
+	accepted, dropped := chain.StageCounts()
+	if accepted[0] != 1 || accepted[1] != 3 || dropped != 0 {
+		t.Errorf("unexpected stage counts %v, dropped %d", accepted, dropped)
+	}
+}
+
+func TestTryLogAfterShutdown(t *testing.T) {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	tgt := target.NewWriterTarget(filter, &format.Plain{}, &test.Buffer{}, 1)
+	_ = lgr.AddTarget(tgt)
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+	rec := logr.NewLogRec(logr.Info, lgr.NewLogger(), "after shutdown", nil, false)
+	if tgt.TryLog(rec, time.Millisecond) {
+		t.Error("TryLog accepted a record after shutdown")
+	}
+}
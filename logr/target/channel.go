@@ -0,0 +1,93 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// ChannelOptions configures a channel target.
+type ChannelOptions struct {
+	// BlockTimeout is how long to wait for the consumer when the channel is
+	// full before dropping the log record. When zero records are dropped
+	// immediately if the channel is full.
+	BlockTimeout time.Duration
+
+	// CloseOnShutdown closes the channel when the target is shut down, so
+	// consumers ranging over it exit. Leave false if the channel is shared
+	// with other senders.
+	CloseOnShutdown bool
+}
+
+// Channel forwards log records to a channel for in-process consumers, such
+// as a rules engine reacting to log records.
+//
+// Each log record sent is a shallow copy of the record shared with other
+// targets. Consumers must treat records as read-only and, in particular,
+// must not modify the map returned by `LogRec.Fields`.
+type Channel struct {
+	logr.Basic
+	opts ChannelOptions
+
+	mux    sync.Mutex
+	out    chan<- *logr.LogRec
+	closed bool
+}
+
+// NewChannelTarget creates a target that forwards log records to out.
+// Records are not formatted, so no Formatter is needed.
+func NewChannelTarget(filter logr.Filter, out chan<- *logr.LogRec, opts ChannelOptions, maxQueue int) *Channel {
+	c := &Channel{out: out, opts: opts}
+	c.Basic.Start(c, c, filter, nil, maxQueue)
+	return c
+}
+
+// Write sends a copy of the log record to the channel, dropping it with an
+// error if the channel stays full.
+func (c *Channel) Write(rec *logr.LogRec) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.closed {
+		return errors.New("channel target is shut down")
+	}
+
+	rec = rec.WithTime(rec.Time())
+	select {
+	case c.out <- rec:
+		return nil
+	default:
+	}
+	if c.opts.BlockTimeout <= 0 {
+		return errors.New("channel target full, dropped log record")
+	}
+
+	timer := time.NewTimer(c.opts.BlockTimeout)
+	defer timer.Stop()
+	select {
+	case c.out <- rec:
+		return nil
+	case <-timer.C:
+		return errors.New("channel target timed out, dropped log record")
+	}
+}
+
+// Shutdown stops sending log records after making best effort to send any
+// queued records, then closes the channel if `ChannelOptions.CloseOnShutdown`
+// is set.
+func (c *Channel) Shutdown(ctx context.Context) error {
+	err := c.Basic.Shutdown(ctx)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !c.closed {
+		c.closed = true
+		if c.opts.CloseOnShutdown {
+			close(c.out)
+		}
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package target
+
+import (
+	"io"
+	"os"
+)
+
+// Colorize determines whether a target requests ANSI colorized output from
+// its formatter, see `logr.FormatterWithOptions`.
+type Colorize int
+
+const (
+	// ColorizeAuto colorizes output written to os.Stdout or os.Stderr when
+	// it is a terminal, unless the NO_COLOR environment variable is set.
+	ColorizeAuto Colorize = iota
+
+	// ColorizeAlways always colorizes output.
+	ColorizeAlways
+
+	// ColorizeNever never colorizes output.
+	ColorizeNever
+)
+
+// enabled returns true if output to w should be colorized.
+func (c Colorize) enabled(w io.Writer) bool {
+	switch c {
+	case ColorizeAlways:
+		return true
+	case ColorizeNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal returns true if w is os.Stdout or os.Stderr and is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || (f != os.Stdout && f != os.Stderr) {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
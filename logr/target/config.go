@@ -0,0 +1,87 @@
+package target
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/logr"
+)
+
+func init() {
+	logr.RegisterTargetType("console", newConsoleFromConfig)
+	logr.RegisterTargetType("file", func(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+		var opts FileOptions
+		if err := decodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return NewFileTarget(filter, formatter, opts, maxQueue), nil
+	})
+	logr.RegisterTargetType("routingfile", func(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+		var opts RoutingFileOptions
+		if err := decodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return NewRoutingFileTarget(filter, formatter, opts, maxQueue)
+	})
+	logr.RegisterTargetType("http", func(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+		var opts HTTPOptions
+		if err := decodeOptions(options, &opts); err != nil {
+			return nil, err
+		}
+		return NewHTTPTarget(filter, formatter, opts, maxQueue)
+	})
+}
+
+// consoleConfig is the configuration options of a console target.
+type consoleConfig struct {
+	// ErrThreshold is the name of the least severe level written to stderr.
+	ErrThreshold string
+
+	// Colorize, when set, always (true) or never (false) colorizes output;
+	// when omitted output to a terminal is colorized.
+	Colorize *bool
+}
+
+func newConsoleFromConfig(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+	var cc consoleConfig
+	if err := decodeOptions(options, &cc); err != nil {
+		return nil, err
+	}
+	var opts ConsoleOptions
+	if cc.ErrThreshold != "" {
+		lvl, err := stdLevel(cc.ErrThreshold)
+		if err != nil {
+			return nil, err
+		}
+		opts.ErrThreshold = &lvl
+	}
+	if cc.Colorize != nil {
+		opts.Colorize = ColorizeNever
+		if *cc.Colorize {
+			opts.Colorize = ColorizeAlways
+		}
+	}
+	return NewConsoleTarget(filter, formatter, opts, maxQueue), nil
+}
+
+// stdLevel returns the standard level with the name, ignoring case.
+func stdLevel(name string) (logr.Level, error) {
+	for _, lvl := range []logr.Level{logr.Panic, logr.Fatal, logr.Error, logr.Warn, logr.Info, logr.Debug, logr.Trace} {
+		if strings.EqualFold(lvl.Name, name) {
+			return lvl, nil
+		}
+	}
+	return logr.Level{}, fmt.Errorf("unknown level %q", name)
+}
+
+// decodeOptions decodes JSON target options into v, rejecting unknown options.
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	if len(options) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(options))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,132 @@
+package target
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+)
+
+// ConsoleOptions provides options for a Console target.
+type ConsoleOptions struct {
+	// Out receives log records less severe than `ErrThreshold`.
+	// Defaults to os.Stdout.
+	Out io.Writer
+
+	// Err receives log records at or more severe than `ErrThreshold`.
+	// Defaults to os.Stderr.
+	Err io.Writer
+
+	// ErrThreshold is the least severe level written to `Err`.
+	// Defaults to logr.Warn.
+	ErrThreshold *logr.Level
+
+	// Colorize determines whether the formatter is asked to colorize output,
+	// decided per writer. Defaults to ColorizeAuto.
+	Colorize Colorize
+
+	// LevelColors are the colors per level when colorizing. Defaults to
+	// `logr.DefaultLevelColors`.
+	LevelColors logr.LevelColors
+}
+
+// Console outputs log records to one of two writers based on level,
+// following the Unix convention of writing warnings and errors to stderr
+// and everything else to stdout.
+type Console struct {
+	logr.Basic
+	out       io.Writer
+	err       io.Writer
+	threshold logr.Level
+
+	colorOut    bool
+	colorErr    bool
+	levelColors logr.LevelColors
+}
+
+type syncer interface {
+	Sync() error
+}
+
+// NewConsoleTarget creates a target which splits log records between two writers.
+func NewConsoleTarget(filter logr.Filter, formatter logr.Formatter, opts ConsoleOptions, maxQueue int) *Console {
+	c := &Console{out: opts.Out, err: opts.Err, threshold: logr.Warn}
+	if c.out == nil {
+		c.out = os.Stdout
+	}
+	if c.err == nil {
+		c.err = os.Stderr
+	}
+	if opts.ErrThreshold != nil {
+		c.threshold = *opts.ErrThreshold
+	}
+	c.colorOut = opts.Colorize.enabled(c.out)
+	c.colorErr = opts.Colorize.enabled(c.err)
+	c.levelColors = opts.LevelColors
+	c.Basic.Start(c, c, filter, formatter, maxQueue)
+	return c
+}
+
+// Write converts the log record to bytes, via the Formatter,
+// and outputs to the writer selected by level.
+func (c *Console) Write(rec *logr.LogRec) error {
+	stacktrace := c.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	w, color := c.writerFor(rec.Level())
+	opts := logr.FormatOptions{Stacktrace: stacktrace, Color: color, LevelColors: c.levelColors}
+	buf, err := logr.FormatWithOptions(c.Formatter(), rec, opts, buf)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writerFor returns the writer for the specified level and whether output to
+// it is colorized.
+func (c *Console) writerFor(lvl logr.Level) (io.Writer, bool) {
+	if lvl.ID <= c.threshold.ID {
+		return c.err, c.colorErr
+	}
+	return c.out, c.colorOut
+}
+
+// Flush flushes or syncs both writers, whichever each supports, e.g.
+// `*bufio.Writer` or `*os.File`. It is called automatically when the target
+// is flushed or shut down.
+func (c *Console) Flush() error {
+	errs := merror.New()
+	for _, w := range []io.Writer{c.out, c.err} {
+		switch o := w.(type) {
+		case interface{ Flush() error }:
+			errs.Append(o.Flush())
+		case syncer:
+			if err := o.Sync(); err != nil && !isUnsupportedSync(err) {
+				errs.Append(err)
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Shutdown flushes any remaining log records then flushes or syncs both
+// writers.
+func (c *Console) Shutdown(ctx context.Context) error {
+	errs := merror.New()
+	errs.Append(c.Basic.Shutdown(ctx))
+	errs.Append(c.Flush())
+	return errs.ErrorOrNil()
+}
+
+// isUnsupportedSync returns true for errors returned when syncing a
+// terminal or pipe, which cannot be synced.
+func isUnsupportedSync(err error) bool {
+	return errors.Is(err, syscall.EINVAL)
+}
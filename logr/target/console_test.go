@@ -0,0 +1,105 @@
+package target_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+// syncBuffer is a Buffer that counts calls to Sync.
+type syncBuffer struct {
+	test.Buffer
+	mux   sync.Mutex
+	syncs int
+}
+
+func (sb *syncBuffer) Sync() error {
+	sb.mux.Lock()
+	defer sb.mux.Unlock()
+	sb.syncs++
+	return nil
+}
+
+func (sb *syncBuffer) syncCount() int {
+	sb.mux.Lock()
+	defer sb.mux.Unlock()
+	return sb.syncs
+}
+
+func TestConsoleRoutesByLevel(t *testing.T) {
+	lgr := &logr.Logr{}
+	out, errOut := &syncBuffer{}, &syncBuffer{}
+	filter := &logr.StdFilter{Lvl: logr.Debug}
+	_ = lgr.AddTarget(target.NewConsoleTarget(filter, &format.Plain{Delim: " | "},
+		target.ConsoleOptions{Out: out, Err: errOut}, 100))
+
+	logger := lgr.NewLogger()
+	logger.Debug("debug record")
+	logger.Info("info record")
+	logger.Warn("warn record")
+	logger.Error("error record")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// warnings and errors go to Err, everything else to Out.
+	for _, msg := range []string{"debug record", "info record"} {
+		if !strings.Contains(out.String(), msg) || strings.Contains(errOut.String(), msg) {
+			t.Errorf("expected %q on Out only", msg)
+		}
+	}
+	for _, msg := range []string{"warn record", "error record"} {
+		if !strings.Contains(errOut.String(), msg) || strings.Contains(out.String(), msg) {
+			t.Errorf("expected %q on Err only", msg)
+		}
+	}
+}
+
+func TestConsoleErrThreshold(t *testing.T) {
+	lgr := &logr.Logr{}
+	out, errOut := &syncBuffer{}, &syncBuffer{}
+	threshold := logr.Error
+	_ = lgr.AddTarget(target.NewConsoleTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{Delim: " | "},
+		target.ConsoleOptions{Out: out, Err: errOut, ErrThreshold: &threshold}, 100))
+
+	logger := lgr.NewLogger()
+	logger.Warn("warn record")
+	logger.Error("error record")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if !strings.Contains(out.String(), "warn record") {
+		t.Error("expected warn on Out below an Error threshold")
+	}
+	if !strings.Contains(errOut.String(), "error record") {
+		t.Error("expected error on Err")
+	}
+}
+
+func TestConsoleFlushSyncsBothWriters(t *testing.T) {
+	lgr := &logr.Logr{}
+	out, errOut := &syncBuffer{}, &syncBuffer{}
+	_ = lgr.AddTarget(target.NewConsoleTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{Delim: " | "},
+		target.ConsoleOptions{Out: out, Err: errOut}, 100))
+
+	lgr.NewLogger().Info("flushed")
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(out.String(), "flushed") {
+		t.Error("record not written by flush")
+	}
+	if out.syncCount() == 0 || errOut.syncCount() == 0 {
+		t.Errorf("expected both writers synced by flush, got %d and %d", out.syncCount(), errOut.syncCount())
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
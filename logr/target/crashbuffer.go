@@ -0,0 +1,56 @@
+package target
+
+import (
+	"bytes"
+
+	"github.com/mattermost/logr"
+)
+
+// CrashBuffer keeps the last N formatted log records in memory so they can be
+// dumped from a signal or panic handler. `Dump` does not acquire any locks,
+// meaning it can be called even when the logging pipeline is deadlocked.
+type CrashBuffer struct {
+	logr.Basic
+	ring *recordRing
+}
+
+// NewCrashBufferTarget creates a target that retains the last `size` formatted
+// log records in memory.
+func NewCrashBufferTarget(filter logr.Filter, formatter logr.Formatter, size int, maxQueue int) *CrashBuffer {
+	if size < 1 {
+		size = 1
+	}
+	cb := &CrashBuffer{ring: newRecordRing(size)}
+	cb.Basic.Start(cb, cb, filter, formatter, maxQueue)
+	return cb
+}
+
+// Write converts the log record to bytes, via the Formatter,
+// and stores it in the buffer, overwriting the oldest record when full.
+func (cb *CrashBuffer) Write(rec *logr.LogRec) error {
+	stacktrace := cb.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := cb.Formatter().Format(rec, stacktrace, buf)
+	if err != nil {
+		return err
+	}
+	line := make([]byte, buf.Len())
+	copy(line, buf.Bytes())
+
+	cb.ring.add(line)
+	return nil
+}
+
+// Dump returns the buffered log records, oldest first. It is safe to call
+// from a crash handler since no locks are acquired. Records written while
+// dumping may or may not be included.
+func (cb *CrashBuffer) Dump() []byte {
+	var buf bytes.Buffer
+	cb.ring.each(func(line []byte) {
+		buf.Write(line)
+	})
+	return buf.Bytes()
+}
@@ -0,0 +1,266 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// DefaultDedupWindow is the deduplication window when the window passed to
+// `NewDedupTarget` is zero.
+const DefaultDedupWindow = time.Second * 10
+
+// DedupHash returns the hash identifying duplicate log records.
+type DedupHash func(rec *logr.LogRec) uint64
+
+// DedupMsg hashes the level and message of a log record, ignoring fields.
+func DedupMsg(rec *logr.LogRec) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(rec.Level().Name))
+	h.Write([]byte{0})
+	h.Write([]byte(rec.Msg()))
+	return h.Sum64()
+}
+
+// DedupFields returns a DedupHash of the level, message and the named fields
+// of a log record. With no keys, all fields participate; this is the default.
+func DedupFields(keys ...string) DedupHash {
+	return func(rec *logr.LogRec) uint64 {
+		h := fnv.New64a()
+		h.Write([]byte(rec.Level().Name))
+		h.Write([]byte{0})
+		h.Write([]byte(rec.Msg()))
+
+		fields := rec.Fields()
+		names := keys
+		if len(names) == 0 {
+			names = make([]string, 0, len(fields))
+			for k := range fields {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+		}
+		for _, k := range names {
+			v, ok := fields[k]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(h, "\x00%s=%v", k, v)
+		}
+		return h.Sum64()
+	}
+}
+
+// dedupEntry tracks the duplicates of one log record within a window.
+type dedupEntry struct {
+	first  time.Time
+	now    func() time.Time // clock of the Logr the records came from
+	last   *logr.LogRec
+	count  uint64 // duplicates suppressed
+	weight uint64 // sum of the sample rates of duplicates suppressed
+}
+
+// Dedup is a target that wraps another target and collapses duplicate log
+// records. The first record is delivered immediately and duplicates within
+// the window that follows are suppressed. When the window expires, or when
+// the target is flushed or shut down, the last duplicate is delivered with
+// "(repeated N times)" appended to the message and N in the
+// `logr.FieldKeyRepeated` field. Its sampling rate, see `logr.LogRec.SampleRate`,
+// is set to the number of duplicates it represents.
+type Dedup struct {
+	name   string
+	target logr.Target
+	window time.Duration
+	hash   DedupHash
+
+	mux     sync.Mutex
+	entries map[uint64]*dedupEntry
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// NewDedupTarget creates a target that wraps target and collapses duplicate
+// log records within window, or DefaultDedupWindow if zero. Records are duplicates if their level, message
+// and fields are equal; use `SetHash` to change this.
+func NewDedupTarget(target logr.Target, window time.Duration) *Dedup {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+	d := &Dedup{
+		target:  target,
+		window:  window,
+		hash:    DedupFields(),
+		entries: make(map[uint64]*dedupEntry),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go d.start()
+	return d
+}
+
+// SetHash replaces the function identifying duplicate log records, e.g.
+// `DedupMsg` or `DedupFields("user_id")`. Must be called before the target
+// is added to a Logr.
+func (d *Dedup) SetHash(hash DedupHash) {
+	if hash == nil {
+		hash = DedupFields()
+	}
+	d.hash = hash
+}
+
+// SetName provides an optional name for the target.
+func (d *Dedup) SetName(name string) {
+	d.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (d *Dedup) Name() string {
+	return d.name
+}
+
+// IsLevelEnabled returns the wrapped target's level status.
+func (d *Dedup) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	return d.target.IsLevelEnabled(lvl)
+}
+
+// Formatter returns the wrapped target's Formatter.
+func (d *Dedup) Formatter() logr.Formatter {
+	return d.target.Formatter()
+}
+
+// Log delivers the log record unless it duplicates a record delivered
+// within the window.
+func (d *Dedup) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		d.expire(true)
+		logr.ForwardFlush(rec, d.target)
+		return
+	}
+
+	key := d.hash(rec)
+	now := rec.Time()
+
+	d.mux.Lock()
+	var expired *logr.LogRec
+	entry, ok := d.entries[key]
+	if ok && now.Sub(entry.first) >= d.window {
+		expired = entry.summary()
+		ok = false
+	}
+	if ok {
+		entry.last = rec
+		entry.count++
+		entry.weight += rec.SampleRate()
+	} else {
+		d.entries[key] = &dedupEntry{first: now, now: rec.Logger().Logr().Now}
+	}
+	d.mux.Unlock()
+
+	if expired != nil {
+		d.target.Log(expired)
+	}
+	if !ok {
+		d.target.Log(rec)
+	}
+}
+
+// summary returns the last duplicate annotated with the repeat count, or nil
+// if there were no duplicates.
+func (entry *dedupEntry) summary() *logr.LogRec {
+	if entry.last == nil {
+		return nil
+	}
+	fields := logr.Fields{}
+	for k, v := range entry.last.Fields() {
+		fields[k] = v
+	}
+	fields[logr.FieldKeyRepeated] = entry.count
+	msg := fmt.Sprintf("%s (repeated %d times)", entry.last.Msg(), entry.count)
+	return entry.last.WithMessage(msg, fields).WithSampleRate(entry.weight)
+}
+
+// expire ends all windows, or only those that have expired when all is
+// false, delivering their summaries.
+func (d *Dedup) expire(all bool) {
+	var summaries []*logr.LogRec
+
+	d.mux.Lock()
+	for key, entry := range d.entries {
+		if !all && entry.now().Sub(entry.first) < d.window {
+			continue
+		}
+		if rec := entry.summary(); rec != nil {
+			summaries = append(summaries, rec)
+		}
+		delete(d.entries, key)
+	}
+	d.mux.Unlock()
+
+	for _, rec := range summaries {
+		d.target.Log(rec)
+	}
+}
+
+// start periodically ends expired windows until the target is shut down.
+func (d *Dedup) start() {
+	defer close(d.done)
+	interval := d.window / 2
+	if interval < time.Millisecond*10 {
+		interval = time.Millisecond * 10
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+			d.expire(false)
+		}
+	}
+}
+
+// Health returns the wrapped target's health.
+func (d *Dedup) Health() logr.TargetHealth {
+	return logr.HealthOf(d.target)
+}
+
+// EnableMetrics enables metrics collection for the wrapped target, if supported.
+func (d *Dedup) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if tm, ok := d.target.(logr.TargetWithMetrics); ok {
+		return tm.EnableMetrics(collector, updateFreqMillis)
+	}
+	return nil
+}
+
+// Validate validates the wrapped target, if supported.
+func (d *Dedup) Validate() error {
+	return logr.ValidateOf(d.target)
+}
+
+// UpdateQueueMetrics updates the queue metrics of the wrapped target, if supported.
+func (d *Dedup) UpdateQueueMetrics() {
+	logr.UpdateQueueMetricsOf(d.target)
+}
+
+// Shutdown delivers the summaries of any duplicates then shuts down the
+// wrapped target.
+func (d *Dedup) Shutdown(ctx context.Context) error {
+	close(d.quit)
+	<-d.done
+	d.expire(true)
+	return d.target.Shutdown(ctx)
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (d *Dedup) String() string {
+	if d.name != "" {
+		return d.name
+	}
+	return fmt.Sprintf("%T", d)
+}
@@ -0,0 +1,146 @@
+package target_test
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/target"
+)
+
+// newDedupLogr returns a synchronous Logr for the dedup target, with a
+// simulated clock advanced via the returned function.
+func newDedupLogr(t *testing.T, dedup *target.Dedup) (*logr.Logr, func(d time.Duration)) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	lgr := &logr.Logr{SyncMode: true}
+	lgr.SetClock(func() time.Time {
+		return time.Unix(0, atomic.LoadInt64(&now))
+	})
+	if err := lgr.AddTarget(dedup); err != nil {
+		t.Fatal(err)
+	}
+	return lgr, func(d time.Duration) {
+		atomic.AddInt64(&now, int64(d))
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	rt := newRecordTarget()
+	dedup := target.NewDedupTarget(rt, time.Minute)
+	lgr, advance := newDedupLogr(t, dedup)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 4; i++ {
+		logger.Info("dup")
+	}
+	logger.Info("other")
+	logger.WithField("user", "a").Info("dup")
+
+	// duplicates are suppressed; differing fields are not duplicates.
+	if msgs := rt.messages(); fmt.Sprint(msgs) != "[dup other dup]" {
+		t.Errorf("expected [dup other dup], got %v", msgs)
+	}
+
+	// the next duplicate after the window ends it, delivering the summary
+	// before starting a new window.
+	advance(time.Minute)
+	logger.Info("dup")
+	if msgs := rt.messages(); fmt.Sprint(msgs) != "[dup other dup dup (repeated 3 times) dup]" {
+		t.Errorf("expected the summary then the record, got %v", msgs)
+	}
+	if n := rt.fields[3][logr.FieldKeyRepeated]; n != uint64(3) {
+		t.Errorf("expected %s of 3, got %v", logr.FieldKeyRepeated, n)
+	}
+	if rate := rt.rates[3]; rate != 3 {
+		t.Errorf("expected the summary to represent 3 records, got %d", rate)
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDedupExpiresOnTick(t *testing.T) {
+	rt := newRecordTarget()
+	dedup := target.NewDedupTarget(rt, 20*time.Millisecond)
+	lgr, advance := newDedupLogr(t, dedup)
+	defer lgr.Shutdown()
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Info("dup")
+	}
+
+	// windows are timed by the Logr's clock, not the wall clock.
+	time.Sleep(50 * time.Millisecond)
+	if msgs := rt.messages(); len(msgs) != 1 {
+		t.Fatalf("expected the window still open, got %v", msgs)
+	}
+
+	advance(20 * time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rt.messages()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("summary not delivered once the window expired")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if msgs := rt.messages(); msgs[1] != "dup (repeated 2 times)" {
+		t.Errorf("expected the summary, got %v", msgs)
+	}
+}
+
+func TestDedupFlushAndShutdown(t *testing.T) {
+	rt := newRecordTarget()
+	dedup := target.NewDedupTarget(rt, time.Hour)
+	lgr, _ := newDedupLogr(t, dedup)
+
+	logger := lgr.NewLogger()
+	logger.Info("dup")
+	logger.Info("dup")
+	logger.Info("dup")
+	logger.Info("once")
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	// a flush ends all windows; records without duplicates have no summary.
+	if msgs := rt.messages(); fmt.Sprint(msgs) != "[dup once dup (repeated 2 times)]" {
+		t.Errorf("expected the summary delivered by flush, got %v", msgs)
+	}
+
+	logger.Info("dup")
+	logger.Info("dup")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	if msgs := rt.messages(); fmt.Sprint(msgs[3:]) != "[dup dup (repeated 1 times)]" {
+		t.Errorf("expected a new window, summarized on shutdown, got %v", msgs[3:])
+	}
+}
+
+func TestDedupSampleRateWeight(t *testing.T) {
+	rt := newRecordTarget()
+	dedup := target.NewDedupTarget(rt, time.Hour)
+	dedup.SetHash(target.DedupMsg)
+	lgr, advance := newDedupLogr(t, dedup)
+
+	// each record emitted by the sampler after the first represents 2.
+	logger := lgr.NewLogger().EveryDuration(time.Second)
+	for i := 0; i < 7; i++ {
+		logger.Info("sampled")
+		advance(600 * time.Millisecond)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if msgs := rt.messages(); fmt.Sprint(msgs) != "[sampled sampled (repeated 3 times)]" {
+		t.Fatalf("expected the first record and a summary, got %v", msgs)
+	}
+	// 3 duplicates, each representing 2 records.
+	if rate := rt.rates[1]; rate != 6 {
+		t.Errorf("expected the summary to represent 6 records, got %d", rate)
+	}
+}
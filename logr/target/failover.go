@@ -0,0 +1,160 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+)
+
+// FailoverOptions configures a failover target. The zero value uses the
+// same defaults as `logr.SupervisorOptions`.
+type FailoverOptions = logr.SupervisorOptions
+
+// Failover is a target that wraps a primary target, such as a network
+// target, and a fallback target, such as a local file. Log records are
+// delivered to the primary until it is found unhealthy, then to the fallback
+// until the primary recovers. See `logr.HealthSwitch` for how health is
+// checked. Each record is delivered to one target, except for the probe
+// records delivered to both while failed over when `FailoverOptions.IsHealthy`
+// is nil. Records already queued within the primary when switching are not
+// recovered.
+type Failover struct {
+	name     string
+	primary  logr.Target
+	fallback logr.Target
+	sw       *logr.HealthSwitch
+
+	forwarded uint64
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewFailoverTarget creates a target that delivers log records to primary,
+// or to fallback while primary is unhealthy.
+func NewFailoverTarget(primary logr.Target, fallback logr.Target, opts FailoverOptions) *Failover {
+	f := &Failover{
+		primary:  primary,
+		fallback: fallback,
+	}
+	f.sw = logr.NewHealthSwitch(func() []logr.Target { return []logr.Target{primary} }, opts)
+	return f
+}
+
+// SetName provides an optional name for the target.
+func (f *Failover) SetName(name string) {
+	f.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (f *Failover) Name() string {
+	return f.name
+}
+
+// IsLevelEnabled returns true if either target has the level enabled.
+func (f *Failover) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	pe, ps := f.primary.IsLevelEnabled(lvl)
+	fe, fs := f.fallback.IsLevelEnabled(lvl)
+	return pe || fe, ps || fs
+}
+
+// Formatter returns the Formatter of the primary.
+func (f *Failover) Formatter() logr.Formatter {
+	return f.primary.Formatter()
+}
+
+// Log delivers the log record to the primary, or to the fallback while
+// failed over. Records are only delivered to a target with the level enabled.
+func (f *Failover) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		logr.ForwardFlush(rec, f.primary, f.fallback)
+		return
+	}
+
+	if !f.IsFailedOver() {
+		if enabled, _ := f.primary.IsLevelEnabled(rec.Level()); enabled {
+			f.primary.Log(rec)
+		}
+		return
+	}
+	if f.sw.Probe() {
+		if enabled, _ := f.primary.IsLevelEnabled(rec.Level()); enabled {
+			f.primary.Log(rec)
+		}
+	}
+	if enabled, _ := f.fallback.IsLevelEnabled(rec.Level()); enabled {
+		atomic.AddUint64(&f.forwarded, 1)
+		f.fallback.Log(rec)
+	}
+}
+
+// IsFailedOver returns true while log records are delivered to the fallback.
+func (f *Failover) IsFailedOver() bool {
+	return f.sw.IsFailedOver()
+}
+
+// Forwarded returns the number of log records delivered to the fallback.
+func (f *Failover) Forwarded() uint64 {
+	return atomic.LoadUint64(&f.forwarded)
+}
+
+// Health returns the health of the target currently receiving log records.
+func (f *Failover) Health() logr.TargetHealth {
+	if f.IsFailedOver() {
+		return logr.HealthOf(f.fallback)
+	}
+	return logr.HealthOf(f.primary)
+}
+
+// EnableMetrics enables metrics collection for both targets, if supported.
+func (f *Failover) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	errs := merror.New()
+	for _, t := range []logr.Target{f.primary, f.fallback} {
+		if tm, ok := t.(logr.TargetWithMetrics); ok {
+			if err := tm.EnableMetrics(collector, updateFreqMillis); err != nil {
+				errs.Append(err)
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Validate validates both targets, if supported.
+func (f *Failover) Validate() error {
+	return logr.ValidateOf(f.primary, f.fallback)
+}
+
+// UpdateQueueMetrics updates the queue metrics of both targets, if supported.
+func (f *Failover) UpdateQueueMetrics() {
+	logr.UpdateQueueMetricsOf(f.primary, f.fallback)
+}
+
+// Shutdown stops health checks and shuts down both targets. Calling Shutdown
+// more than once returns the result of the first call.
+func (f *Failover) Shutdown(ctx context.Context) error {
+	f.shutdownOnce.Do(func() {
+		f.sw.Stop()
+
+		errs := merror.New()
+		if err := f.primary.Shutdown(ctx); err != nil {
+			errs.Append(err)
+		}
+		if err := f.fallback.Shutdown(ctx); err != nil {
+			errs.Append(err)
+		}
+		f.shutdownErr = errs.ErrorOrNil()
+	})
+	return f.shutdownErr
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (f *Failover) String() string {
+	if f.name != "" {
+		return f.name
+	}
+	return fmt.Sprintf("%T", f)
+}
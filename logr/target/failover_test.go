@@ -0,0 +1,88 @@
+package target_test
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+// toggleTarget fails writes while failing is set.
+type toggleTarget struct {
+	logr.Basic
+	failing int32
+	written int32
+}
+
+func newToggleTarget() *toggleTarget {
+	tt := &toggleTarget{failing: 1}
+	tt.Basic.Start(tt, tt, &logr.StdFilter{Lvl: logr.Info}, &format.Plain{}, 100)
+	return tt
+}
+
+func (tt *toggleTarget) Write(rec *logr.LogRec) error {
+	if atomic.LoadInt32(&tt.failing) == 1 {
+		return errors.New("sink down")
+	}
+	atomic.AddInt32(&tt.written, 1)
+	return nil
+}
+
+// logUntil logs a record every millisecond until cond returns true, failing
+// the test after a timeout.
+func logUntil(t *testing.T, logger logr.Logger, msg string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out logging %q", msg)
+		}
+		logger.Info(msg)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {}
+	primary := newToggleTarget()
+	buf := &test.Buffer{}
+	fallback := target.NewWriterTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{}, buf, 100)
+	opts := target.FailoverOptions{CheckInterval: 5 * time.Millisecond, FailAfter: 2, RecoverAfter: 3}
+	failover := target.NewFailoverTarget(primary, fallback, opts)
+	_ = lgr.AddTarget(failover)
+	logger := lgr.NewLogger()
+
+	logUntil(t, logger, "primary down", failover.IsFailedOver)
+
+	// the primary stays down, so delivery must not switch back.
+	for i := 0; i < 50; i++ {
+		logger.Info("still down")
+		time.Sleep(time.Millisecond)
+		if !failover.IsFailedOver() {
+			t.Fatal("switched back to a primary that is still down")
+		}
+	}
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(buf.String(), "still down") {
+		t.Error("fallback did not receive records")
+	}
+
+	// probe records let the primary's health recover.
+	atomic.StoreInt32(&primary.failing, 0)
+	logUntil(t, logger, "primary up", func() bool { return !failover.IsFailedOver() })
+	if atomic.LoadInt32(&primary.written) == 0 {
+		t.Error("primary did not receive probe records")
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,230 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type FileOptions struct {
+	// Filename is the file to write logs to.  Backup log files will be retained
+	// in the same directory.  It uses <processname>-lumberjack.log in
+	// os.TempDir() if empty.
+	Filename string
+
+	// MaxSize is the maximum size in megabytes of the log file before it gets
+	// rotated. It defaults to 100 megabytes.
+	MaxSize int
+
+	// MaxAge is the maximum number of days to retain old log files based on the
+	// timestamp encoded in their filename.  Note that a day is defined as 24
+	// hours and may not exactly correspond to calendar days due to daylight
+	// savings, leap seconds, etc. The default is not to remove old log files
+	// based on age.
+	MaxAge int
+
+	// MaxBackups is the maximum number of old log files to retain.  The default
+	// is to retain all old log files (though MaxAge may still cause them to get
+	// deleted.)
+	MaxBackups int
+
+	// Compress determines if the rotated log files should be compressed
+	// using gzip. The default is not to perform compression.
+	Compress bool
+
+	// LockFile, when true, uses an OS advisory lock (flock) on "<Filename>.lock"
+	// around each write so that multiple processes sharing the same log file do
+	// not interleave lines, e.g. pre-fork servers. Every write then requires two
+	// extra system calls and can block waiting on other processes, so expect
+	// noticeably lower throughput. Where file locking is unsupported (e.g. Windows)
+	// writes proceed unlocked and an error is reported once.
+	LockFile bool
+
+	// ReopenOnSignal, when true, reopens the file after the process receives
+	// SIGHUP, e.g. sent by logrotate after moving the file. Unsupported on
+	// Windows and js/wasm.
+	ReopenOnSignal bool
+
+	// ReopenCheckInterval, when greater than zero, is how often the file is
+	// checked, before writing, for having been moved or deleted (its inode
+	// changed), in which case it is reopened. Zero disables the check.
+	ReopenCheckInterval time.Duration
+}
+
+// fileLocker provides cross-process locking around file writes.
+type fileLocker interface {
+	Lock() error
+	Unlock() error
+	Close() error
+}
+
+// File outputs log records to a file which can be log rotated based on size or age.
+// Uses `https://github.com/natefinch/lumberjack` for rotation.
+type File struct {
+	logr.Basic
+	out      io.WriteCloser
+	filename string
+
+	reopen        int32 // atomic; 1 when the file should be reopened
+	checkInterval time.Duration
+	nextCheck     time.Time
+	opened        os.FileInfo
+	signals       chan os.Signal
+
+	lock        fileLocker
+	lockErr     error
+	lockErrOnce sync.Once
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewFileTarget creates a target capable of outputting log records to a rotated file.
+func NewFileTarget(filter logr.Filter, formatter logr.Formatter, opts FileOptions, maxQueue int) *File {
+	lumber := &lumberjack.Logger{
+		Filename:   opts.Filename,
+		MaxSize:    opts.MaxSize,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAge,
+		Compress:   opts.Compress,
+	}
+	f := &File{out: lumber, filename: lumber.Filename, checkInterval: opts.ReopenCheckInterval}
+	if opts.LockFile {
+		f.lock, f.lockErr = newFileLock(lumber.Filename + ".lock")
+	}
+	if opts.ReopenOnSignal {
+		f.signals = make(chan os.Signal, 1)
+		notifyReopen(f.signals)
+		go f.watchSignals()
+	}
+	f.Basic.Start(f, f, filter, formatter, maxQueue)
+	return f
+}
+
+// Validate checks that the file can be opened for writing, creating it and
+// its directory if needed as the first write would, and that the lock file,
+// if any, was created.
+func (f *File) Validate() error {
+	if f.lockErr != nil {
+		return f.lockErr
+	}
+	if f.filename == "" {
+		return nil // lumberjack's default file in the temp directory
+	}
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// watchSignals flags the file for reopening on each SIGHUP until the signal
+// channel is closed by `Shutdown`.
+func (f *File) watchSignals() {
+	for range f.signals {
+		atomic.StoreInt32(&f.reopen, 1)
+	}
+}
+
+// reopenIfNeeded closes the file if flagged by a signal or if it was moved
+// or deleted; lumberjack reopens it on the next write. Only called from
+// `Write`, which the target's queue serializes.
+func (f *File) reopenIfNeeded() error {
+	reopen := atomic.CompareAndSwapInt32(&f.reopen, 1, 0)
+
+	if f.checkInterval > 0 && f.filename != "" {
+		if now := time.Now(); !now.Before(f.nextCheck) {
+			f.nextCheck = now.Add(f.checkInterval)
+			current, err := os.Stat(f.filename)
+			if err != nil || (f.opened != nil && !os.SameFile(f.opened, current)) {
+				reopen = true
+			}
+			if !reopen {
+				f.opened = current
+			}
+		}
+	}
+
+	if !reopen {
+		return nil
+	}
+	f.opened = nil
+	return f.out.Close()
+}
+
+// Write converts the log record to bytes, via the Formatter,
+// and outputs to a file.
+func (f *File) Write(rec *logr.LogRec) error {
+	stacktrace := f.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := f.Formatter().Format(rec, stacktrace, buf)
+	if err != nil {
+		return err
+	}
+
+	if f.lockErr != nil {
+		f.lockErrOnce.Do(func() {
+			rec.Logger().Logr().ReportError(fmt.Errorf("file target writing without lock: %w", f.lockErr))
+		})
+	}
+	if f.lock != nil {
+		if err := f.lock.Lock(); err != nil {
+			return fmt.Errorf("file target cannot acquire lock: %w", err)
+		}
+		defer f.lock.Unlock()
+	}
+
+	if err := f.reopenIfNeeded(); err != nil {
+		rec.Logger().Logr().ReportError(fmt.Errorf("file target cannot close file for reopening: %w", err))
+	}
+
+	_, err = f.out.Write(buf.Bytes())
+	return err
+}
+
+// Shutdown flushes any remaining log records and closes the file. Calling
+// Shutdown more than once returns the result of the first call.
+func (f *File) Shutdown(ctx context.Context) error {
+	f.shutdownOnce.Do(func() {
+		f.shutdownErr = f.shutdown(ctx)
+	})
+	return f.shutdownErr
+}
+
+func (f *File) shutdown(ctx context.Context) error {
+	errs := merror.New()
+
+	err := f.Basic.Shutdown(ctx)
+	errs.Append(err)
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+		close(f.signals)
+	}
+
+	err = f.out.Close()
+	errs.Append(err)
+
+	if f.lock != nil {
+		err = f.lock.Close()
+		errs.Append(err)
+	}
+
+	return errs.ErrorOrNil()
+}
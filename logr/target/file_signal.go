@@ -0,0 +1,14 @@
+// +build !js
+
+package target
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen relays SIGHUP, which requests reopening log files, to c.
+func notifyReopen(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGHUP)
+}
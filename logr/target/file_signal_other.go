@@ -0,0 +1,9 @@
+// +build js
+
+package target
+
+import "os"
+
+// notifyReopen does nothing as SIGHUP is not supported on this platform.
+func notifyReopen(c chan<- os.Signal) {
+}
@@ -0,0 +1,60 @@
+// +build !windows,!js,!plan9
+
+package target_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+)
+
+func TestFileReopenOnSignal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logr-reopen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	tgt := target.NewFileTarget(filter, &format.Plain{Delim: " | ", DisableTimestamp: true},
+		target.FileOptions{Filename: filename, ReopenOnSignal: true}, 1000)
+	_ = lgr.AddTarget(tgt)
+	logger := lgr.NewLogger()
+
+	logger.Info("before rotation")
+	if err := lgr.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate as logrotate would: move the file, then send SIGHUP.
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 100) // signal delivery is asynchronous
+
+	logger.Info("after rotation")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); !strings.Contains(got, "after rotation") || strings.Contains(got, "before rotation") {
+		t.Errorf("file not reopened, got %q", got)
+	}
+}
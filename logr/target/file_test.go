@@ -0,0 +1,217 @@
+package target_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func ExampleFile() {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Warn, Stacktrace: logr.Error}
+	formatter := &format.JSON{}
+	opts := target.FileOptions{
+		Filename:   "./logs/test_lumberjack.log",
+		MaxSize:    1,
+		MaxAge:     2,
+		MaxBackups: 3,
+		Compress:   false,
+	}
+	t := target.NewFileTarget(filter, formatter, opts, 1000)
+	_ = lgr.AddTarget(t)
+
+	logger := lgr.NewLogger().WithField("name", "wiggin")
+
+	logger.Errorf("the erroneous data is %s", test.StringRnd(10))
+	logger.Warnf("strange data: %s", test.StringRnd(5))
+	logger.Debug("XXX")
+	logger.Trace("XXX")
+
+	err := lgr.Shutdown()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func TestFilePlain(t *testing.T) {
+	plain := &format.Plain{Delim: " | "}
+	file(t, plain, "./logs/test_lumberjack_plain.log")
+}
+
+func TestFileJSON(t *testing.T) {
+	json := &format.JSON{Indent: "\n  "}
+	file(t, json, "./logs/test_lumberjack_json.log")
+}
+
+func file(t *testing.T, formatter logr.Formatter, filename string) {
+	lgr := &logr.Logr{}
+	opts := target.FileOptions{
+		Filename:   filename,
+		MaxSize:    1,
+		MaxAge:     2,
+		MaxBackups: 3,
+		Compress:   false,
+	}
+
+	filter := &logr.StdFilter{Lvl: logr.Error, Stacktrace: logr.Error}
+	target := target.NewFileTarget(filter, formatter, opts, 1000)
+	_ = lgr.AddTarget(target)
+
+	const goodToken = "Woot!"
+	const badToken = "XXX!!XXX"
+
+	cfg := test.DoSomeLoggingCfg{
+		Lgr:        lgr,
+		Goroutines: 10,
+		Loops:      50,
+		GoodToken:  goodToken,
+		BadToken:   badToken,
+		Lvl:        logr.Error,
+		Delay:      time.Millisecond * 1,
+	}
+	test.DoSomeLogging(cfg)
+	err := lgr.Shutdown()
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !fileContains(t, filename, goodToken) {
+		t.Errorf("missing warnings")
+	}
+
+	if fileContains(t, filename, badToken) {
+		t.Errorf("wrong level(s) enabled")
+	}
+}
+
+func TestFileValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logr-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "logs", "app.log")
+
+	tgt := target.NewFileTarget(&logr.StdFilter{Lvl: logr.Info}, &format.Plain{Delim: " | "},
+		target.FileOptions{Filename: filename}, 100)
+	defer tgt.Shutdown(context.Background())
+	if err := tgt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// created with the mode lumberjack uses for new files.
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0044 == 0 {
+		t.Errorf("expected a file readable by others, got %v", info.Mode().Perm())
+	}
+}
+
+func TestFileLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logr-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "shared.log")
+
+	// two Logr instances sharing a file, as two processes would.
+	const loops = 200
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		lgr := &logr.Logr{}
+		filter := &logr.StdFilter{Lvl: logr.Info}
+		tgt := target.NewFileTarget(filter, &format.Plain{Delim: " | ", DisableTimestamp: true},
+			target.FileOptions{Filename: filename, LockFile: true}, 1000)
+		if err := tgt.Validate(); err != nil {
+			t.Skipf("file locking not supported: %v", err)
+		}
+		_ = lgr.AddTarget(tgt)
+
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			logger := lgr.NewLogger()
+			for j := 0; j < loops; j++ {
+				logger.Infof("writer %d line %d", id, j)
+			}
+			if err := lgr.Shutdown(); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, err := os.Stat(filename + ".lock"); err != nil {
+		t.Errorf("missing lock file: %v", err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2*loops {
+		t.Errorf("expected %d lines, got %d", 2*loops, len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "info | writer ") {
+			t.Errorf("interleaved line: %q", line)
+		}
+	}
+}
+
+func TestFileShutdownTwice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logr-shutdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	opts := target.FileOptions{Filename: filepath.Join(dir, "app.log"), ReopenOnSignal: true}
+	tgt := target.NewFileTarget(filter, &format.Plain{Delim: " | "}, opts, 1000)
+
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if err := tgt.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func fileContains(t *testing.T, filename string, text string) bool {
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Error(err)
+		return false
+	}
+	defer file.Close()
+
+	const bufSize = 1000 * 1024
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, bufSize), bufSize)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), text) {
+			return true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Error(err)
+	}
+	return false
+}
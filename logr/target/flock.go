@@ -0,0 +1,39 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package target
+
+import (
+	"os"
+	"syscall"
+)
+
+// flock is an advisory, cross-process lock using flock(2) on a lock file.
+type flock struct {
+	f *os.File
+}
+
+// newFileLock opens (creating if needed) the lock file at path.
+func newFileLock(path string) (fileLocker, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &flock{f: f}, nil
+}
+
+func (l *flock) Lock() error {
+	for {
+		err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func (l *flock) Unlock() error {
+	return syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+}
+
+func (l *flock) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,10 @@
+// +build !linux,!darwin,!freebsd,!openbsd,!netbsd,!dragonfly
+
+package target
+
+import "errors"
+
+// newFileLock is not supported on this platform; writes are not locked.
+func newFileLock(path string) (fileLocker, error) {
+	return nil, errors.New("file locking not supported on this platform")
+}
@@ -0,0 +1,208 @@
+package target
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+const (
+	// DefaultHTTPBatchSize is the number of records sent per request when
+	// `HTTPOptions.BatchSize` is zero.
+	DefaultHTTPBatchSize = 100
+
+	// DefaultHTTPFlushInterval is the maximum time records wait for a batch to
+	// fill when `HTTPOptions.FlushInterval` is zero.
+	DefaultHTTPFlushInterval = time.Second
+
+	// DefaultHTTPTimeout is the timeout for each request when
+	// `HTTPOptions.Timeout` is zero.
+	DefaultHTTPTimeout = time.Second * 10
+)
+
+// HTTPOptions configures an HTTP target.
+type HTTPOptions struct {
+	// URL is the endpoint batches are posted to.
+	URL string
+
+	// Client sends the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	// ContentType is the Content-Type of the payload. Defaults to
+	// "application/x-ndjson".
+	ContentType string
+
+	// Header contains additional request headers, such as authorization.
+	Header http.Header
+
+	// Compression is the payload compression method, applied to each batch.
+	Compression Compression
+
+	// BatchSize is the number of records sent per request. Defaults to
+	// DefaultHTTPBatchSize.
+	BatchSize int
+
+	// FlushInterval is the maximum time records wait for a batch to fill before
+	// being sent. Defaults to DefaultHTTPFlushInterval.
+	FlushInterval time.Duration
+
+	// Timeout is the timeout for each request. Defaults to DefaultHTTPTimeout.
+	Timeout time.Duration
+}
+
+// HTTP posts batches of formatted log records to an HTTP endpoint, one record
+// per line, using an `HTTPBatchSender`. A formatter producing one line per
+// record, such as `format.JSON`, should be used.
+type HTTP struct {
+	logr.Basic
+	sender *HTTPBatchSender
+	opts   HTTPOptions
+
+	mux   sync.Mutex // guards batch, count and lgr, but is not held while sending
+	batch *bytes.Buffer
+	count int
+	lgr   *logr.Logr // used to report errors from periodic sends
+
+	sendMux sync.Mutex // serializes sends so batches are sent in order
+
+	quit         chan struct{}
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewHTTPTarget creates a target that posts batches of log records to an
+// HTTP endpoint.
+func NewHTTPTarget(filter logr.Filter, formatter logr.Formatter, opts HTTPOptions, maxQueue int) (*HTTP, error) {
+	if opts.URL == "" {
+		return nil, errors.New("http target requires a URL")
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = "application/x-ndjson"
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultHTTPBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultHTTPFlushInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultHTTPTimeout
+	}
+
+	h := &HTTP{
+		sender: &HTTPBatchSender{
+			Client:      opts.Client,
+			URL:         opts.URL,
+			ContentType: opts.ContentType,
+			Header:      opts.Header,
+			Compression: opts.Compression,
+		},
+		opts:  opts,
+		batch: &bytes.Buffer{},
+		quit:  make(chan struct{}),
+	}
+	h.Basic.Start(h, h, filter, formatter, maxQueue)
+	go h.startFlusher()
+
+	return h, nil
+}
+
+// IsCompressing returns true if batches are being compressed, i.e. compression
+// is configured and the server has not rejected it.
+func (h *HTTP) IsCompressing() bool {
+	return h.sender.IsCompressing()
+}
+
+// Write adds the formatted log record to the current batch, sending the batch
+// once full.
+func (h *HTTP) Write(rec *logr.LogRec) error {
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := h.Formatter().Format(rec, h.IncludeStacktrace(rec), buf)
+	if err != nil {
+		return err
+	}
+	line := bytes.TrimRight(buf.Bytes(), "\n")
+
+	h.mux.Lock()
+	h.lgr = rec.Logger().Logr()
+	h.batch.Write(line)
+	h.batch.WriteByte('\n')
+	h.count++
+	full := h.count >= h.opts.BatchSize
+	h.mux.Unlock()
+
+	if !full {
+		return nil
+	}
+	return h.sendBatch()
+}
+
+// Flush sends any records in the current batch. It is called automatically
+// when the target is flushed or shut down.
+func (h *HTTP) Flush() error {
+	return h.sendBatch()
+}
+
+// Shutdown stops the target after sending any queued or batched records.
+// Calling Shutdown more than once returns the result of the first call.
+func (h *HTTP) Shutdown(ctx context.Context) error {
+	h.shutdownOnce.Do(func() {
+		h.shutdownErr = h.Basic.Shutdown(ctx)
+		close(h.quit)
+	})
+	return h.shutdownErr
+}
+
+// sendBatch takes the current batch and posts it. The records are dropped if
+// the request fails.
+func (h *HTTP) sendBatch() error {
+	h.sendMux.Lock()
+	defer h.sendMux.Unlock()
+
+	h.mux.Lock()
+	payload := h.batch.Bytes()
+	count := h.count
+	if count > 0 {
+		h.batch = &bytes.Buffer{}
+		h.count = 0
+	}
+	h.mux.Unlock()
+	if count == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	defer cancel()
+	if err := h.sender.Send(ctx, payload); err != nil {
+		return fmt.Errorf("http target dropped %d records: %w", count, err)
+	}
+	return nil
+}
+
+// startFlusher periodically sends partial batches until the target is shut down.
+func (h *HTTP) startFlusher() {
+	ticker := time.NewTicker(h.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.quit:
+			return
+		case <-ticker.C:
+			err := h.sendBatch()
+			h.mux.Lock()
+			lgr := h.lgr
+			h.mux.Unlock()
+			if err != nil && lgr != nil {
+				lgr.ReportError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package target_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+)
+
+// batchServer records the decoded body of each batch posted to it.
+type batchServer struct {
+	mux       sync.Mutex
+	bodies    []string
+	encodings []string
+	rejectGz  bool
+}
+
+func (s *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	enc := r.Header.Get("Content-Encoding")
+	if enc == "gzip" && s.rejectGz {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil && enc == "gzip" {
+		var zr *gzip.Reader
+		if zr, err = gzip.NewReader(bytes.NewReader(body)); err == nil {
+			body, err = ioutil.ReadAll(zr)
+		}
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.bodies = append(s.bodies, string(body))
+	s.encodings = append(s.encodings, enc)
+}
+
+func testHTTPBatches(t *testing.T, srv *batchServer) *target.HTTP {
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {
+		t.Error(err)
+	}
+	opts := target.HTTPOptions{URL: ts.URL, Compression: target.CompressionGzip, BatchSize: 2}
+	tgt, err := target.NewHTTPTarget(&logr.StdFilter{Lvl: logr.Info}, &format.JSON{}, opts, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = lgr.AddTarget(tgt)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Info("batched")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	srv.mux.Lock()
+	defer srv.mux.Unlock()
+	if len(srv.bodies) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(srv.bodies))
+	}
+	for i, want := range []int{2, 1} {
+		lines := strings.Split(strings.TrimSpace(srv.bodies[i]), "\n")
+		if len(lines) != want {
+			t.Errorf("batch %d: expected %d records, got %d", i, want, len(lines))
+		}
+	}
+	return tgt
+}
+
+func TestHTTPCompressedBatches(t *testing.T) {
+	srv := &batchServer{}
+	tgt := testHTTPBatches(t, srv)
+	if !tgt.IsCompressing() {
+		t.Error("expected compression")
+	}
+	for _, enc := range srv.encodings {
+		if enc != "gzip" {
+			t.Errorf("expected gzip encoding, got %q", enc)
+		}
+	}
+}
+
+func TestHTTPUnsupportedCompression(t *testing.T) {
+	srv := &batchServer{rejectGz: true}
+	tgt := testHTTPBatches(t, srv)
+	if tgt.IsCompressing() {
+		t.Error("expected compression disabled after 415")
+	}
+	for _, enc := range srv.encodings {
+		if enc != "" {
+			t.Errorf("expected no encoding, got %q", enc)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// Keyed sampler defaults.
+const (
+	// DefaultKeyedSampleInterval is the interval after which the count for a
+	// key is reset.
+	DefaultKeyedSampleInterval = time.Second
+
+	// DefaultKeyedSampleMaxKeys is the maximum number of keys counted at once.
+	DefaultKeyedSampleMaxKeys = 10000
+)
+
+// KeyedSampleField returns a key function for `NewKeyedSampler` that keys log
+// records by the value of the named field, e.g. "user_id".
+func KeyedSampleField(name string) func(rec *logr.LogRec) string {
+	return func(rec *logr.LogRec) string {
+		v, ok := rec.Fields()[name]
+		if !ok {
+			return ""
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// keyedCount tracks the log records of one key within an interval.
+type keyedCount struct {
+	first  time.Time
+	now    func() time.Time // clock of the Logr the records came from
+	count  int
+	weight uint64 // sum of the sample rates of records suppressed since the last delivered
+}
+
+// KeyedSampler is a target that wraps another target and samples log records
+// by key: for each key, the first `initial` records of every interval are
+// delivered, then every `thereafter`th record, and the rest are suppressed.
+// Each delivered record's sampling rate, see `logr.LogRec.SampleRate`, is
+// set to the number of records it represents: itself plus the records of the
+// same key suppressed since the previous delivered record.
+//
+// Counts are kept for at most `SetMaxKeys` keys; once reached, records of
+// further keys share a single count until expired keys are evicted.
+type KeyedSampler struct {
+	name       string
+	target     logr.Target
+	keyFn      func(rec *logr.LogRec) string
+	initial    int
+	thereafter int
+	interval   time.Duration
+	maxKeys    int
+
+	mux      sync.Mutex
+	counts   map[string]*keyedCount
+	overflow *keyedCount // shared count once maxKeys is reached
+
+	suppressed uint64
+	quit       chan struct{}
+	done       chan struct{}
+	startOnce  sync.Once
+}
+
+// NewKeyedSampler creates a target that wraps inner and, for each key returned
+// by keyFn, delivers the first initial log records per interval, then every
+// thereafter'th record. A nil keyFn keys records by message. A thereafter of
+// zero or less suppresses all records beyond the first initial.
+func NewKeyedSampler(inner logr.Target, keyFn func(rec *logr.LogRec) string, initial int, thereafter int) *KeyedSampler {
+	if keyFn == nil {
+		keyFn = (*logr.LogRec).Msg
+	}
+	return &KeyedSampler{
+		target:     inner,
+		keyFn:      keyFn,
+		initial:    initial,
+		thereafter: thereafter,
+		interval:   DefaultKeyedSampleInterval,
+		maxKeys:    DefaultKeyedSampleMaxKeys,
+		counts:     make(map[string]*keyedCount),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// SetInterval sets the interval after which the count for a key is reset,
+// or DefaultKeyedSampleInterval if zero. Must be called before the target is
+// added to a Logr.
+func (s *KeyedSampler) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultKeyedSampleInterval
+	}
+	s.interval = interval
+}
+
+// SetMaxKeys sets the maximum number of keys counted at once, or
+// DefaultKeyedSampleMaxKeys if zero. Must be called before the target is
+// added to a Logr.
+func (s *KeyedSampler) SetMaxKeys(max int) {
+	if max <= 0 {
+		max = DefaultKeyedSampleMaxKeys
+	}
+	s.maxKeys = max
+}
+
+// SetName provides an optional name for the target.
+func (s *KeyedSampler) SetName(name string) {
+	s.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (s *KeyedSampler) Name() string {
+	return s.name
+}
+
+// IsLevelEnabled returns the wrapped target's level status.
+func (s *KeyedSampler) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	return s.target.IsLevelEnabled(lvl)
+}
+
+// Formatter returns the wrapped target's Formatter.
+func (s *KeyedSampler) Formatter() logr.Formatter {
+	return s.target.Formatter()
+}
+
+// Log delivers the log record if it is among the first for its key this
+// interval or falls on the sampling period, otherwise it is suppressed.
+func (s *KeyedSampler) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		logr.ForwardFlush(rec, s.target)
+		return
+	}
+	s.startOnce.Do(func() {
+		go s.start()
+	})
+
+	key := s.keyFn(rec)
+	clock := rec.Logger().Logr().Now
+	now := clock()
+
+	s.mux.Lock()
+	kc, ok := s.counts[key]
+	if !ok {
+		if len(s.counts) >= s.maxKeys {
+			if s.overflow == nil {
+				s.overflow = &keyedCount{first: now, now: clock}
+			}
+			kc = s.overflow
+		} else {
+			kc = &keyedCount{first: now, now: clock}
+			s.counts[key] = kc
+		}
+	}
+	if now.Sub(kc.first) >= s.interval {
+		kc.first = now
+		kc.count = 0
+	}
+	kc.count++
+
+	n := kc.count - s.initial
+	if n > 0 && (s.thereafter <= 0 || n%s.thereafter != 0) {
+		kc.weight += rec.SampleRate()
+		s.mux.Unlock()
+		atomic.AddUint64(&s.suppressed, 1)
+		return
+	}
+	weight := kc.weight
+	kc.weight = 0
+	s.mux.Unlock()
+
+	if weight > 0 {
+		rec = rec.WithSampleRate(rec.SampleRate() + weight)
+	}
+	s.target.Log(rec)
+}
+
+// Suppressed returns the total number of log records suppressed.
+func (s *KeyedSampler) Suppressed() uint64 {
+	return atomic.LoadUint64(&s.suppressed)
+}
+
+// evict forgets the keys whose interval has ended.
+func (s *KeyedSampler) evict() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for key, kc := range s.counts {
+		if kc.now().Sub(kc.first) >= s.interval {
+			delete(s.counts, key)
+		}
+	}
+	if s.overflow != nil && s.overflow.now().Sub(s.overflow.first) >= s.interval {
+		s.overflow = nil
+	}
+}
+
+// start periodically evicts expired keys until the target is shut down.
+func (s *KeyedSampler) start() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+			s.evict()
+		}
+	}
+}
+
+// Health returns the wrapped target's health.
+func (s *KeyedSampler) Health() logr.TargetHealth {
+	return logr.HealthOf(s.target)
+}
+
+// EnableMetrics enables metrics collection for the wrapped target, if supported.
+func (s *KeyedSampler) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if tm, ok := s.target.(logr.TargetWithMetrics); ok {
+		return tm.EnableMetrics(collector, updateFreqMillis)
+	}
+	return nil
+}
+
+// Validate validates the wrapped target, if supported.
+func (s *KeyedSampler) Validate() error {
+	return logr.ValidateOf(s.target)
+}
+
+// UpdateQueueMetrics updates the queue metrics of the wrapped target, if supported.
+func (s *KeyedSampler) UpdateQueueMetrics() {
+	logr.UpdateQueueMetricsOf(s.target)
+}
+
+// Shutdown stops evicting keys then shuts down the wrapped target.
+func (s *KeyedSampler) Shutdown(ctx context.Context) error {
+	s.startOnce.Do(func() {
+		close(s.done)
+	})
+	close(s.quit)
+	<-s.done
+	return s.target.Shutdown(ctx)
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (s *KeyedSampler) String() string {
+	if s.name != "" {
+		return s.name
+	}
+	return fmt.Sprintf("%T", s)
+}
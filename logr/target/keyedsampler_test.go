@@ -0,0 +1,183 @@
+package target_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/target"
+)
+
+// recordTarget records the message, fields and sample rate of each record
+// written.
+type recordTarget struct {
+	logr.Basic
+
+	mux    sync.Mutex
+	msgs   []string
+	fields []logr.Fields
+	rates  []uint64
+}
+
+func newRecordTarget() *recordTarget {
+	rt := &recordTarget{}
+	rt.Basic.Start(rt, rt, &logr.StdFilter{Lvl: logr.Debug}, nil, 1000)
+	return rt
+}
+
+func (rt *recordTarget) Write(rec *logr.LogRec) error {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	rt.msgs = append(rt.msgs, rec.Msg())
+	rt.fields = append(rt.fields, rec.Fields())
+	rt.rates = append(rt.rates, rec.SampleRate())
+	return nil
+}
+
+// messages returns the messages written so far.
+func (rt *recordTarget) messages() []string {
+	rt.mux.Lock()
+	defer rt.mux.Unlock()
+	return append([]string(nil), rt.msgs...)
+}
+
+func newKeyedSamplerLogr(t *testing.T, sampler *target.KeyedSampler) *logr.Logr {
+	lgr := &logr.Logr{}
+	if err := lgr.AddTarget(sampler); err != nil {
+		t.Fatal(err)
+	}
+	return lgr
+}
+
+func TestKeyedSamplerPerKey(t *testing.T) {
+	rt := newRecordTarget()
+	sampler := target.NewKeyedSampler(rt, target.KeyedSampleField("user"), 2, 3)
+	sampler.SetInterval(time.Hour)
+	lgr := newKeyedSamplerLogr(t, sampler)
+
+	logger := lgr.NewLogger()
+	for i := 1; i <= 10; i++ {
+		logger.WithField("user", "a").Info(fmt.Sprintf("a%d", i))
+	}
+	for i := 1; i <= 4; i++ {
+		logger.WithField("user", "b").Info(fmt.Sprintf("b%d", i))
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// first 2 per key, then every 3rd; each carrying the records it represents.
+	wantMsgs := []string{"a1", "a2", "a5", "a8", "b1", "b2"}
+	wantRates := []uint64{1, 1, 3, 3, 1, 1}
+	if fmt.Sprint(rt.msgs) != fmt.Sprint(wantMsgs) {
+		t.Errorf("expected %v, got %v", wantMsgs, rt.msgs)
+	}
+	if fmt.Sprint(rt.rates) != fmt.Sprint(wantRates) {
+		t.Errorf("expected sample rates %v, got %v", wantRates, rt.rates)
+	}
+	if n := sampler.Suppressed(); n != 8 {
+		t.Errorf("expected 8 suppressed, got %d", n)
+	}
+}
+
+func TestKeyedSamplerIntervalResets(t *testing.T) {
+	var now int64
+	rt := newRecordTarget()
+	sampler := target.NewKeyedSampler(rt, nil, 1, 0)
+	sampler.SetInterval(time.Minute)
+	lgr := newKeyedSamplerLogr(t, sampler)
+	lgr.SetClock(func() time.Time {
+		return time.Unix(0, atomic.LoadInt64(&now))
+	})
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Info("repeated")
+	}
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	atomic.AddInt64(&now, int64(time.Minute))
+	logger.Info("repeated")
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// keyed by message; the count resets once the interval ends.
+	if len(rt.msgs) != 2 {
+		t.Fatalf("expected 2 records, got %v", rt.msgs)
+	}
+	if rt.rates[1] != 3 {
+		t.Errorf("expected the second record to represent 3, got %d", rt.rates[1])
+	}
+}
+
+func TestKeyedSamplerMaxKeys(t *testing.T) {
+	rt := newRecordTarget()
+	sampler := target.NewKeyedSampler(rt, nil, 1, 0)
+	sampler.SetInterval(time.Hour)
+	sampler.SetMaxKeys(1)
+	lgr := newKeyedSamplerLogr(t, sampler)
+
+	logger := lgr.NewLogger()
+	for _, msg := range []string{"a", "b", "c", "a"} {
+		logger.Info(msg)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// "b" and "c" share the overflow count, so only "b" is delivered.
+	if fmt.Sprint(rt.msgs) != "[a b]" {
+		t.Errorf("expected [a b], got %v", rt.msgs)
+	}
+	if n := sampler.Suppressed(); n != 2 {
+		t.Errorf("expected 2 suppressed, got %d", n)
+	}
+}
+
+func TestKeyedSamplerConcurrent(t *testing.T) {
+	rt := newRecordTarget()
+	sampler := target.NewKeyedSampler(rt, target.KeyedSampleField("worker"), 5, 10)
+	sampler.SetInterval(time.Hour)
+	lgr := newKeyedSamplerLogr(t, sampler)
+
+	const workers = 10
+	const records = 100
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			logger := lgr.NewLogger().WithField("worker", w)
+			for i := 0; i < records; i++ {
+				logger.Info("working")
+			}
+		}(w)
+	}
+	wg.Wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	// per key: the first 5, then the 15th, 25th ... 95th.
+	const perKey = 5 + (records-5)/10
+	if n := len(rt.msgs); n != workers*perKey {
+		t.Errorf("expected %d records, got %d", workers*perKey, n)
+	}
+	// delivered records represent all but the 96th to 100th of each key,
+	// suppressed after the last delivered record.
+	var total uint64
+	for _, rate := range rt.rates {
+		total += rate
+	}
+	if want := uint64(workers * (records - 5)); total != want {
+		t.Errorf("expected total sample rate %d, got %d", want, total)
+	}
+	if n := sampler.Suppressed(); n != workers*(records-perKey) {
+		t.Errorf("expected %d suppressed, got %d", workers*(records-perKey), n)
+	}
+}
@@ -0,0 +1,132 @@
+package target
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mattermost/logr"
+)
+
+// DefaultMemorySize is the number of log records retained by a memory target
+// when the size provided is less than one.
+const DefaultMemorySize = 1000
+
+// DefaultMemorySubscribeBuffer is the number of log records buffered for a
+// subscriber when the buffer size provided is less than one.
+const DefaultMemorySubscribeBuffer = 100
+
+// Memory keeps the last N formatted log records in a ring buffer, e.g. for
+// an admin view of recent logs, and streams new records to subscribers.
+// Use a filter such as `logr.CustomFilter` to retain a range of levels.
+type Memory struct {
+	logr.Basic
+
+	ring *recordRing
+
+	mux      sync.Mutex // guards subscribers
+	subs     map[int]chan string
+	nextSub  int
+	shutdown bool
+}
+
+// NewMemoryTarget creates a target that retains the last `size` formatted log
+// records in memory.
+func NewMemoryTarget(filter logr.Filter, formatter logr.Formatter, size int, maxQueue int) *Memory {
+	if size < 1 {
+		size = DefaultMemorySize
+	}
+	m := &Memory{
+		ring: newRecordRing(size),
+		subs: make(map[int]chan string),
+	}
+	m.Basic.Start(m, m, filter, formatter, maxQueue)
+	return m
+}
+
+// Write converts the log record to a string, via the Formatter, stores it in
+// the ring buffer, overwriting the oldest record when full, and sends it to
+// each subscriber.
+func (m *Memory) Write(rec *logr.LogRec) error {
+	stacktrace := m.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := m.Formatter().Format(rec, stacktrace, buf)
+	if err != nil {
+		return err
+	}
+	line := buf.String()
+	m.ring.add([]byte(line))
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, ch := range m.subs {
+		select {
+		case ch <- line:
+		default:
+			// slow subscriber; drop rather than block the target.
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the retained log records, oldest first. Records written
+// while taking the snapshot may or may not be included.
+func (m *Memory) Snapshot() []string {
+	lines := make([]string, 0)
+	m.ring.each(func(line []byte) {
+		lines = append(lines, string(line))
+	})
+	return lines
+}
+
+// Subscribe returns a channel receiving each log record written after the call,
+// buffering up to `buffer` records, or DefaultMemorySubscribeBuffer when less
+// than one. Records are dropped for a subscriber whose channel is full. Call the
+// returned func to unsubscribe and close the channel; all channels are closed
+// when the target is shut down.
+func (m *Memory) Subscribe(buffer int) (<-chan string, func()) {
+	if buffer < 1 {
+		buffer = DefaultMemorySubscribeBuffer
+	}
+	ch := make(chan string, buffer)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if m.shutdown {
+		close(ch)
+		return ch, func() {}
+	}
+	id := m.nextSub
+	m.nextSub++
+	m.subs[id] = ch
+
+	unsubscribe := func() {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+		if ch, ok := m.subs[id]; ok {
+			delete(m.subs, id)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Shutdown stops processing log records after making best effort to flush
+// the queue, then closes all subscriber channels. Retained log records can
+// still be read via `Snapshot`.
+func (m *Memory) Shutdown(ctx context.Context) error {
+	err := m.Basic.Shutdown(ctx)
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.shutdown = true
+	for id, ch := range m.subs {
+		delete(m.subs, id)
+		close(ch)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package target_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+)
+
+func TestMemorySnapshot(t *testing.T) {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	formatter := &format.Plain{DisableTimestamp: true, DisableLevel: true}
+	mem := target.NewMemoryTarget(filter, formatter, 3, 100)
+	cb := target.NewCrashBufferTarget(filter, formatter, 3, 100)
+	_ = lgr.AddTarget(mem)
+	_ = lgr.AddTarget(cb)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info(fmt.Sprintf("record %d", i))
+	}
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+
+	lines := mem.Snapshot()
+	want := []string{"record 2", "record 3", "record 4"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d records, got %d: %q", len(want), len(lines), lines)
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) != want[i] {
+			t.Errorf("record %d: expected %q, got %q", i, want[i], line)
+		}
+	}
+	if dump := string(cb.Dump()); dump != strings.Join(lines, "") {
+		t.Errorf("crash buffer dump %q does not match snapshot %q", dump, lines)
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMemorySubscribeDefaultBuffer(t *testing.T) {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	mem := target.NewMemoryTarget(filter, &format.Plain{DisableTimestamp: true}, 10, 100)
+	_ = lgr.AddTarget(mem)
+
+	// nothing reads the channel until after logging, so records are only
+	// received if the channel is buffered.
+	ch, unsubscribe := mem.Subscribe(0)
+	defer unsubscribe()
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Info("subscribed")
+	}
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if len(ch) != 5 {
+		t.Errorf("expected 5 buffered records, got %d", len(ch))
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+	for range ch {
+	}
+}
@@ -0,0 +1,338 @@
+// Package netutil provides helpers for targets writing to network services.
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// DefaultBufferSize is the maximum number of writes buffered while
+// disconnected when `Options.BufferSize` is zero.
+const DefaultBufferSize = 1000
+
+// ErrClosed is returned when writing to a closed ReconnectingConn.
+var ErrClosed = errors.New("connection closed")
+
+// DialFunc dials a new connection, e.g. wrapping `net.Dialer.DialContext`
+// followed by any protocol handshake.
+type DialFunc func(ctx context.Context) (net.Conn, error)
+
+// Options configures a ReconnectingConn.
+type Options struct {
+	// Backoff determines the delay between reconnect attempts, including the
+	// initial and maximum delay and jitter.
+	Backoff logr.Backoff
+
+	// BufferSize is the maximum number of writes buffered while disconnected,
+	// after which the oldest is dropped. Defaults to DefaultBufferSize; a
+	// negative value disables buffering, so writes fail while disconnected.
+	BufferSize int
+
+	// WriteTimeout, when non-zero, is the deadline for each write to the
+	// connection, after which the connection is considered lost.
+	WriteTimeout time.Duration
+
+	// OnHealth, when not nil, is called each time the connection is lost or
+	// re-established, with the error that caused the loss. It is called from
+	// the writing or reconnecting goroutine and must not block or call the
+	// ReconnectingConn.
+	OnHealth func(connected bool, err error)
+
+	// DroppedCounter, when not nil, is incremented for each write dropped
+	// while disconnected, e.g. a target's `logr.MetricsCollector.DroppedCounter`.
+	DroppedCounter logr.Counter
+}
+
+// ReconnectingConn is an io.Writer over a network connection that is
+// re-established in the background with exponential backoff when lost.
+// While disconnected, writes are buffered up to `Options.BufferSize` then the
+// oldest are dropped; buffered writes are sent in order once reconnected.
+// Each write is sent as a whole, so framing by the caller is preserved.
+// It is safe for concurrent use.
+type ReconnectingConn struct {
+	dial DialFunc
+	opts Options
+
+	mux          sync.Mutex
+	conn         net.Conn
+	pending      [][]byte
+	reconnecting bool
+	closed       bool
+	lastErr      error
+	lastErrTime  time.Time
+	failures     int // dial attempts failed since last connected
+
+	dropped uint64
+	quit    chan struct{}
+}
+
+// NewReconnectingConn creates a ReconnectingConn which dials via dial. No
+// connection is made until `Connect` or the first write.
+func NewReconnectingConn(dial DialFunc, opts Options) *ReconnectingConn {
+	if opts.BufferSize == 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	return &ReconnectingConn{
+		dial: dial,
+		opts: opts,
+		quit: make(chan struct{}),
+	}
+}
+
+// Connect dials the connection, returning any error, e.g. so a target can
+// fail to be created when its service cannot be reached. On failure
+// reconnecting continues in the background. Returns nil if already connected.
+func (rc *ReconnectingConn) Connect(ctx context.Context) error {
+	rc.mux.Lock()
+	if rc.closed {
+		rc.mux.Unlock()
+		return ErrClosed
+	}
+	if rc.conn != nil {
+		rc.mux.Unlock()
+		return nil
+	}
+	rc.mux.Unlock()
+
+	conn, err := rc.dial(ctx)
+
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	switch {
+	case err != nil:
+		rc.failed(err)
+		rc.startReconnect()
+	case rc.closed:
+		_ = conn.Close()
+		return ErrClosed
+	case rc.conn != nil:
+		// connected in the background meanwhile.
+		_ = conn.Close()
+	default:
+		rc.connected(conn)
+	}
+	return err
+}
+
+// Write sends p on the connection, or buffers it while disconnected. An error
+// is returned if the write fails, in which case p is buffered and
+// reconnecting starts, or if the buffer is full and the oldest write dropped.
+func (rc *ReconnectingConn) Write(p []byte) (int, error) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	if rc.closed {
+		return 0, ErrClosed
+	}
+	if rc.conn == nil {
+		rc.startReconnect()
+		return len(p), rc.addPending(p)
+	}
+	if err := rc.write(p); err != nil {
+		rc.lost(err)
+		_ = rc.addPending(p)
+		return len(p), fmt.Errorf("write failed, reconnecting: %w", err)
+	}
+	return len(p), nil
+}
+
+// SetDroppedCounter sets the counter incremented for each write dropped
+// while disconnected, replacing `Options.DroppedCounter`, e.g. once a
+// target's metrics are enabled.
+func (rc *ReconnectingConn) SetDroppedCounter(counter logr.Counter) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	rc.opts.DroppedCounter = counter
+}
+
+// Connected returns true while connected.
+func (rc *ReconnectingConn) Connected() bool {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	return rc.conn != nil
+}
+
+// Buffered returns the number of writes buffered while disconnected.
+func (rc *ReconnectingConn) Buffered() int {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	return len(rc.pending)
+}
+
+// Dropped returns the number of writes dropped while disconnected.
+func (rc *ReconnectingConn) Dropped() uint64 {
+	return atomic.LoadUint64(&rc.dropped)
+}
+
+// Health returns the health of the connection, which is down while
+// disconnected, e.g. for a target's `Health`.
+func (rc *ReconnectingConn) Health() logr.TargetHealth {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	return logr.TargetHealth{
+		Known:               true,
+		Up:                  rc.conn != nil || (rc.lastErr == nil && !rc.reconnecting),
+		LastError:           rc.lastErr,
+		LastErrorTime:       rc.lastErrTime,
+		ConsecutiveFailures: rc.failures,
+	}
+}
+
+// Close stops reconnecting and closes the connection. Writes buffered while
+// disconnected are discarded, which is reported in the returned error.
+func (rc *ReconnectingConn) Close() error {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	if rc.closed {
+		return nil
+	}
+	rc.closed = true
+	close(rc.quit)
+
+	var err error
+	if rc.conn != nil {
+		err = rc.conn.Close()
+		rc.conn = nil
+	}
+	if n := len(rc.pending); n > 0 {
+		rc.pending = nil
+		if err == nil {
+			err = fmt.Errorf("discarded %d writes while disconnected", n)
+		}
+	}
+	return err
+}
+
+// write sends p on the connection. Caller must hold the mutex.
+func (rc *ReconnectingConn) write(p []byte) error {
+	if rc.opts.WriteTimeout > 0 {
+		_ = rc.conn.SetWriteDeadline(time.Now().Add(rc.opts.WriteTimeout))
+	}
+	_, err := rc.conn.Write(p)
+	return err
+}
+
+// addPending buffers a copy of p while disconnected, dropping the oldest
+// write when full. Caller must hold the mutex.
+func (rc *ReconnectingConn) addPending(p []byte) error {
+	if rc.opts.BufferSize < 0 {
+		rc.drop()
+		return errors.New("not connected, write dropped")
+	}
+	var err error
+	if len(rc.pending) >= rc.opts.BufferSize {
+		rc.pending[0] = nil
+		rc.pending = rc.pending[1:]
+		rc.drop()
+		err = errors.New("not connected and buffer full, dropped oldest write")
+	}
+	rc.pending = append(rc.pending, append([]byte(nil), p...))
+	return err
+}
+
+// drop counts a dropped write.
+func (rc *ReconnectingConn) drop() {
+	atomic.AddUint64(&rc.dropped, 1)
+	if rc.opts.DroppedCounter != nil {
+		rc.opts.DroppedCounter.Inc()
+	}
+}
+
+// failed records a failed dial. Caller must hold the mutex.
+func (rc *ReconnectingConn) failed(err error) {
+	rc.lastErr, rc.lastErrTime = err, time.Now()
+	rc.failures++
+}
+
+// lost closes the current connection after an error and starts
+// reconnecting. Caller must hold the mutex.
+func (rc *ReconnectingConn) lost(err error) {
+	_ = rc.conn.Close()
+	rc.conn = nil
+	rc.lastErr, rc.lastErrTime = err, time.Now()
+	rc.startReconnect()
+	if rc.opts.OnHealth != nil {
+		rc.opts.OnHealth(false, err)
+	}
+}
+
+// connected installs a new connection and sends any buffered writes.
+// Caller must hold the mutex.
+func (rc *ReconnectingConn) connected(conn net.Conn) {
+	rc.conn = conn
+	rc.failures = 0
+	if rc.opts.OnHealth != nil {
+		rc.opts.OnHealth(true, nil)
+	}
+	for len(rc.pending) > 0 {
+		if err := rc.write(rc.pending[0]); err != nil {
+			rc.lost(err)
+			return
+		}
+		rc.pending[0] = nil
+		rc.pending = rc.pending[1:]
+	}
+	rc.pending = nil
+	rc.lastErr = nil
+}
+
+// startReconnect starts reconnecting in the background unless already
+// reconnecting. Caller must hold the mutex.
+func (rc *ReconnectingConn) startReconnect() {
+	if !rc.reconnecting && !rc.closed {
+		rc.reconnecting = true
+		go rc.reconnect()
+	}
+}
+
+// reconnect dials with backoff until successful or closed.
+func (rc *ReconnectingConn) reconnect() {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-rc.quit:
+			return
+		case <-time.After(rc.opts.Backoff.Delay(attempt)):
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-rc.quit:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		conn, err := rc.dial(ctx)
+		cancel()
+
+		rc.mux.Lock()
+		if rc.closed {
+			rc.mux.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		if err != nil {
+			rc.failed(err)
+			rc.mux.Unlock()
+			continue
+		}
+		rc.reconnecting = false
+		if rc.conn != nil {
+			// connected via `Connect` meanwhile.
+			_ = conn.Close()
+		} else {
+			rc.connected(conn)
+		}
+		rc.mux.Unlock()
+		return
+	}
+}
@@ -0,0 +1,242 @@
+package netutil_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/target/netutil"
+	"github.com/mattermost/logr/test"
+)
+
+// server accepts connections dialed via dial while up, sending each line
+// received to lines.
+type server struct {
+	mux   sync.Mutex
+	up    bool
+	conns []net.Conn
+	dials int
+	lines chan string
+}
+
+func newServer(up bool) *server {
+	return &server{up: up, lines: make(chan string, 100)}
+}
+
+func (s *server) dial(ctx context.Context) (net.Conn, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.dials++
+	if !s.up {
+		return nil, errors.New("connection refused")
+	}
+	client, srv := net.Pipe()
+	s.conns = append(s.conns, srv)
+	go func() {
+		scanner := bufio.NewScanner(srv)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+	}()
+	return client, nil
+}
+
+// setUp starts or stops accepting connections. Stopping closes existing
+// connections.
+func (s *server) setUp(up bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.up = up
+	if !up {
+		for _, c := range s.conns {
+			c.Close()
+		}
+		s.conns = nil
+	}
+}
+
+func (s *server) dialCount() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.dials
+}
+
+func (s *server) expect(t *testing.T, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case line := <-s.lines:
+			if line != w {
+				t.Errorf("expected %q, got %q", w, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", w)
+		}
+	}
+}
+
+var fastBackoff = logr.Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}
+
+func TestReconnectingConnWrites(t *testing.T) {
+	srv := newServer(true)
+	rc := netutil.NewReconnectingConn(srv.dial, netutil.Options{Backoff: fastBackoff})
+	if err := rc.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !rc.Connected() {
+		t.Error("expected connected")
+	}
+	if _, err := rc.Write([]byte("one\n")); err != nil {
+		t.Error(err)
+	}
+	srv.expect(t, "one")
+
+	if err := rc.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err := rc.Write([]byte("two\n")); err != netutil.ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestReconnectingConnBuffersUntilConnected(t *testing.T) {
+	srv := newServer(false)
+	rc := netutil.NewReconnectingConn(srv.dial, netutil.Options{Backoff: fastBackoff})
+	defer rc.Close()
+
+	if err := rc.Connect(context.Background()); err == nil {
+		t.Fatal("expected connect error")
+	}
+	if h := rc.Health(); !h.Known || h.Up || h.LastError == nil {
+		t.Errorf("expected down with an error, got %+v", h)
+	}
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		if _, err := rc.Write([]byte(line)); err != nil {
+			t.Error(err)
+		}
+	}
+	if n := rc.Buffered(); n != 3 {
+		t.Errorf("expected 3 buffered, got %d", n)
+	}
+
+	srv.setUp(true)
+	srv.expect(t, "one", "two", "three")
+	if n := rc.Buffered(); n != 0 {
+		t.Errorf("expected 0 buffered, got %d", n)
+	}
+	if h := rc.Health(); !h.Up {
+		t.Errorf("expected up, got %+v", h)
+	}
+}
+
+func TestReconnectingConnDropsOldest(t *testing.T) {
+	srv := newServer(false)
+	collector := test.NewTestMetricsCollector()
+	counter, _ := collector.DroppedCounter("rc")
+	rc := netutil.NewReconnectingConn(srv.dial, netutil.Options{
+		Backoff:        logr.Backoff{Initial: time.Hour, Max: time.Hour},
+		BufferSize:     2,
+		DroppedCounter: counter,
+	})
+	defer rc.Close()
+
+	_, _ = rc.Write([]byte("one\n"))
+	_, _ = rc.Write([]byte("two\n"))
+	if _, err := rc.Write([]byte("three\n")); err == nil {
+		t.Error("expected an error dropping a write")
+	}
+	if n := rc.Buffered(); n != 2 {
+		t.Errorf("expected 2 buffered, got %d", n)
+	}
+	if n := rc.Dropped(); n != 1 {
+		t.Errorf("expected 1 dropped, got %d", n)
+	}
+	if n := collector.Get("rc").Dropped; n != 1 {
+		t.Errorf("expected dropped counter 1, got %v", n)
+	}
+
+	if err := rc.Close(); err == nil {
+		t.Error("expected close to report discarded writes")
+	}
+}
+
+func TestReconnectingConnNoBuffer(t *testing.T) {
+	srv := newServer(false)
+	rc := netutil.NewReconnectingConn(srv.dial, netutil.Options{
+		Backoff:    logr.Backoff{Initial: time.Hour, Max: time.Hour},
+		BufferSize: -1,
+	})
+	defer rc.Close()
+
+	if _, err := rc.Write([]byte("one\n")); err == nil {
+		t.Error("expected an error while disconnected")
+	}
+	if n := rc.Buffered(); n != 0 {
+		t.Errorf("expected 0 buffered, got %d", n)
+	}
+	if n := rc.Dropped(); n != 1 {
+		t.Errorf("expected 1 dropped, got %d", n)
+	}
+}
+
+func TestReconnectingConnReconnectsAfterWriteError(t *testing.T) {
+	srv := newServer(true)
+
+	var mux sync.Mutex
+	var health []bool
+	rc := netutil.NewReconnectingConn(srv.dial, netutil.Options{
+		Backoff: fastBackoff,
+		OnHealth: func(connected bool, err error) {
+			mux.Lock()
+			defer mux.Unlock()
+			health = append(health, connected)
+		},
+	})
+	defer rc.Close()
+
+	if err := rc.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the write fails on the closed connection and is buffered.
+	srv.setUp(false)
+	if _, err := rc.Write([]byte("lost\n")); err == nil {
+		t.Error("expected a write error")
+	}
+	if rc.Connected() {
+		t.Error("expected disconnected")
+	}
+
+	srv.setUp(true)
+	srv.expect(t, "lost")
+	if dials := srv.dialCount(); dials < 2 {
+		t.Errorf("expected a redial, got %d dials", dials)
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	if len(health) != 3 || !health[0] || health[1] || !health[2] {
+		t.Errorf("expected health up, down, up; got %v", health)
+	}
+}
+
+func TestReconnectingConnCloseStopsReconnecting(t *testing.T) {
+	srv := newServer(false)
+	rc := netutil.NewReconnectingConn(srv.dial, netutil.Options{Backoff: fastBackoff})
+
+	_ = rc.Connect(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	_ = rc.Close()
+
+	dials := srv.dialCount()
+	time.Sleep(50 * time.Millisecond)
+	if n := srv.dialCount(); n > dials+1 {
+		t.Errorf("expected dialing to stop after close, got %d more dials", n-dials)
+	}
+}
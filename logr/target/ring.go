@@ -0,0 +1,39 @@
+package target
+
+import "sync/atomic"
+
+// recordRing keeps the last N formatted log records, overwriting the oldest
+// record when full. Records are added by a single writer, the target's write
+// loop, and can be read concurrently without acquiring any locks.
+type recordRing struct {
+	slots []atomic.Value // each holds []byte
+	next  uint64         // total records written; only add increments
+}
+
+func newRecordRing(size int) *recordRing {
+	return &recordRing{slots: make([]atomic.Value, size)}
+}
+
+// add stores a record, which must not be modified afterwards.
+func (r *recordRing) add(line []byte) {
+	n := atomic.LoadUint64(&r.next)
+	r.slots[n%uint64(len(r.slots))].Store(line)
+	atomic.StoreUint64(&r.next, n+1)
+}
+
+// each calls f for each retained record, oldest first. Records added while
+// iterating may or may not be included.
+func (r *recordRing) each(f func(line []byte)) {
+	n := atomic.LoadUint64(&r.next)
+	size := uint64(len(r.slots))
+
+	var start uint64
+	if n > size {
+		start = n - size
+	}
+	for i := start; i < n; i++ {
+		if line, ok := r.slots[i%size].Load().([]byte); ok {
+			f(line)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package target
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+)
+
+// LevelRoute routes a range of levels to a target.
+type LevelRoute struct {
+	// Min is the most severe level routed, e.g. `logr.Panic`. The zero value
+	// routes from the most severe level.
+	Min logr.Level
+
+	// Max is the most verbose level routed, e.g. `logr.Error`. The zero value
+	// routes Min and every more verbose level, including custom levels.
+	Max logr.Level
+
+	// Target receives log records with levels in the range.
+	Target logr.Target
+}
+
+// includes returns true if the level is within the route's range.
+func (r LevelRoute) includes(lvl logr.Level) bool {
+	if lvl.ID < r.Min.ID {
+		return false
+	}
+	return r.Max.Name == "" || lvl.ID <= r.Max.ID
+}
+
+// LevelRouter is a target that wraps other targets keyed by level ranges,
+// e.g. sending error and above to a pager while all levels go to a file.
+// Each log record is delivered to every target with a route including the
+// record's level, subject to that target's own filter. A target appearing
+// in more than one route receives each record at most once.
+type LevelRouter struct {
+	name    string
+	routes  []LevelRoute
+	targets []logr.Target // unique targets, in route order
+}
+
+// NewLevelRouter creates a target that delivers log records to the targets
+// of the routes including each record's level.
+func NewLevelRouter(routes ...LevelRoute) *LevelRouter {
+	lr := &LevelRouter{routes: routes}
+	seen := make(map[logr.Target]bool, len(routes))
+	for _, r := range routes {
+		if !seen[r.Target] {
+			seen[r.Target] = true
+			lr.targets = append(lr.targets, r.Target)
+		}
+	}
+	return lr
+}
+
+// SetName provides an optional name for the target.
+func (lr *LevelRouter) SetName(name string) {
+	lr.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (lr *LevelRouter) Name() string {
+	return lr.name
+}
+
+// IsLevelEnabled returns true if any target routed the level has it enabled.
+func (lr *LevelRouter) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	for _, r := range lr.routes {
+		if !r.includes(lvl) {
+			continue
+		}
+		e, s := r.Target.IsLevelEnabled(lvl)
+		enabled = enabled || e
+		stacktrace = stacktrace || (e && s)
+	}
+	return enabled, stacktrace
+}
+
+// Formatter returns the Formatter of the first route's target.
+func (lr *LevelRouter) Formatter() logr.Formatter {
+	if len(lr.routes) == 0 {
+		return &logr.DefaultFormatter{}
+	}
+	return lr.routes[0].Target.Formatter()
+}
+
+// Log delivers the log record to each target routed its level.
+func (lr *LevelRouter) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		logr.ForwardFlush(rec, lr.targets...)
+		return
+	}
+
+	lvl := rec.Level()
+	var delivered map[logr.Target]bool
+	for _, r := range lr.routes {
+		if !r.includes(lvl) {
+			continue
+		}
+		if enabled, _ := r.Target.IsLevelEnabled(lvl); !enabled {
+			continue
+		}
+		if delivered[r.Target] {
+			continue
+		}
+		if len(lr.targets) < len(lr.routes) {
+			if delivered == nil {
+				delivered = make(map[logr.Target]bool)
+			}
+			delivered[r.Target] = true
+		}
+		r.Target.Log(rec)
+	}
+}
+
+// Health returns down if any routed target is down.
+func (lr *LevelRouter) Health() logr.TargetHealth {
+	health := logr.TargetHealth{Known: true, Up: true}
+	for _, t := range lr.targets {
+		h := logr.HealthOf(t)
+		if !h.Known || h.Up {
+			continue
+		}
+		health.Up = false
+		if h.LastErrorTime.After(health.LastErrorTime) {
+			health.LastError = h.LastError
+			health.LastErrorTime = h.LastErrorTime
+		}
+		if h.ConsecutiveFailures > health.ConsecutiveFailures {
+			health.ConsecutiveFailures = h.ConsecutiveFailures
+		}
+	}
+	return health
+}
+
+// EnableMetrics enables metrics collection for all routed targets, if supported.
+func (lr *LevelRouter) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	errs := merror.New()
+	for _, t := range lr.targets {
+		if tm, ok := t.(logr.TargetWithMetrics); ok {
+			errs.Append(tm.EnableMetrics(collector, updateFreqMillis))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Validate validates all routed targets, if supported.
+func (lr *LevelRouter) Validate() error {
+	return logr.ValidateOf(lr.targets...)
+}
+
+// UpdateQueueMetrics updates the queue metrics of all routed targets, if supported.
+func (lr *LevelRouter) UpdateQueueMetrics() {
+	logr.UpdateQueueMetricsOf(lr.targets...)
+}
+
+// Shutdown shuts down all routed targets.
+func (lr *LevelRouter) Shutdown(ctx context.Context) error {
+	errs := merror.New()
+	for _, t := range lr.targets {
+		errs.Append(t.Shutdown(ctx))
+	}
+	return errs.ErrorOrNil()
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (lr *LevelRouter) String() string {
+	if lr.name != "" {
+		return lr.name
+	}
+	return fmt.Sprintf("%T", lr)
+}
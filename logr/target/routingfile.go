@@ -0,0 +1,260 @@
+package target
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/wiggin77/merror"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// DefaultRoutingMaxOpenFiles is the maximum number of files a routing file
+	// target keeps open when `RoutingFileOptions.MaxOpenFiles` is zero.
+	DefaultRoutingMaxOpenFiles = 64
+
+	// DefaultRoutingIdleTimeout is how long an unused file stays open when
+	// `RoutingFileOptions.IdleTimeout` is zero.
+	DefaultRoutingIdleTimeout = time.Minute * 5
+
+	// DefaultRoutingKey is the route used for log records without a valid
+	// routing field when `RoutingFileOptions.DefaultKey` is empty.
+	DefaultRoutingKey = "default"
+)
+
+// routeKeyPattern matches route keys that are safe to use as file names. Keys
+// cannot contain path separators and cannot begin with a dot, so "." and ".."
+// are rejected.
+var routeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// RoutingFileOptions configures a routing file target.
+type RoutingFileOptions struct {
+	// Dir is the directory containing the routed log files. Each route is
+	// written to "<Dir>/<key>.log".
+	Dir string
+
+	// Field is the name of the field whose value selects the file, e.g. "tenant_id".
+	Field string
+
+	// DefaultKey is the route for log records without the field, or whose field
+	// value is not a safe file name. Defaults to DefaultRoutingKey.
+	DefaultKey string
+
+	// MaxOpenFiles is the maximum number of files kept open. When exceeded the
+	// least recently used file is closed. Defaults to DefaultRoutingMaxOpenFiles.
+	MaxOpenFiles int
+
+	// IdleTimeout is how long a file can go unused before it is closed. Files are
+	// reopened as needed. Defaults to DefaultRoutingIdleTimeout.
+	IdleTimeout time.Duration
+
+	// MaxSize, MaxAge, MaxBackups and Compress provide the rotation settings
+	// shared by every routed file. See `FileOptions`.
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	Compress   bool
+}
+
+// routedFile is an open file for one route.
+type routedFile struct {
+	key      string
+	out      *lumberjack.Logger
+	lastUsed time.Time
+}
+
+// RoutingFile outputs log records to one of many rotated files selected by the
+// value of a field, such as a tenant ID. Files are opened on first use and
+// closed when idle or when too many are open.
+type RoutingFile struct {
+	logr.Basic
+	opts RoutingFileOptions
+
+	mux   sync.Mutex
+	files map[string]*list.Element
+	lru   *list.List // front is most recently used
+	quit  chan struct{}
+}
+
+// NewRoutingFileTarget creates a target that outputs log records to files
+// selected by the value of a field.
+func NewRoutingFileTarget(filter logr.Filter, formatter logr.Formatter, opts RoutingFileOptions, maxQueue int) (*RoutingFile, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("routing file target requires a directory")
+	}
+	if opts.Field == "" {
+		return nil, errors.New("routing file target requires a field")
+	}
+	if opts.DefaultKey == "" {
+		opts.DefaultKey = DefaultRoutingKey
+	}
+	if !routeKeyPattern.MatchString(opts.DefaultKey) {
+		return nil, fmt.Errorf("routing file target invalid default key %q", opts.DefaultKey)
+	}
+	if opts.MaxOpenFiles <= 0 {
+		opts.MaxOpenFiles = DefaultRoutingMaxOpenFiles
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultRoutingIdleTimeout
+	}
+	opts.Dir = filepath.Clean(opts.Dir)
+
+	r := &RoutingFile{
+		opts:  opts,
+		files: make(map[string]*list.Element),
+		lru:   list.New(),
+		quit:  make(chan struct{}),
+	}
+	r.Basic.Start(r, r, filter, formatter, maxQueue)
+	go r.startIdleCloser()
+
+	return r, nil
+}
+
+// Write converts the log record to bytes, via the Formatter,
+// and outputs to the file selected by the routing field.
+func (r *RoutingFile) Write(rec *logr.LogRec) error {
+	stacktrace := r.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := r.Formatter().Format(rec, stacktrace, buf)
+	if err != nil {
+		return err
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	out, err := r.file(r.routeKey(rec), rec.Logger().Logr())
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(buf.Bytes())
+	return err
+}
+
+// routeKey returns the route for a log record, falling back to the default
+// route for missing or unsafe field values.
+func (r *RoutingFile) routeKey(rec *logr.LogRec) string {
+	val, ok := rec.Fields()[r.opts.Field]
+	if !ok || val == nil {
+		return r.opts.DefaultKey
+	}
+	key := fmt.Sprint(val)
+	if !routeKeyPattern.MatchString(key) {
+		return r.opts.DefaultKey
+	}
+	return key
+}
+
+// file returns the open file for the route, opening it and closing the least
+// recently used file if needed. Errors closing files are reported via lgr so
+// that the current record is still written. Caller must hold the mutex.
+func (r *RoutingFile) file(key string, lgr *logr.Logr) (*lumberjack.Logger, error) {
+	now := time.Now()
+	if elem, ok := r.files[key]; ok {
+		rf := elem.Value.(*routedFile)
+		rf.lastUsed = now
+		r.lru.MoveToFront(elem)
+		return rf.out, nil
+	}
+
+	filename := filepath.Join(r.opts.Dir, key+".log")
+	if filepath.Dir(filename) != r.opts.Dir {
+		return nil, fmt.Errorf("routing file target invalid route %q", key)
+	}
+
+	for r.lru.Len() >= r.opts.MaxOpenFiles {
+		if err := r.closeFile(r.lru.Back()); err != nil {
+			lgr.ReportError(fmt.Errorf("routing file target cannot close file: %w", err))
+		}
+	}
+
+	rf := &routedFile{
+		key: key,
+		out: &lumberjack.Logger{
+			Filename:   filename,
+			MaxSize:    r.opts.MaxSize,
+			MaxBackups: r.opts.MaxBackups,
+			MaxAge:     r.opts.MaxAge,
+			Compress:   r.opts.Compress,
+		},
+		lastUsed: now,
+	}
+	r.files[key] = r.lru.PushFront(rf)
+	return rf.out, nil
+}
+
+// closeFile closes and forgets a file. Caller must hold the mutex.
+func (r *RoutingFile) closeFile(elem *list.Element) error {
+	rf := r.lru.Remove(elem).(*routedFile)
+	delete(r.files, rf.key)
+	return rf.out.Close()
+}
+
+// OpenFiles returns the number of routed files currently open.
+func (r *RoutingFile) OpenFiles() int {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.lru.Len()
+}
+
+// closeIdle closes all files unused since the idle timeout.
+func (r *RoutingFile) closeIdle() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	errs := merror.New()
+	expired := time.Now().Add(-r.opts.IdleTimeout)
+	for elem := r.lru.Back(); elem != nil; elem = r.lru.Back() {
+		if elem.Value.(*routedFile).lastUsed.After(expired) {
+			break
+		}
+		errs.Append(r.closeFile(elem))
+	}
+	return errs.ErrorOrNil()
+}
+
+// startIdleCloser periodically closes idle files until the target is shut down.
+func (r *RoutingFile) startIdleCloser() {
+	interval := r.opts.IdleTimeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.quit:
+			return
+		case <-ticker.C:
+			_ = r.closeIdle()
+		}
+	}
+}
+
+// Shutdown flushes any remaining log records and closes all files.
+func (r *RoutingFile) Shutdown(ctx context.Context) error {
+	errs := merror.New()
+
+	err := r.Basic.Shutdown(ctx)
+	errs.Append(err)
+	close(r.quit)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for elem := r.lru.Back(); elem != nil; elem = r.lru.Back() {
+		errs.Append(r.closeFile(elem))
+	}
+
+	return errs.ErrorOrNil()
+}
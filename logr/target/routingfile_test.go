@@ -0,0 +1,61 @@
+package target_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+)
+
+func TestRoutingFileEviction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logr-routing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lgr := &logr.Logr{}
+	lgr.OnLoggerError = func(err error) {
+		t.Error(err)
+	}
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	opts := target.RoutingFileOptions{Dir: dir, Field: "tenant", MaxOpenFiles: 1}
+	tgt, err := target.NewRoutingFileTarget(filter, &format.Plain{Delim: " | ", DisableTimestamp: true}, opts, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = lgr.AddTarget(tgt)
+
+	// alternate routes so each record evicts the other route's file.
+	logger := lgr.NewLogger()
+	for i := 0; i < 10; i++ {
+		for _, tenant := range []string{"a", "b", "../escape"} {
+			logger.WithField("tenant", tenant).Info(fmt.Sprintf("record %d", i))
+		}
+	}
+	if err := lgr.Flush(); err != nil {
+		t.Error(err)
+	}
+	if n := tgt.OpenFiles(); n != 1 {
+		t.Errorf("expected 1 open file, got %d", n)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	for _, key := range []string{"a", "b", target.DefaultRoutingKey} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, key+".log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(data), "record "); n != 10 {
+			t.Errorf("route %s: expected 10 records, got %d", key, n)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package target
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// Sampled target defaults.
+const (
+	// DefaultSampleLimit is the number of log records per level delivered each
+	// interval when `SampleOptions.Limit` is zero.
+	DefaultSampleLimit = 100
+
+	// DefaultSampleInterval is the interval when `SampleOptions.Interval` is zero.
+	DefaultSampleInterval = time.Second
+)
+
+// SampleOptions configures a sampled target.
+type SampleOptions struct {
+	// Limit is the maximum number of log records per level delivered each
+	// interval, allowing bursts of up to Limit records. Defaults to DefaultSampleLimit.
+	Limit int
+
+	// Interval is the period over which Limit applies. Defaults to DefaultSampleInterval.
+	Interval time.Duration
+
+	// Exempt is the least severe level that is never sampled, so errors are
+	// delivered while debug is throttled. Defaults to `logr.Error`.
+	Exempt logr.Level
+
+	// Summary, when true, delivers a summary log record for each level at the
+	// end of every interval in which records of that level were suppressed.
+	Summary bool
+}
+
+// sampledLevel tracks the token bucket and suppressed records for one level.
+type sampledLevel struct {
+	bucket *logr.TokenBucket
+
+	weight     uint64 // sum of the sample rates of records suppressed since the last delivered
+	dropped    uint64 // records suppressed since the last summary
+	lastDrop   *logr.LogRec
+	suppressed uint64 // total records suppressed
+}
+
+// Sampled is a target that wraps another target and delivers at most
+// `SampleOptions.Limit` log records per level each interval, suppressing the
+// rest. Levels at least as severe as `SampleOptions.Exempt` are never
+// suppressed. Each delivered record's sampling rate, see `logr.LogRec.SampleRate`,
+// is set to the number of records it represents: itself plus the records of
+// the same level suppressed since the previous delivered record. When
+// `SampleOptions.Summary` is true a summary record is also delivered at the
+// end of each interval, with the number of records suppressed in the
+// `logr.FieldKeySuppressed` field.
+type Sampled struct {
+	name   string
+	target logr.Target
+	opts   SampleOptions
+
+	mux    sync.Mutex
+	levels map[logr.LevelID]*sampledLevel
+
+	suppressed uint64
+	quit       chan struct{}
+	done       chan struct{}
+}
+
+// NewSampledTarget creates a target that wraps target and limits the log
+// records delivered to it per level.
+func NewSampledTarget(target logr.Target, opts SampleOptions) *Sampled {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultSampleLimit
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSampleInterval
+	}
+	if opts.Exempt.Name == "" {
+		opts.Exempt = logr.Error
+	}
+	s := &Sampled{
+		target: target,
+		opts:   opts,
+		levels: make(map[logr.LevelID]*sampledLevel),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if opts.Summary {
+		go s.start()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+// SetName provides an optional name for the target.
+func (s *Sampled) SetName(name string) {
+	s.name = name
+}
+
+// Name returns the name provided via `SetName`, or empty string if none.
+func (s *Sampled) Name() string {
+	return s.name
+}
+
+// IsLevelEnabled returns the wrapped target's level status.
+func (s *Sampled) IsLevelEnabled(lvl logr.Level) (enabled bool, stacktrace bool) {
+	return s.target.IsLevelEnabled(lvl)
+}
+
+// Formatter returns the wrapped target's Formatter.
+func (s *Sampled) Formatter() logr.Formatter {
+	return s.target.Formatter()
+}
+
+// Log delivers the log record if its level is exempt or within the limit,
+// otherwise it is suppressed.
+func (s *Sampled) Log(rec *logr.LogRec) {
+	if rec.IsFlush() {
+		s.summarize()
+		logr.ForwardFlush(rec, s.target)
+		return
+	}
+
+	lvl := rec.Level()
+	if lvl.ID <= s.opts.Exempt.ID {
+		s.target.Log(rec)
+		return
+	}
+
+	now := rec.Logger().Logr().Now()
+
+	s.mux.Lock()
+	sl, ok := s.levels[lvl.ID]
+	if !ok {
+		rate := float64(s.opts.Limit) / s.opts.Interval.Seconds()
+		sl = &sampledLevel{bucket: logr.NewTokenBucket(rate, s.opts.Limit)}
+		s.levels[lvl.ID] = sl
+	}
+
+	if !sl.bucket.Allow(now) {
+		sl.weight += rec.SampleRate()
+		sl.dropped++
+		sl.lastDrop = rec
+		sl.suppressed++
+		s.mux.Unlock()
+		atomic.AddUint64(&s.suppressed, 1)
+		return
+	}
+	weight := sl.weight
+	sl.weight = 0
+	s.mux.Unlock()
+
+	if weight > 0 {
+		rec = rec.WithSampleRate(rec.SampleRate() + weight)
+	}
+	s.target.Log(rec)
+}
+
+// Suppressed returns the total number of log records suppressed.
+func (s *Sampled) Suppressed() uint64 {
+	return atomic.LoadUint64(&s.suppressed)
+}
+
+// SuppressedLevel returns the number of log records of the level suppressed.
+func (s *Sampled) SuppressedLevel(lvl logr.Level) uint64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if sl, ok := s.levels[lvl.ID]; ok {
+		return sl.suppressed
+	}
+	return 0
+}
+
+// summarize delivers a summary for each level with records suppressed since
+// the previous summary, if summaries are enabled.
+func (s *Sampled) summarize() {
+	if !s.opts.Summary {
+		return
+	}
+	var summaries []*logr.LogRec
+
+	s.mux.Lock()
+	for _, sl := range s.levels {
+		if sl.dropped == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("dropped %d records in last interval", sl.dropped)
+		rec := sl.lastDrop.WithMessage(msg, logr.Fields{logr.FieldKeySuppressed: sl.dropped})
+		summaries = append(summaries, rec.WithSampleRate(1))
+		sl.dropped = 0
+		sl.lastDrop = nil
+	}
+	s.mux.Unlock()
+
+	for _, rec := range summaries {
+		s.target.Log(rec)
+	}
+}
+
+// start delivers summaries every interval until the target is shut down.
+func (s *Sampled) start() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+			s.summarize()
+		}
+	}
+}
+
+// Health returns the wrapped target's health.
+func (s *Sampled) Health() logr.TargetHealth {
+	return logr.HealthOf(s.target)
+}
+
+// EnableMetrics enables metrics collection for the wrapped target, if supported.
+func (s *Sampled) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if tm, ok := s.target.(logr.TargetWithMetrics); ok {
+		return tm.EnableMetrics(collector, updateFreqMillis)
+	}
+	return nil
+}
+
+// Validate validates the wrapped target, if supported.
+func (s *Sampled) Validate() error {
+	return logr.ValidateOf(s.target)
+}
+
+// UpdateQueueMetrics updates the queue metrics of the wrapped target, if supported.
+func (s *Sampled) UpdateQueueMetrics() {
+	logr.UpdateQueueMetricsOf(s.target)
+}
+
+// Shutdown delivers any pending summaries then shuts down the wrapped target.
+func (s *Sampled) Shutdown(ctx context.Context) error {
+	close(s.quit)
+	<-s.done
+	s.summarize()
+	return s.target.Shutdown(ctx)
+}
+
+// String returns a name for this target. Use `SetName` to specify a name.
+func (s *Sampled) String() string {
+	if s.name != "" {
+		return s.name
+	}
+	return fmt.Sprintf("%T", s)
+}
@@ -0,0 +1,40 @@
+package target_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func TestSampledPerLevel(t *testing.T) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	writer := target.NewWriterTarget(&logr.StdFilter{Lvl: logr.Debug}, &format.Plain{Delim: " | "}, buf, 100)
+	sampled := target.NewSampledTarget(writer, target.SampleOptions{Limit: 2, Interval: time.Hour})
+	_ = lgr.AddTarget(sampled)
+
+	logger := lgr.NewLogger()
+	for i := 0; i < 5; i++ {
+		logger.Debug("sampled")
+		logger.Error("exempt")
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	output := buf.String()
+	if n := strings.Count(output, "sampled"); n != 2 {
+		t.Errorf("expected 2 debug records, got %d", n)
+	}
+	if n := strings.Count(output, "exempt"); n != 5 {
+		t.Errorf("expected 5 error records, got %d", n)
+	}
+	if n := sampled.SuppressedLevel(logr.Debug); n != 3 {
+		t.Errorf("expected 3 debug records suppressed, got %d", n)
+	}
+}
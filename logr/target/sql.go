@@ -0,0 +1,327 @@
+package target
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+const (
+	// DefaultSQLBatchSize is the number of records inserted per transaction
+	// when `SQLOptions.BatchSize` is zero.
+	DefaultSQLBatchSize = 100
+
+	// DefaultSQLFlushInterval is the maximum time records wait for a batch to
+	// fill when `SQLOptions.FlushInterval` is zero.
+	DefaultSQLFlushInterval = time.Second
+
+	// DefaultSQLMaxRetries is the number of times a failed batch is retried
+	// when `SQLOptions.MaxRetries` is zero.
+	DefaultSQLMaxRetries = 3
+
+	sqlPingTimeout = 10 * time.Second
+)
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLColumns maps log record values to table columns. Any empty column name
+// uses the default shown for that field.
+type SQLColumns struct {
+	Time    string // default "time"
+	Level   string // default "level"
+	Message string // default "message"
+	Fields  string // default "fields"; fields are stored as a JSON object
+}
+
+// SQLOptions configures a SQL target.
+type SQLOptions struct {
+	// Table is the table to insert log records into.
+	Table string
+
+	// Columns maps log record values to table columns.
+	Columns SQLColumns
+
+	// NumberedPlaceholders uses `$1, $2, ...` placeholders (e.g. PostgreSQL)
+	// instead of `?`.
+	NumberedPlaceholders bool
+
+	// BatchSize is the number of records inserted per transaction. Defaults
+	// to DefaultSQLBatchSize.
+	BatchSize int
+
+	// FlushInterval is the maximum time records wait for a batch to fill before
+	// being inserted. Defaults to DefaultSQLFlushInterval.
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times a failed batch is retried before its
+	// records are dropped. Defaults to DefaultSQLMaxRetries; use a negative
+	// value to disable retries.
+	MaxRetries int
+
+	// Backoff determines the delay between retries.
+	Backoff logr.Backoff
+
+	// IsTransient reports whether an error is worth retrying. Defaults to
+	// retrying connection errors, such as network errors and
+	// `driver.ErrBadConn`; errors such as syntax errors or constraint
+	// violations fail the same way on every attempt and are not retried.
+	IsTransient func(err error) bool
+}
+
+// sqlRow is a log record resolved to column values.
+type sqlRow struct {
+	time   time.Time
+	level  string
+	msg    string
+	fields string
+}
+
+// SQL inserts log records into a database table, batching records into one
+// transaction per batch using a prepared statement.
+type SQL struct {
+	logr.Basic
+	db   *sql.DB
+	opts SQLOptions
+	stmt string
+
+	mux   sync.Mutex // guards batch and lgr, but is not held while inserting
+	batch []sqlRow
+	lgr   *logr.Logr // used to report errors from periodic inserts
+	quit  chan struct{}
+
+	insertMux sync.Mutex // serializes inserts so batches are inserted in order
+}
+
+// NewSQLTarget creates a target that inserts log records into a SQL table.
+// The table must already exist with columns compatible with `SQLColumns`.
+func NewSQLTarget(filter logr.Filter, db *sql.DB, opts SQLOptions, maxQueue int) (*SQL, error) {
+	if db == nil {
+		return nil, errors.New("sql target requires a database")
+	}
+	if opts.Columns.Time == "" {
+		opts.Columns.Time = "time"
+	}
+	if opts.Columns.Level == "" {
+		opts.Columns.Level = "level"
+	}
+	if opts.Columns.Message == "" {
+		opts.Columns.Message = "message"
+	}
+	if opts.Columns.Fields == "" {
+		opts.Columns.Fields = "fields"
+	}
+	cols := []string{opts.Columns.Time, opts.Columns.Level, opts.Columns.Message, opts.Columns.Fields}
+	for _, ident := range append([]string{opts.Table}, cols...) {
+		if !sqlIdentifier.MatchString(ident) {
+			return nil, fmt.Errorf("sql target invalid identifier %q", ident)
+		}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultSQLBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultSQLFlushInterval
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultSQLMaxRetries
+	}
+	if opts.IsTransient == nil {
+		opts.IsTransient = isTransientSQLError
+	}
+
+	placeholders := make([]string, len(cols))
+	for i := range placeholders {
+		if opts.NumberedPlaceholders {
+			placeholders[i] = fmt.Sprintf("$%d", i+1)
+		} else {
+			placeholders[i] = "?"
+		}
+	}
+
+	s := &SQL{
+		db:    db,
+		opts:  opts,
+		stmt:  fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", opts.Table, strings.Join(cols, ", "), strings.Join(placeholders, ", ")),
+		batch: make([]sqlRow, 0, opts.BatchSize),
+		quit:  make(chan struct{}),
+	}
+	s.Basic.Start(s, s, filter, nil, maxQueue)
+	go s.startFlusher()
+
+	return s, nil
+}
+
+// Write adds the log record to the current batch, inserting the batch
+// once full.
+func (s *SQL) Write(rec *logr.LogRec) error {
+	flds, err := fieldsJSON(rec.Fields())
+	if err != nil {
+		return err
+	}
+	row := sqlRow{
+		time:   rec.Time(),
+		level:  rec.Level().Name,
+		msg:    rec.Msg(),
+		fields: flds,
+	}
+
+	s.mux.Lock()
+	s.lgr = rec.Logger().Logr()
+	s.batch = append(s.batch, row)
+	full := len(s.batch) >= s.opts.BatchSize
+	s.mux.Unlock()
+
+	if !full {
+		return nil
+	}
+	return s.insertBatch()
+}
+
+// Flush inserts any records in the current batch. It is called
+// automatically when the target is flushed or shut down.
+func (s *SQL) Flush() error {
+	return s.insertBatch()
+}
+
+// Validate checks that the database can be reached.
+func (s *SQL) Validate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlPingTimeout)
+	defer cancel()
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("sql target cannot reach database: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops the target after inserting any queued or batched records.
+func (s *SQL) Shutdown(ctx context.Context) error {
+	err := s.Basic.Shutdown(ctx)
+	close(s.quit)
+	return err
+}
+
+// insertBatch takes the current batch and inserts it, retrying transient
+// errors. The records are dropped if the insert fails. Records can be added
+// to the next batch while inserting and retrying.
+func (s *SQL) insertBatch() error {
+	s.insertMux.Lock()
+	defer s.insertMux.Unlock()
+
+	s.mux.Lock()
+	rows := s.batch
+	if len(rows) > 0 {
+		s.batch = make([]sqlRow, 0, s.opts.BatchSize)
+	}
+	s.mux.Unlock()
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = s.insert(rows); err == nil {
+			return nil
+		}
+		if attempt >= s.opts.MaxRetries || !s.opts.IsTransient(err) {
+			break
+		}
+		time.Sleep(s.opts.Backoff.Delay(attempt + 1))
+	}
+	return fmt.Errorf("sql target dropped %d records: %w", len(rows), err)
+}
+
+// insert writes the rows within a single transaction.
+func (s *SQL) insert(rows []sqlRow) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.Prepare(s.stmt)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err = stmt.Exec(row.time, row.level, row.msg, row.fields); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// startFlusher periodically inserts partial batches until the target is shut down.
+func (s *SQL) startFlusher() {
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+			err := s.insertBatch()
+			s.mux.Lock()
+			lgr := s.lgr
+			s.mux.Unlock()
+			if err != nil && lgr != nil {
+				lgr.ReportError(err)
+			}
+		}
+	}
+}
+
+// fieldsJSON encodes fields as a JSON object. Values that cannot be
+// encoded are stored using their string representation.
+func fieldsJSON(flds logr.Fields) (string, error) {
+	if len(flds) == 0 {
+		return "{}", nil
+	}
+	m := make(map[string]interface{}, len(flds))
+	for k, v := range flds {
+		switch t := v.(type) {
+		case error:
+			m[k] = t.Error()
+		case fmt.Stringer:
+			m[k] = t.String()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				m[k] = fmt.Sprint(v)
+			} else {
+				m[k] = v
+			}
+		}
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// isTransientSQLError returns true for connection errors, which may succeed
+// when retried on a new connection.
+func isTransientSQLError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,216 @@
+package target_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/target"
+)
+
+// fakeDB is a database/sql driver whose inserts fail with queued errors.
+type fakeDB struct {
+	mux     sync.Mutex
+	errs    []error
+	pingErr error
+	execs   int
+	rows    int
+}
+
+func (d *fakeDB) failNext(errs ...error) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	d.errs = append(d.errs, errs...)
+}
+
+func (d *fakeDB) counts() (execs int, rows int) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return d.execs, d.rows
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt(c), nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (c fakeConn) Ping(ctx context.Context) error {
+	c.db.mux.Lock()
+	defer c.db.mux.Unlock()
+	return c.db.pingErr
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct{ db *fakeDB }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return 4 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mux.Lock()
+	defer s.db.mux.Unlock()
+	s.db.execs++
+	if len(s.db.errs) > 0 {
+		err := s.db.errs[0]
+		s.db.errs = s.db.errs[1:]
+		return nil, err
+	}
+	s.db.rows++
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var registerFakeDB sync.Once
+
+// openFakeDB returns a database backed by a new fakeDB.
+func openFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	registerFakeDB.Do(func() {
+		sql.Register("logrfake", fakeDriver{})
+	})
+	fake := &fakeDB{}
+	fakeDriversMux.Lock()
+	fakeDriverDBs[t.Name()] = fake
+	fakeDriversMux.Unlock()
+
+	db, err := sql.Open("logrfake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+var (
+	fakeDriversMux sync.Mutex
+	fakeDriverDBs  = make(map[string]*fakeDB)
+)
+
+// fakeDriver opens connections to the fakeDB registered for each data
+// source name.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDriversMux.Lock()
+	defer fakeDriversMux.Unlock()
+	return fakeConn{db: fakeDriverDBs[name]}, nil
+}
+
+// newSQLTarget creates a logr in SyncMode, so each Log call writes to the
+// SQL target in the calling goroutine.
+func newSQLTarget(t *testing.T, db *sql.DB, opts target.SQLOptions) *logr.Logr {
+	lgr := &logr.Logr{SyncMode: true}
+	lgr.OnLoggerError = func(err error) {}
+	opts.Table = "logs"
+	tgt, err := target.NewSQLTarget(&logr.StdFilter{Lvl: logr.Info}, db, opts, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = lgr.AddTarget(tgt)
+	return lgr
+}
+
+func TestSQLRetriesOnlyConnectionErrors(t *testing.T) {
+	db, fake := openFakeDB(t)
+	defer db.Close()
+
+	opts := target.SQLOptions{
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		MaxRetries:    3,
+		Backoff:       logr.Backoff{Initial: time.Millisecond, Max: time.Millisecond},
+	}
+	lgr := newSQLTarget(t, db, opts)
+	logger := lgr.NewLogger()
+
+	// a constraint violation fails the same way every time.
+	fake.failNext(errors.New("UNIQUE constraint failed: logs.id"))
+	logger.Info("not retried")
+	if execs, rows := fake.counts(); execs != 1 || rows != 0 {
+		t.Errorf("expected 1 exec and 0 rows, got %d execs and %d rows", execs, rows)
+	}
+
+	// a connection error may succeed on a new connection.
+	fake.failNext(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")})
+	logger.Info("retried")
+	if execs, rows := fake.counts(); execs != 3 || rows != 1 {
+		t.Errorf("expected 3 execs and 1 row, got %d execs and %d rows", execs, rows)
+	}
+
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLLogDuringBackoff(t *testing.T) {
+	db, fake := openFakeDB(t)
+	defer db.Close()
+
+	const backoff = 500 * time.Millisecond
+	opts := target.SQLOptions{
+		BatchSize:     100,
+		FlushInterval: 10 * time.Millisecond,
+		MaxRetries:    1,
+		Backoff:       logr.Backoff{Initial: backoff, Max: backoff},
+	}
+	lgr := newSQLTarget(t, db, opts)
+	logger := lgr.NewLogger()
+
+	// the periodic insert of this record fails and waits to retry.
+	fake.failNext(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")})
+	logger.Info("first")
+	for {
+		if execs, _ := fake.counts(); execs > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	logger.Info("second")
+	if elapsed := time.Since(start); elapsed >= backoff/2 {
+		t.Errorf("log blocked for %v while the insert was backing off", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := lgr.ShutdownWithContext(ctx); err != nil {
+		t.Error(err)
+	}
+	if _, rows := fake.counts(); rows != 2 {
+		t.Errorf("expected 2 rows, got %d", rows)
+	}
+}
+
+func TestSQLValidate(t *testing.T) {
+	db, fake := openFakeDB(t)
+	defer db.Close()
+
+	tgt, err := target.NewSQLTarget(&logr.StdFilter{Lvl: logr.Info}, db, target.SQLOptions{Table: "logs"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tgt.Shutdown(context.Background())
+
+	if err := tgt.Validate(); err != nil {
+		t.Error(err)
+	}
+	fake.mux.Lock()
+	fake.pingErr = errors.New("database unavailable")
+	fake.mux.Unlock()
+	if err := tgt.Validate(); err == nil {
+		t.Error("expected an error when the database cannot be reached")
+	}
+}
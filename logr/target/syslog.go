@@ -0,0 +1,319 @@
+package target
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/target/netutil"
+	"github.com/wiggin77/merror"
+)
+
+// Syslog target defaults.
+const (
+	// DefaultSyslogFacility is the facility when `SyslogParams.Facility` is zero: user-level messages.
+	DefaultSyslogFacility = 1
+
+	syslogDialTimeout = 10 * time.Second
+)
+
+// localSyslogAddrs are the unix sockets tried when no network and address are provided.
+var localSyslogAddrs = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogParams provides parameters for dialing a syslog daemon.
+type SyslogParams struct {
+	// Network is "udp", "tcp", "unix" or "unixgram". Defaults to "udp" when
+	// Raddr is provided. When Network and Raddr are both empty the local
+	// syslog daemon is used via its unix socket.
+	Network string
+
+	// Raddr is the address of the syslog daemon, e.g. "localhost:514", or the
+	// path of its unix socket.
+	Raddr string
+
+	// Facility is the syslog facility code, e.g. 16 for local0. Defaults to
+	// DefaultSyslogFacility.
+	Facility int
+
+	// Tag is the APP-NAME of each message. Defaults to the program name.
+	Tag string
+
+	// Hostname is the HOSTNAME of each message. Defaults to `os.Hostname`.
+	Hostname string
+
+	// TLS, when true, dials Raddr using TLS over TCP.
+	TLS bool
+
+	// TLSConfig configures the TLS connection when TLS is true. Defaults to a
+	// config verifying the server against the host's root CAs.
+	TLSConfig *tls.Config `json:"-"`
+
+	// Insecure, when true, skips verification of the server certificate.
+	Insecure bool
+
+	// Backoff determines the delay between reconnect attempts after the
+	// connection is lost.
+	Backoff logr.Backoff
+
+	// BufferSize is the maximum number of messages buffered while
+	// disconnected, after which the oldest are dropped. Defaults to
+	// `netutil.DefaultBufferSize`; a negative value disables buffering.
+	BufferSize int
+
+	// Severity, when not nil, maps levels to syslog severities (0-7). By default
+	// `logr.SyslogSeverity` is used, which can also be changed for all syslog
+	// targets via `logr.RegisterLevelMapping` with `logr.LevelSchemeSyslog`.
+	Severity func(lvl logr.Level) int `json:"-"`
+}
+
+// Syslog outputs log records to local or remote syslog using RFC 5424
+// framing. Messages sent over TCP are framed by octet counting (RFC 6587).
+// When the connection is lost it is re-established in the background via a
+// `netutil.ReconnectingConn`, with messages buffered meanwhile up to
+// `SyslogParams.BufferSize`.
+type Syslog struct {
+	logr.Basic
+	params   SyslogParams
+	hostname string
+	appName  string
+	procID   string
+
+	conn   *netutil.ReconnectingConn
+	framed int32 // atomic; 1 for stream connections, which need octet counting
+}
+
+func init() {
+	logr.RegisterTargetType("syslog", func(options json.RawMessage, filter logr.Filter, formatter logr.Formatter, maxQueue int) (logr.Target, error) {
+		params := &SyslogParams{}
+		if err := decodeOptions(options, params); err != nil {
+			return nil, err
+		}
+		return NewSyslogTarget(filter, formatter, params, maxQueue)
+	})
+}
+
+// NewSyslogTarget creates a target capable of outputting log records to remote or local syslog.
+// An error is returned if the syslog daemon cannot be reached initially.
+func NewSyslogTarget(filter logr.Filter, formatter logr.Formatter, params *SyslogParams, maxQueue int) (*Syslog, error) {
+	p := *params
+	if p.Facility <= 0 {
+		p.Facility = DefaultSyslogFacility
+	}
+	if p.Facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", p.Facility)
+	}
+	if p.Severity == nil {
+		p.Severity = logr.SyslogSeverity
+	}
+
+	s := &Syslog{
+		params:   p,
+		hostname: syslogHeaderValue(p.Hostname, 255),
+		appName:  syslogHeaderValue(p.Tag, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+	if p.Hostname == "" {
+		if host, err := os.Hostname(); err == nil {
+			s.hostname = syslogHeaderValue(host, 255)
+		}
+	}
+	if p.Tag == "" && len(os.Args) > 0 {
+		s.appName = syslogHeaderValue(filepath.Base(os.Args[0]), 48)
+	}
+
+	s.conn = netutil.NewReconnectingConn(s.dial, netutil.Options{
+		Backoff:    p.Backoff,
+		BufferSize: p.BufferSize,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), syslogDialTimeout)
+	defer cancel()
+	if err := s.conn.Connect(ctx); err != nil {
+		_ = s.conn.Close()
+		return nil, err
+	}
+
+	s.Basic.Start(s, s, filter, formatter, maxQueue)
+	return s, nil
+}
+
+// IsConnected returns true while connected to the syslog daemon.
+func (s *Syslog) IsConnected() bool {
+	return s.conn.Connected()
+}
+
+// Health returns the health of this target, which is down while not connected.
+func (s *Syslog) Health() logr.TargetHealth {
+	h := s.Basic.Health()
+	h.Up = h.Up && s.IsConnected()
+	return h
+}
+
+// Validate checks that the syslog daemon can be reached, dialing it if not
+// currently connected. For UDP this only resolves the address.
+func (s *Syslog) Validate() error {
+	if s.IsConnected() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), syslogDialTimeout)
+	defer cancel()
+	conn, _, err := s.connect(ctx)
+	if err != nil {
+		return fmt.Errorf("syslog not reachable: %w", err)
+	}
+	return conn.Close()
+}
+
+// EnableMetrics enables metrics collection using the provided
+// MetricsCollector. Messages dropped while disconnected are counted by the
+// target's dropped counter.
+func (s *Syslog) EnableMetrics(collector logr.MetricsCollector, updateFreqMillis int64) error {
+	if err := s.Basic.EnableMetrics(collector, updateFreqMillis); err != nil {
+		return err
+	}
+	name := s.Name()
+	if name == "" {
+		name = s.String()
+	}
+	counter, err := collector.DroppedCounter(name)
+	if err != nil {
+		return err
+	}
+	s.conn.SetDroppedCounter(counter)
+	return nil
+}
+
+// Shutdown stops processing log records after making best
+// effort to flush queue.
+func (s *Syslog) Shutdown(ctx context.Context) error {
+	errs := merror.New()
+	errs.Append(s.Basic.Shutdown(ctx))
+	errs.Append(s.conn.Close())
+	return errs.ErrorOrNil()
+}
+
+// Write converts the log record to bytes, via the Formatter,
+// and outputs to syslog.
+func (s *Syslog) Write(rec *logr.LogRec) error {
+	stacktrace := s.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	buf, err := s.Formatter().Format(rec, stacktrace, buf)
+	if err != nil {
+		return err
+	}
+
+	msg := s.message(rec, bytes.TrimRight(buf.Bytes(), "\n"))
+	if _, err = s.conn.Write(msg); err != nil {
+		return fmt.Errorf("syslog write fail: %w", err)
+	}
+	return nil
+}
+
+// message builds an RFC 5424 message, framed for stream connections.
+func (s *Syslog) message(rec *logr.LogRec, text []byte) []byte {
+	severity := s.params.Severity(rec.Level())
+	if severity < 0 || severity > 7 {
+		severity = logr.SyslogSeverity(logr.Info)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<%d>1 %s %s %s %s - - ",
+		s.params.Facility*8+severity,
+		rec.Time().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogNilValue(s.hostname), syslogNilValue(s.appName), s.procID)
+	sb.Write(text)
+
+	if atomic.LoadInt32(&s.framed) == 0 {
+		return []byte(sb.String())
+	}
+	return []byte(strconv.Itoa(sb.Len()) + " " + sb.String())
+}
+
+// dial connects to the syslog daemon, recording whether messages need
+// octet counting on the new connection. It is the `netutil.DialFunc` of the
+// target's connection.
+func (s *Syslog) dial(ctx context.Context) (net.Conn, error) {
+	conn, framed, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var v int32
+	if framed {
+		v = 1
+	}
+	atomic.StoreInt32(&s.framed, v)
+	return conn, nil
+}
+
+// connect dials the configured network and address, or the local daemon.
+func (s *Syslog) connect(ctx context.Context) (net.Conn, bool, error) {
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+
+	p := s.params
+	if p.TLS {
+		config := p.TLSConfig
+		if config == nil {
+			config = &tls.Config{}
+		} else {
+			config = config.Clone()
+		}
+		if p.Insecure {
+			config.InsecureSkipVerify = true
+		}
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: config}
+		conn, err := tlsDialer.DialContext(ctx, "tcp", p.Raddr)
+		return conn, true, err
+	}
+
+	if p.Network == "" && p.Raddr == "" {
+		for _, addr := range localSyslogAddrs {
+			for _, network := range []string{"unixgram", "unix"} {
+				if conn, err := dialer.DialContext(ctx, network, addr); err == nil {
+					return conn, network == "unix", nil
+				}
+			}
+		}
+		return nil, false, errors.New("local syslog daemon not found")
+	}
+
+	network := p.Network
+	if network == "" {
+		network = "udp"
+	}
+	conn, err := dialer.DialContext(ctx, network, p.Raddr)
+	framed := !strings.HasPrefix(network, "udp") && network != "unixgram"
+	return conn, framed, err
+}
+
+// syslogHeaderValue makes a header field value of printable US-ASCII, without
+// spaces, truncated to max characters.
+func syslogHeaderValue(v string, max int) string {
+	b := make([]byte, 0, len(v))
+	for i := 0; i < len(v) && len(b) < max; i++ {
+		if c := v[i]; c > 32 && c < 127 {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+// syslogNilValue returns the RFC 5424 NILVALUE, "-", for an empty header field.
+func syslogNilValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
@@ -0,0 +1,137 @@
+package target_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func ExampleSyslog() {
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Warn, Stacktrace: logr.Error}
+	formatter := &format.Plain{Delim: " | "}
+	params := &target.SyslogParams{Network: "", Raddr: "", Facility: 3, Tag: "logrtest"}
+	t, err := target.NewSyslogTarget(filter, formatter, params, 1000)
+	if err != nil {
+		panic(err)
+	}
+	_ = lgr.AddTarget(t)
+
+	logger := lgr.NewLogger().WithField("name", "wiggin")
+
+	logger.Errorf("the erroneous data is %s", test.StringRnd(10))
+	logger.Warnf("strange data: %s", test.StringRnd(5))
+	logger.Debug("XXX")
+	logger.Trace("XXX")
+
+	err = lgr.Shutdown()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func TestSyslogPlain(t *testing.T) {
+	plain := &format.Plain{Delim: " | ", DisableTimestamp: true}
+	syslogger(t, plain)
+}
+
+func syslogger(t *testing.T, formatter logr.Formatter) {
+	lgr := &logr.Logr{}
+
+	lgr.OnLoggerError = func(err error) {
+		t.Error(err)
+	}
+
+	filter := &logr.StdFilter{Lvl: logr.Warn, Stacktrace: logr.Panic}
+	params := &target.SyslogParams{Network: "", Raddr: "", Facility: 3, Tag: "logrtest"}
+	target, err := target.NewSyslogTarget(filter, formatter, params, 1000)
+	if err != nil {
+		t.Skipf("syslog daemon not available: %v", err)
+	}
+	_ = lgr.AddTarget(target)
+
+	cfg := test.DoSomeLoggingCfg{
+		Lgr:        lgr,
+		Goroutines: 3,
+		Loops:      5,
+		GoodToken:  "Woot!",
+		BadToken:   "XXX!!XXX",
+		Lvl:        logr.Warn,
+		Delay:      time.Millisecond * 1,
+	}
+	test.DoSomeLogging(cfg)
+	err = lgr.Shutdown()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			// messages are framed by octet counting: "<len> <msg>".
+			size, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(size))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	lgr := &logr.Logr{}
+	filter := &logr.StdFilter{Lvl: logr.Info, Stacktrace: logr.Panic}
+	params := &target.SyslogParams{Network: "tcp", Raddr: ln.Addr().String(), Facility: 16, Tag: "logrtest"}
+	tgt, err := target.NewSyslogTarget(filter, &format.Plain{DisableTimestamp: true}, params, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = lgr.AddTarget(tgt)
+	lgr.NewLogger().Warn("over tcp")
+
+	select {
+	case msg := <-received:
+		// facility 16 (local0) * 8 + severity 4 (warning).
+		if !strings.HasPrefix(msg, "<132>1 ") || !strings.Contains(msg, "logrtest") || !strings.Contains(msg, "over tcp") {
+			t.Errorf("unexpected message %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	if !tgt.IsConnected() {
+		t.Error("expected connected")
+	}
+	if err := tgt.Validate(); err != nil {
+		t.Error(err)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,231 @@
+package target
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+const (
+	// DefaultBufferSize is the buffer size used by buffered writer targets
+	// when `BufferOptions.Size` is zero.
+	DefaultBufferSize = 32 * 1024
+
+	// DefaultBufferFlushInterval is the maximum time records stay buffered when
+	// `BufferOptions.FlushInterval` is zero.
+	DefaultBufferFlushInterval = time.Second
+)
+
+// BufferOptions configures write coalescing for a Writer target.
+//
+// Buffered records are held in memory until the buffer fills, the flush
+// interval elapses, or the target is flushed or shut down. Records still in
+// the buffer are lost if the process crashes or exits without calling
+// `Logr.Flush` or `Logr.Shutdown`, so buffering trades crash durability for
+// fewer, larger writes.
+type BufferOptions struct {
+	// Size is the buffer size in bytes. Defaults to `DefaultBufferSize`.
+	Size int
+
+	// FlushInterval is the maximum time records stay buffered before being
+	// written. Defaults to `DefaultBufferFlushInterval`.
+	FlushInterval time.Duration
+}
+
+// WriterOptions configures a Writer target.
+type WriterOptions struct {
+	// Lock, when not nil, is held while writing to the io.Writer, e.g. a mutex
+	// also held by other code writing to the same pipe or stream, so that
+	// output is not interleaved. Writes by the target itself are always
+	// serialized.
+	Lock sync.Locker
+
+	// Buffer, when not nil, coalesces log records into fewer writes. See
+	// `BufferOptions` for the durability tradeoff.
+	Buffer *BufferOptions
+
+	// Colorize determines whether the formatter is asked to colorize output.
+	// Defaults to ColorizeAuto.
+	Colorize Colorize
+
+	// LevelColors are the colors per level when colorizing. Defaults to
+	// `logr.DefaultLevelColors`.
+	LevelColors logr.LevelColors
+}
+
+// Writer outputs log records to any `io.Writer`, each formatted record
+// followed by a newline. Use a filter such as `logr.CustomFilter` to write a
+// range of levels. On shutdown any buffered records are written, then the
+// io.Writer is flushed if it has a `Flush() error` method, or synced if it has
+// a `Sync() error` method, e.g. `*os.File` or `*bufio.Writer`.
+type Writer struct {
+	logr.Basic
+	out  io.Writer
+	lock sync.Locker
+
+	mux  sync.Mutex
+	buf  *bufio.Writer
+	quit chan struct{}
+
+	color       bool
+	levelColors logr.LevelColors
+}
+
+// NewWriterTarget creates a target capable of outputting log records to an io.Writer.
+// Output is never colorized; use `NewWriterTargetWithOptions` to colorize.
+func NewWriterTarget(filter logr.Filter, formatter logr.Formatter, out io.Writer, maxQueue int) *Writer {
+	if out == nil {
+		out = ioutil.Discard
+	}
+	w := &Writer{out: out}
+	w.Basic.Start(w, w, filter, formatter, maxQueue)
+	return w
+}
+
+// NewBufferedWriterTarget creates a target that coalesces log records into
+// fewer writes to an io.Writer. See `BufferOptions` for the durability tradeoff.
+// Output is never colorized.
+func NewBufferedWriterTarget(filter logr.Filter, formatter logr.Formatter, out io.Writer, opts BufferOptions, maxQueue int) *Writer {
+	return NewWriterTargetWithOptions(filter, formatter, out, WriterOptions{Buffer: &opts, Colorize: ColorizeNever}, maxQueue)
+}
+
+// NewWriterTargetWithOptions creates a target that outputs log records to an
+// io.Writer, optionally guarded by a lock shared with other writers and
+// optionally buffered.
+func NewWriterTargetWithOptions(filter logr.Filter, formatter logr.Formatter, out io.Writer, opts WriterOptions, maxQueue int) *Writer {
+	if out == nil {
+		out = ioutil.Discard
+	}
+	color := opts.Colorize.enabled(out)
+	if opts.Lock != nil {
+		out = &lockedWriter{w: out, lock: opts.Lock}
+	}
+	if opts.Buffer == nil {
+		w := &Writer{out: out, lock: opts.Lock, color: color, levelColors: opts.LevelColors}
+		w.Basic.Start(w, w, filter, formatter, maxQueue)
+		return w
+	}
+	size := opts.Buffer.Size
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	interval := opts.Buffer.FlushInterval
+	if interval <= 0 {
+		interval = DefaultBufferFlushInterval
+	}
+	w := &Writer{out: out, lock: opts.Lock, buf: bufio.NewWriterSize(out, size), quit: make(chan struct{}),
+		color: color, levelColors: opts.LevelColors}
+	w.Basic.Start(w, w, filter, formatter, maxQueue)
+	go w.startFlusher(interval)
+	return w
+}
+
+// Write converts the log record to bytes, via the Formatter,
+// and outputs to the io.Writer.
+func (w *Writer) Write(rec *logr.LogRec) error {
+	stacktrace := w.IncludeStacktrace(rec)
+
+	buf := rec.Logger().Logr().BorrowBuffer()
+	defer rec.Logger().Logr().ReleaseBuffer(buf)
+
+	opts := logr.FormatOptions{Stacktrace: stacktrace, Color: w.color, LevelColors: w.levelColors}
+	buf, err := logr.FormatWithOptions(w.Formatter(), rec, opts, buf)
+	if err != nil {
+		return err
+	}
+	if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	if w.buf == nil {
+		_, err = w.out.Write(buf.Bytes())
+		return err
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	_, err = w.buf.Write(buf.Bytes())
+	return err
+}
+
+// Flush writes any buffered records to the io.Writer. It is called
+// automatically when the target is flushed or shut down.
+func (w *Writer) Flush() error {
+	if w.buf == nil {
+		return nil
+	}
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.buf.Flush()
+}
+
+// Shutdown stops the target, writing any buffered records first, then
+// flushes or syncs the io.Writer if supported.
+func (w *Writer) Shutdown(ctx context.Context) error {
+	err := w.Basic.Shutdown(ctx)
+	if w.quit != nil {
+		close(w.quit)
+	}
+	if errSync := w.syncOut(); err == nil {
+		err = errSync
+	}
+	return err
+}
+
+// syncOut flushes or syncs the io.Writer, whichever it supports, holding the
+// shared lock if any.
+func (w *Writer) syncOut() error {
+	out := w.out
+	if lw, ok := out.(*lockedWriter); ok {
+		out = lw.w
+	}
+	var sync func() error
+	switch o := out.(type) {
+	case interface{ Flush() error }:
+		sync = o.Flush
+	case interface{ Sync() error }:
+		sync = o.Sync
+	default:
+		return nil
+	}
+	if w.lock != nil {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+	}
+	if err := sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err // EINVAL is returned syncing terminals and pipes, e.g. os.Stdout.
+	}
+	return nil
+}
+
+// lockedWriter holds a lock while writing to an io.Writer.
+type lockedWriter struct {
+	w    io.Writer
+	lock sync.Locker
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.lock.Lock()
+	defer lw.lock.Unlock()
+	return lw.w.Write(p)
+}
+
+// startFlusher periodically writes buffered records until the target is shut down.
+func (w *Writer) startFlusher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+			_ = w.Flush()
+		}
+	}
+}
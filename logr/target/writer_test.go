@@ -0,0 +1,181 @@
+package target_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	"github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/mattermost/logr/test"
+)
+
+func ExampleWriter() {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Warn, Stacktrace: logr.Error}
+	formatter := &format.Plain{Delim: " | "}
+	t := target.NewWriterTarget(filter, formatter, buf, 1000)
+	_ = lgr.AddTarget(t)
+
+	logger := lgr.NewLogger().WithField("name", "wiggin")
+
+	logger.Errorf("the erroneous data is %s", test.StringRnd(10))
+	logger.Warnf("strange data: %s", test.StringRnd(5))
+	logger.Debug("XXX")
+	logger.Trace("XXX")
+
+	err := lgr.Shutdown()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	output := buf.String()
+	fmt.Println(output)
+}
+
+func TestWriterPlain(t *testing.T) {
+	plain := &format.Plain{Delim: " | "}
+	writer(t, plain)
+}
+
+func TestWriterJSON(t *testing.T) {
+	json := &format.JSON{Indent: "  "}
+	writer(t, json)
+}
+
+func writer(t *testing.T, formatter logr.Formatter) {
+	lgr := &logr.Logr{}
+	buf := &test.Buffer{}
+	filter := &logr.StdFilter{Lvl: logr.Error, Stacktrace: logr.Error}
+	target := target.NewWriterTarget(filter, formatter, buf, 1000)
+	_ = lgr.AddTarget(target)
+
+	const goodToken = "Woot!"
+	const badToken = "XXX!!XXX"
+
+	cfg := test.DoSomeLoggingCfg{
+		Lgr:        lgr,
+		Goroutines: 10,
+		Loops:      50,
+		GoodToken:  goodToken,
+		BadToken:   badToken,
+		Lvl:        logr.Error,
+		Delay:      time.Millisecond * 1,
+	}
+	test.DoSomeLogging(cfg)
+	err := lgr.Shutdown()
+	if err != nil {
+		t.Error(err)
+	}
+
+	output := buf.String()
+	fmt.Println(output)
+
+	if !strings.Contains(output, goodToken) {
+		t.Errorf("missing warnings")
+	}
+
+	if strings.Contains(output, badToken) {
+		t.Errorf("wrong level(s) enabled")
+	}
+}
+
+func TestWriterColorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		colorize target.Colorize
+		want     bool
+	}{
+		{name: "always", colorize: target.ColorizeAlways, want: true},
+		{name: "never", colorize: target.ColorizeNever, want: false},
+		{name: "auto, not a terminal", colorize: target.ColorizeAuto, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lgr := &logr.Logr{}
+			buf := &test.Buffer{}
+			filter := &logr.StdFilter{Lvl: logr.Info}
+			opts := target.WriterOptions{Colorize: tt.colorize}
+			tgt := target.NewWriterTargetWithOptions(filter, &format.Plain{Delim: " | "}, buf, opts, 1000)
+			_ = lgr.AddTarget(tgt)
+
+			lgr.NewLogger().Error("colorful")
+			if err := lgr.Shutdown(); err != nil {
+				t.Error(err)
+			}
+
+			if got := strings.Contains(buf.String(), "\x1b["); got != tt.want {
+				t.Errorf("colorized = %t, want %t: %q", got, tt.want, buf.String())
+			}
+		})
+	}
+}
+
+// countingWriter counts the writes made to it.
+type countingWriter struct {
+	test.Buffer
+	mux    sync.Mutex
+	writes int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.mux.Lock()
+	cw.writes++
+	cw.mux.Unlock()
+	return cw.Buffer.Write(p)
+}
+
+func (cw *countingWriter) count() int {
+	cw.mux.Lock()
+	defer cw.mux.Unlock()
+	return cw.writes
+}
+
+func TestBufferedWriterCoalesces(t *testing.T) {
+	const records = 500
+	filter := &logr.StdFilter{Lvl: logr.Info}
+	formatter := &format.Plain{Delim: " | ", DisableTimestamp: true}
+
+	burst := func(newTarget func(out *countingWriter) logr.Target) *countingWriter {
+		out := &countingWriter{}
+		lgr := &logr.Logr{}
+		_ = lgr.AddTarget(newTarget(out))
+		logger := lgr.NewLogger()
+		for i := 0; i < records; i++ {
+			logger.Infof("record %d", i)
+		}
+		if err := lgr.Flush(); err != nil {
+			t.Error(err)
+		}
+		if n := strings.Count(out.String(), "\n"); n != records {
+			t.Errorf("expected %d records after flush, got %d", records, n)
+		}
+		if err := lgr.Shutdown(); err != nil {
+			t.Error(err)
+		}
+		return out
+	}
+
+	unbuffered := burst(func(out *countingWriter) logr.Target {
+		return target.NewWriterTarget(filter, formatter, out, records)
+	})
+	buffered := burst(func(out *countingWriter) logr.Target {
+		// the flush interval is long, so only the buffer filling or the flush
+		// write records.
+		opts := target.BufferOptions{Size: 64 * 1024, FlushInterval: time.Hour}
+		return target.NewBufferedWriterTarget(filter, formatter, out, opts, records)
+	})
+
+	t.Logf("writes for %d records: unbuffered %d, buffered %d", records, unbuffered.count(), buffered.count())
+	if n := unbuffered.count(); n != records {
+		t.Errorf("expected %d unbuffered writes, got %d", records, n)
+	}
+	if n := buffered.count(); n != 1 {
+		t.Errorf("expected 1 buffered write, got %d", n)
+	}
+}
@@ -0,0 +1,263 @@
+package logr_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+)
+
+// countTarget counts the log records written, and records whether it was
+// shut down and whether it was written to afterwards.
+type countTarget struct {
+	logr.Basic
+	count         int64
+	shutdown      int32
+	writeAfterEnd int32
+}
+
+func newCountTarget(name string) *countTarget {
+	ct := &countTarget{}
+	ct.Basic.Start(ct, ct, &logr.StdFilter{Lvl: logr.Info}, nil, 100)
+	ct.SetName(name)
+	return ct
+}
+
+func (ct *countTarget) Write(rec *logr.LogRec) error {
+	if atomic.LoadInt32(&ct.shutdown) != 0 {
+		atomic.StoreInt32(&ct.writeAfterEnd, 1)
+	}
+	atomic.AddInt64(&ct.count, 1)
+	return nil
+}
+
+func (ct *countTarget) Shutdown(ctx context.Context) error {
+	err := ct.Basic.Shutdown(ctx)
+	atomic.StoreInt32(&ct.shutdown, 1)
+	return err
+}
+
+func (ct *countTarget) written() int64 {
+	return atomic.LoadInt64(&ct.count)
+}
+
+func (ct *countTarget) isShutdown() bool {
+	return atomic.LoadInt32(&ct.shutdown) != 0
+}
+
+// logConcurrently logs records from several goroutines, returning a function
+// that waits for them to finish and returns the number logged.
+func logConcurrently(lgr *logr.Logr, workers int, records int) func() int64 {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger := lgr.NewLogger()
+			for i := 0; i < records; i++ {
+				logger.Info("concurrent")
+			}
+		}()
+	}
+	return func() int64 {
+		wg.Wait()
+		return int64(workers * records)
+	}
+}
+
+// waitWritten waits until the target has written at least n records.
+func waitWritten(ct *countTarget, n int64) {
+	for ct.written() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSetMaxQueueSizeWhileLogging(t *testing.T) {
+	lgr := &logr.Logr{MaxQueueSize: 10}
+	ct := newCountTarget("count")
+	_ = lgr.AddTarget(ct)
+
+	wait := logConcurrently(lgr, 8, 500)
+	done := make(chan struct{})
+	resized := make(chan struct{})
+	go func() {
+		defer close(resized)
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// shrinking fails while records are queued; growing always succeeds.
+			_ = lgr.SetMaxQueueSize(5 + i%3*50)
+		}
+	}()
+	logged := wait()
+	close(done)
+	<-resized
+	if err := lgr.SetMaxQueueSize(1000); err != nil {
+		t.Error(err)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if n := ct.written(); n != logged {
+		t.Errorf("expected %d records written across resizes, got %d", logged, n)
+	}
+	if stats := lgr.Stats(); stats.Dropped != 0 {
+		t.Errorf("expected no records dropped, got %d", stats.Dropped)
+	}
+	if err := lgr.SetMaxQueueSize(10); err != logr.ErrShutdown {
+		t.Errorf("expected ErrShutdown after shutdown, got %v", err)
+	}
+}
+
+func TestRemoveTargetWhileLogging(t *testing.T) {
+	lgr := &logr.Logr{}
+	kept := newCountTarget("kept")
+	removed := newCountTarget("removed")
+	_ = lgr.AddTarget(kept)
+	_ = lgr.AddTarget(removed)
+
+	wait := logConcurrently(lgr, 8, 500)
+	waitWritten(removed, 100)
+	if err := lgr.RemoveTarget(removed); err != nil {
+		t.Error(err)
+	}
+	logged := wait()
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if n := kept.written(); n != logged {
+		t.Errorf("expected %d records on the kept target, got %d", logged, n)
+	}
+	if !removed.isShutdown() {
+		t.Error("expected the removed target shut down")
+	}
+	if atomic.LoadInt32(&removed.writeAfterEnd) != 0 {
+		t.Error("removed target written after shutdown")
+	}
+	if n := removed.written(); n > logged {
+		t.Errorf("removed target wrote %d of %d records", n, logged)
+	}
+	if err := lgr.RemoveTarget(removed); err != logr.ErrTargetNotFound {
+		t.Errorf("expected ErrTargetNotFound removing again, got %v", err)
+	}
+}
+
+func TestRemoveTargetConcurrently(t *testing.T) {
+	lgr := &logr.Logr{}
+	ct := newCountTarget("count")
+	_ = lgr.AddTarget(ct)
+	defer lgr.Shutdown()
+
+	// exactly one of the concurrent removals succeeds.
+	const removers = 8
+	errs := make(chan error, removers)
+	var wg sync.WaitGroup
+	for i := 0; i < removers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- lgr.RemoveTarget(ct)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var removed int
+	for err := range errs {
+		switch err {
+		case nil:
+			removed++
+		case logr.ErrTargetNotFound:
+		default:
+			t.Error(err)
+		}
+	}
+	if removed != 1 {
+		t.Errorf("expected one removal to succeed, got %d", removed)
+	}
+	if lgr.HasTargets() {
+		t.Error("expected no targets left")
+	}
+}
+
+func TestRemoveTargetByNameWhileLogging(t *testing.T) {
+	lgr := &logr.Logr{}
+	targets := []*countTarget{newCountTarget("a"), newCountTarget("b"), newCountTarget("c")}
+	for _, ct := range targets {
+		_ = lgr.AddTarget(ct)
+	}
+
+	// Targets is called throughout; each snapshot is a consistent subset.
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				seen := make(map[logr.Target]bool)
+				for _, tgt := range lgr.Targets() {
+					if seen[tgt] {
+						t.Error("target listed twice")
+					}
+					seen[tgt] = true
+				}
+			}
+		}()
+	}
+
+	wait := logConcurrently(lgr, 8, 500)
+	waitWritten(targets[0], 100)
+	for _, name := range []string{"a", "c"} {
+		if err := lgr.RemoveTargetByName(name); err != nil {
+			t.Error(err)
+		}
+	}
+	logged := wait()
+	close(done)
+	readers.Wait()
+
+	if remaining := lgr.Targets(); len(remaining) != 1 || remaining[0] != targets[1] {
+		t.Errorf("expected only target b left, got %v", remaining)
+	}
+	if err := lgr.RemoveTargetByName("a"); err != logr.ErrTargetNotFound {
+		t.Errorf("expected ErrTargetNotFound, got %v", err)
+	}
+	if err := lgr.Shutdown(); err != nil {
+		t.Error(err)
+	}
+
+	if !targets[0].isShutdown() || !targets[2].isShutdown() {
+		t.Error("expected the removed targets shut down")
+	}
+	if n := targets[1].written(); n != logged {
+		t.Errorf("expected %d records on target b, got %d", logged, n)
+	}
+}
+
+func TestRemoveTargetByNameAmbiguous(t *testing.T) {
+	lgr := &logr.Logr{}
+	_ = lgr.AddTarget(newCountTarget("dup"))
+	_ = lgr.AddTarget(newCountTarget("dup"))
+	defer lgr.Shutdown()
+
+	if err := lgr.RemoveTargetByName("dup"); err == nil || err == logr.ErrTargetNotFound {
+		t.Errorf("expected an ambiguous name error, got %v", err)
+	}
+	if n := len(lgr.Targets()); n != 2 {
+		t.Errorf("expected no target removed, got %d targets", n)
+	}
+}
@@ -0,0 +1,56 @@
+package logr
+
+// Tee creates a new `Logger` whose log records are also output to the extra
+// target, in addition to the Logr's targets, subject to the extra target's
+// level filter. Other loggers are unaffected. This is useful for temporarily
+// capturing one subsystem's logging in more detail, e.g. to a verbose file.
+//
+// The extra target is not added to the Logr; the caller is responsible for
+// its lifecycle, including shutting it down once the logger is no longer used.
+// `Logr.Flush` does not flush the extra target.
+func (logger Logger) Tee(extra Target) Logger {
+	l := logger
+	l.tees = make([]Target, 0, len(logger.tees)+1)
+	l.tees = append(l.tees, logger.tees...)
+	l.tees = append(l.tees, extra)
+	return l
+}
+
+// teeLevelStatus merges the level status of any extra targets added via
+// `Logger.Tee` into status.
+func (logger Logger) teeLevelStatus(lvl Level, status LevelStatus) LevelStatus {
+	for _, t := range logger.tees {
+		enabled, stacktrace := t.IsLevelEnabled(lvl)
+		status.Enabled = status.Enabled || enabled
+		status.Stacktrace = status.Stacktrace || (enabled && stacktrace)
+	}
+	return status
+}
+
+// fanoutTees pushes a LogRec to any extra targets added via `Logger.Tee`.
+// Returns true if any extra target accepted the record.
+func fanoutTees(rec *LogRec) bool {
+	var logged bool
+	for _, t := range rec.logger.tees {
+		if enabled, _ := t.IsLevelEnabled(rec.Level()); enabled {
+			retainFor(t, rec)
+			if logTee(t, rec) {
+				logged = true
+			}
+		}
+	}
+	return logged
+}
+
+// logTee delivers a LogRec to an extra target, recovering from a panic in the
+// target's `Log` like `fanout` does for Logr targets. Returns false if the
+// target panicked.
+func logTee(t Target, rec *LogRec) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			rec.logger.logr.targetPanicked(t, rec, r)
+		}
+	}()
+	t.Log(rec)
+	return true
+}
@@ -16,8 +16,8 @@ func onLoggerError(err error) {
 // onQueueFull is called when the main logger queue is full, indicating the
 // volume and frequency of log record creation is too high for the queue size
 // and/or the target latencies.
-func onQueueFull(rec *logr.LogRec, maxQueueSize int) bool {
-	Log(LvlLogError, "main queue full, dropping record", Any("rec", rec))
+func onQueueFull(rec *logr.LogRec, stats logr.QueueFullStats) bool {
+	Log(LvlLogError, "main queue full, dropping record", Any("rec", rec), Uint64("dropped", stats.Dropped))
 	return true // drop record
 }
 
@@ -33,6 +33,7 @@ var Int64 = zap.Int64
 var Int32 = zap.Int32
 var Int = zap.Int
 var Uint32 = zap.Uint32
+var Uint64 = zap.Uint64
 var String = zap.String
 var Any = zap.Any
 var Err = zap.Error
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/mattermost/logr"
@@ -109,7 +110,7 @@ func newFilter(name string, levels []LogLevel) (logr.Filter, error) {
 func newFormatter(name string, format string) (logr.Formatter, error) {
 	switch format {
 	case "json", "":
-		return &logrFmt.JSON{}, nil
+		return &logrFmt.JSON{TimestampFormat: logr.DefTimestampFormat}, nil
 	case "plain":
 		return &logrFmt.Plain{Delim: " | "}, nil
 	default:
@@ -140,14 +141,34 @@ func newConsoleTarget(name string, t *LogTarget, filter logr.Filter, formatter l
 	return newTarget, nil
 }
 
-func newFileTarget(name string, t *LogTarget, filter logr.Filter, formatter logr.Formatter) (logr.Target, error) {
-	type fileOptions struct {
-		Filename   string `json:"Filename"`
-		MaxSize    int    `json:"MaxSizeMB"`
-		MaxAge     int    `json:"MaxAgeDays"`
-		MaxBackups int    `json:"MaxBackups"`
-		Compress   bool   `json:"Compress"`
+// fileOptions are the options of a "file" target, decoded from its JSON config.
+type fileOptions struct {
+	Filename   string `json:"Filename"`
+	MaxSize    int    `json:"MaxSizeMB"`
+	MaxAge     int    `json:"MaxAgeDays"`
+	MaxBackups int    `json:"MaxBackups"`
+	Compress   bool   `json:"Compress"`
+	LockFile   bool   `json:"LockFile"`
+
+	ReopenOnSignal            bool  `json:"ReopenOnSignal"`
+	ReopenCheckIntervalMillis int64 `json:"ReopenCheckIntervalMillis"`
+}
+
+// targetOptions converts the config options to those of the logr file target.
+func (o *fileOptions) targetOptions() target.FileOptions {
+	return target.FileOptions{
+		Filename:            o.Filename,
+		MaxSize:             o.MaxSize,
+		MaxAge:              o.MaxAge,
+		MaxBackups:          o.MaxBackups,
+		Compress:            o.Compress,
+		LockFile:            o.LockFile,
+		ReopenOnSignal:      o.ReopenOnSignal,
+		ReopenCheckInterval: time.Duration(o.ReopenCheckIntervalMillis) * time.Millisecond,
 	}
+}
+
+func newFileTarget(name string, t *LogTarget, filter logr.Filter, formatter logr.Formatter) (logr.Target, error) {
 	options := &fileOptions{}
 	if err := json.Unmarshal(t.Options, options); err != nil {
 		return nil, err
@@ -160,7 +181,7 @@ func newFileTarget(name string, t *LogTarget, filter logr.Filter, formatter logr
 		return nil, fmt.Errorf("error writing to 'Filename' for target %s: %w", name, err)
 	}
 
-	newTarget := target.NewFileTarget(filter, formatter, target.FileOptions(*options), t.MaxQueueSize)
+	newTarget := target.NewFileTarget(filter, formatter, options.targetOptions(), t.MaxQueueSize)
 	return newTarget, nil
 }
 
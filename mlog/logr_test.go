@@ -0,0 +1,48 @@
+// Copyright (c) 2015-present Mattermost, Inc. All Rights Reserved.
+// See LICENSE.txt for license information.
+
+package mlog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mattermost/logr"
+	logrFmt "github.com/mattermost/logr/format"
+	"github.com/mattermost/logr/target"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFormatter(t *testing.T) {
+	t.Run("json keeps the logr timestamp format", func(t *testing.T) {
+		formatter, err := newFormatter("test", "json")
+		require.NoError(t, err)
+
+		jsonFmt, ok := formatter.(*logrFmt.JSON)
+		require.True(t, ok)
+		require.Equal(t, logr.DefTimestampFormat, jsonFmt.TimestampFormat)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := newFormatter("test", "xml")
+		require.Error(t, err)
+	})
+}
+
+func TestFileOptions(t *testing.T) {
+	options := &fileOptions{}
+	err := json.Unmarshal([]byte(`{"Filename":"app.log","MaxSizeMB":10,"MaxAgeDays":3,"MaxBackups":2,"Compress":true,"LockFile":true,"ReopenOnSignal":true,"ReopenCheckIntervalMillis":5000}`), options)
+	require.NoError(t, err)
+
+	require.Equal(t, target.FileOptions{
+		Filename:            "app.log",
+		MaxSize:             10,
+		MaxAge:              3,
+		MaxBackups:          2,
+		Compress:            true,
+		LockFile:            true,
+		ReopenOnSignal:      true,
+		ReopenCheckInterval: 5 * time.Second,
+	}, options.targetOptions())
+}
@@ -103,7 +103,7 @@ func getCertPool(cert string) (*x509.CertPool, error) {
 // Write converts the log record to bytes, via the Formatter,
 // and outputs to syslog.
 func (s *Syslog) Write(rec *logr.LogRec) error {
-	_, stacktrace := s.IsLevelEnabled(rec.Level())
+	stacktrace := s.IncludeStacktrace(rec)
 
 	buf := rec.Logger().Logr().BorrowBuffer()
 	defer rec.Logger().Logr().ReleaseBuffer(buf)
@@ -172,7 +172,7 @@ func (tcp *Tcp) Shutdown(ctx context.Context) error {
 // Write converts the log record to bytes, via the Formatter, and outputs to the socket.
 // Called by dedicated target goroutine and will block until success or shutdown.
 func (tcp *Tcp) Write(rec *logr.LogRec) error {
-	_, stacktrace := tcp.IsLevelEnabled(rec.Level())
+	stacktrace := tcp.IncludeStacktrace(rec)
 
 	buf := rec.Logger().Logr().BorrowBuffer()
 	defer rec.Logger().Logr().ReleaseBuffer(buf)